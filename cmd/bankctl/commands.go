@@ -0,0 +1,370 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/database"
+	"github.com/FaizanAC/Go-Banking/internal/mailer"
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/repository"
+	"github.com/FaizanAC/Go-Banking/internal/server/services"
+	"github.com/FaizanAC/Go-Banking/internal/util"
+)
+
+func (c *ctl) user(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "create":
+		return c.userCreate(args[1:])
+
+	case "show":
+		if len(args) != 2 {
+			return errUsage
+		}
+		if err := c.require(models.PERMISSION_READ_USERS); err != nil {
+			return err
+		}
+
+		user, err := c.findUser(args[1])
+		if err != nil {
+			return err
+		}
+		return c.out.print(user.Response(), userHeader, userRow(user))
+
+	default:
+		return errUsage
+	}
+}
+
+func (c *ctl) userCreate(args []string) error {
+	flags := flag.NewFlagSet("user create", flag.ContinueOnError)
+	email := flags.String("email", "", "email address")
+	firstName := flags.String("first", "", "first name")
+	lastName := flags.String("last", "", "last name")
+	passwordStdin := flags.Bool("password-stdin", false, "read the initial password from stdin; random if left out")
+	role := flags.String("role", models.ROLE_CUSTOMER, "role")
+	verified := flags.Bool("verified", false, "mark the email address as verified")
+	if positional, err := parseFlags(flags, args); err != nil || len(positional) > 0 || *email == "" {
+		return errUsage
+	}
+
+	if !models.IsValidRole(*role) {
+		return errUsage
+	}
+
+	// The first admin is created before there is anyone to name as operator.
+	if c.operator != nil || *role != models.ROLE_ADMIN {
+		if err := c.requireOperator(models.PERMISSION_MANAGE_USERS); err != nil {
+			return err
+		}
+	} else if err := c.requireNoAdmin(); err != nil {
+		return err
+	}
+
+	// Without a password on stdin the password is random, and the user sets
+	// one through the reset flow.
+	password, err := util.GenerateToken(16)
+	if err != nil {
+		return err
+	}
+	if *passwordStdin {
+		if password, err = c.readPassword(); err != nil {
+			return err
+		}
+	}
+
+	mail, err := mailer.NewMailerFromEnv()
+	if err != nil {
+		return err
+	}
+
+	user := models.User{Email: *email, FirstName: *firstName, LastName: *lastName, Password: password}
+	if err := services.NewUserService(repository.NewGormStore(c.db), mail).CreateUser(&user, c.actor.ClientInfo); err != nil {
+		return err
+	}
+
+	if *role != models.ROLE_CUSTOMER {
+		if user, err = c.admin.UpdateRole(c.actor, user.ID, *role); err != nil {
+			return err
+		}
+	}
+
+	if *verified {
+		if user, err = c.admin.VerifyEmail(c.actor, user.ID); err != nil {
+			return err
+		}
+	}
+
+	return c.out.print(user.Response(), userHeader, userRow(user))
+}
+
+// requireNoAdmin fails once an admin exists, who must then be the operator.
+func (c *ctl) requireNoAdmin() error {
+	var admins int64
+	if err := c.db.Model(&models.User{}).Where("role = ?", models.ROLE_ADMIN).Count(&admins).Error; err != nil {
+		return err
+	}
+	if admins > 0 {
+		return errOperatorRequired
+	}
+	return nil
+}
+
+// readPassword reads a password from the first line of stdin, so that it
+// does not show up in the process list or shell history.
+func (c *ctl) readPassword() (string, error) {
+	line, err := bufio.NewReader(c.in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("no password on stdin")
+	}
+	return password, nil
+}
+
+func (c *ctl) account(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "open":
+		if len(args) != 2 {
+			return errUsage
+		}
+		if err := c.requireOperator(models.PERMISSION_MANAGE_ACCOUNTS); err != nil {
+			return err
+		}
+
+		user, err := c.findUser(args[1])
+		if err != nil {
+			return err
+		}
+
+		account, err := c.bank.CreateAccountFor(user.ID, c.actor)
+		if err != nil {
+			return err
+		}
+		return c.out.print(account, accountHeader, accountRow(account))
+
+	case "list":
+		if len(args) != 2 {
+			return errUsage
+		}
+		if err := c.require(models.PERMISSION_READ_USERS); err != nil {
+			return err
+		}
+
+		user, err := c.findUser(args[1])
+		if err != nil {
+			return err
+		}
+
+		accounts, err := c.admin.GetUserAccounts(user.ID)
+		if err != nil {
+			return err
+		}
+
+		rows := [][]string{}
+		for _, account := range accounts {
+			rows = append(rows, accountRow(account))
+		}
+		return c.out.print(accounts, accountHeader, rows...)
+
+	case "show":
+		if len(args) != 2 {
+			return errUsage
+		}
+		if err := c.require(models.PERMISSION_READ_TRANSACTIONS); err != nil {
+			return err
+		}
+
+		account, err := c.admin.GetAccount(args[1])
+		if err != nil {
+			return err
+		}
+		return c.out.print(account, accountHeader, accountRow(account))
+
+	case "history":
+		return c.accountHistory(args[1:])
+
+	case "freeze", "unfreeze":
+		if len(args) != 2 {
+			return errUsage
+		}
+		if err := c.requireOperator(models.PERMISSION_FREEZE_ACCOUNTS); err != nil {
+			return err
+		}
+
+		account, err := c.admin.SetAccountFrozen(c.actor, args[1], args[0] == "freeze")
+		if err != nil {
+			return err
+		}
+		return c.out.print(account, accountHeader, accountRow(account))
+
+	case "close":
+		if len(args) != 2 {
+			return errUsage
+		}
+		if err := c.requireOperator(models.PERMISSION_MANAGE_ACCOUNTS); err != nil {
+			return err
+		}
+
+		account, err := c.admin.CloseAccount(c.actor, args[1])
+		if err != nil {
+			return err
+		}
+		return c.out.print(account, accountHeader, accountRow(account))
+
+	case "adjust":
+		return c.accountAdjust(args[1:])
+
+	default:
+		return errUsage
+	}
+}
+
+func (c *ctl) accountHistory(args []string) error {
+	flags := flag.NewFlagSet("account history", flag.ContinueOnError)
+	page := flags.Int("page", 1, "page number")
+	pageSize := flags.Int("size", 20, "transactions per page")
+	positional, err := parseFlags(flags, args)
+	if err != nil || len(positional) != 1 || *page < 1 || *pageSize < 1 {
+		return errUsage
+	}
+
+	if err := c.require(models.PERMISSION_READ_TRANSACTIONS); err != nil {
+		return err
+	}
+
+	transactions, err := c.admin.GetAccountTransactions(positional[0], *page, *pageSize)
+	if err != nil {
+		return err
+	}
+
+	rows := [][]string{}
+	for _, transaction := range transactions {
+		rows = append(rows, transactionRow(transaction))
+	}
+	return c.out.print(transactions, transactionHeader, rows...)
+}
+
+func (c *ctl) accountAdjust(args []string) error {
+	flags := flag.NewFlagSet("account adjust", flag.ContinueOnError)
+	amount := flags.Float64("amount", 0, "amount to credit, or debit when negative")
+	reason := flags.String("reason", "", "why the adjustment is made")
+	positional, err := parseFlags(flags, args)
+	if err != nil || len(positional) != 1 {
+		return errUsage
+	}
+
+	if err := c.requireOperator(models.PERMISSION_MANAGE_ACCOUNTS); err != nil {
+		return err
+	}
+
+	account, transaction, err := c.admin.AdjustBalance(c.actor, positional[0], *amount, *reason)
+	if err != nil {
+		return err
+	}
+
+	return c.out.print(map[string]interface{}{"account": account, "transaction": transaction},
+		transactionHeader, transactionRow(transaction))
+}
+
+func (c *ctl) transfer(args []string) error {
+	if len(args) != 1 || args[0] != "expire" {
+		return errUsage
+	}
+
+	if err := c.requireOperator(models.PERMISSION_MANAGE_ACCOUNTS); err != nil {
+		return err
+	}
+
+	expired, err := c.admin.ExpireTransfers(c.actor, time.Now())
+	if err != nil {
+		return err
+	}
+
+	rows := [][]string{}
+	for _, transfer := range expired {
+		rows = append(rows, transferRow(transfer))
+	}
+	return c.out.print(expired, transferHeader, rows...)
+}
+
+// migrate runs `migrate up | down [N] | to VERSION` and then prints the
+// status of every migration, which `migrate status` prints on its own.
+func (c *ctl) migrate(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	migrator, err := database.NewMigrator(c.db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		_, err = migrator.Up()
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return errUsage
+			}
+		}
+		_, err = migrator.Down(steps)
+
+	case "to":
+		if len(args) != 2 {
+			return errUsage
+		}
+		version, parseErr := strconv.ParseUint(args[1], 10, 32)
+		if parseErr != nil {
+			return errUsage
+		}
+		_, err = migrator.To(uint(version))
+
+	case "status":
+
+	default:
+		return errUsage
+	}
+	if err != nil {
+		return err
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	rows := [][]string{}
+	for _, status := range statuses {
+		rows = append(rows, migrationRow(status))
+	}
+	return c.out.print(statuses, migrationHeader, rows...)
+}
+
+// findUser looks a user up by id or email address.
+func (c *ctl) findUser(ref string) (models.User, error) {
+	if id, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		return c.admin.GetUser(uint(id))
+	}
+	return c.admin.GetUserByEmail(ref)
+}
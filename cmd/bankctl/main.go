@@ -0,0 +1,177 @@
+// Command bankctl lets support staff look up and fix users and accounts
+// through the same services as the API, with every change audited.
+//
+//	bankctl [-o table|json] [-as EMAIL] COMMAND
+//
+//	user create -email EMAIL -first NAME -last NAME [-password-stdin] [-role ROLE] [-verified]
+//	user show USER
+//	account open USER
+//	account list USER
+//	account show NUMBER
+//	account history NUMBER [-page N] [-size N]
+//	account freeze | unfreeze | close NUMBER
+//	account adjust NUMBER -amount AMOUNT -reason REASON
+//	transfer expire
+//	migrate up | down [N] | status | to VERSION
+//
+// USER is a user id or email address. -as, or BANKCTL_OPERATOR, names the
+// staff member running the command: changes are attributed to them and
+// limited to what their role allows. Commands that change data need an
+// operator, except creating the first admin.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/FaizanAC/Go-Banking/internal/database"
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/repository"
+	"github.com/FaizanAC/Go-Banking/internal/server/services"
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+)
+
+const USAGE = `usage: bankctl [-o table|json] [-as EMAIL] COMMAND
+
+  user create -email EMAIL -first NAME -last NAME [-password-stdin] [-role ROLE] [-verified]
+  user show USER
+  account open USER
+  account list USER
+  account show NUMBER
+  account history NUMBER [-page N] [-size N]
+  account freeze | unfreeze | close NUMBER
+  account adjust NUMBER -amount AMOUNT -reason REASON
+  transfer expire
+  migrate up | down [N] | status | to VERSION`
+
+var (
+	errUsage            = errors.New("invalid usage")
+	errOperatorRequired = errors.New("this command changes data; name the operator with -as or BANKCTL_OPERATOR")
+)
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("no .env file loaded")
+	}
+
+	err := run(database.NewDatabase(), os.Args[1:], os.Stdin, os.Stdout)
+	if errors.Is(err, errUsage) {
+		fmt.Fprintln(os.Stderr, USAGE)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bankctl:", err)
+		os.Exit(1)
+	}
+}
+
+// ctl runs one command against the database.
+type ctl struct {
+	db    *gorm.DB
+	in    io.Reader
+	out   output
+	admin *services.AdminService
+	bank  *services.BankService
+
+	// operator is nil when no operator was named.
+	operator *models.User
+	actor    models.Actor
+}
+
+func run(db *gorm.DB, args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("bankctl", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	format := flags.String("o", FORMAT_TABLE, "output format, table or json")
+	operator := flags.String("as", os.Getenv("BANKCTL_OPERATOR"), "email of the staff member running the command")
+	if err := flags.Parse(args); err != nil || flags.NArg() < 1 {
+		return errUsage
+	}
+	if *format != FORMAT_TABLE && *format != FORMAT_JSON {
+		return errUsage
+	}
+
+	store := repository.NewGormStore(db)
+	c := &ctl{
+		db:    db,
+		in:    stdin,
+		out:   output{w: stdout, format: *format},
+		admin: services.NewAdminService(store),
+		bank:  services.NewBankService(store),
+		actor: models.Actor{
+			AuthMethod: models.AUTH_METHOD_CLI,
+			ClientInfo: models.ClientInfo{UserAgent: "bankctl", RequestID: uuid.New().String()},
+		},
+	}
+
+	command, args := flags.Arg(0), flags.Args()[1:]
+	if command == "migrate" {
+		return c.migrate(args)
+	}
+
+	if err := database.CheckSchema(db); err != nil {
+		return fmt.Errorf("%w; run `bankctl migrate up` first", err)
+	}
+
+	if *operator != "" {
+		user, err := c.admin.GetUserByEmail(*operator)
+		if err != nil {
+			return fmt.Errorf("operator %s not found", *operator)
+		}
+		c.operator = &user
+		c.actor.UserID = user.ID
+	}
+
+	switch command {
+	case "user":
+		return c.user(args)
+	case "account":
+		return c.account(args)
+	case "transfer":
+		return c.transfer(args)
+	default:
+		return errUsage
+	}
+}
+
+// require fails unless the operator's role grants permission. Without an
+// operator the command runs unrestricted, as anyone with database access
+// could, so only commands that just read use it.
+func (c *ctl) require(permission models.Permission) error {
+	if c.operator != nil && !models.HasPermission(c.operator.Role, permission) {
+		return fmt.Errorf("%s does not have the %s permission", c.operator.Email, permission)
+	}
+	return nil
+}
+
+// requireOperator is require for commands that change data, which must be
+// attributed to a named operator.
+func (c *ctl) requireOperator(permission models.Permission) error {
+	if c.operator == nil {
+		return errOperatorRequired
+	}
+	return c.require(permission)
+}
+
+// parseFlags parses args with flags, allowing flags after the positional
+// arguments, and returns the positional arguments.
+func parseFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	flags.SetOutput(io.Discard)
+
+	positional := []string{}
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, errUsage
+		}
+		if flags.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
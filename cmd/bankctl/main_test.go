@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/repository"
+	"github.com/FaizanAC/Go-Banking/internal/server/services"
+	"github.com/FaizanAC/Go-Banking/tests/integration/testdb"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+func bankctl(t *testing.T, db *gorm.DB, args ...string) (string, error) {
+	t.Helper()
+
+	return bankctlInput(t, db, "", args...)
+}
+
+// bankctlInput runs a command with stdin.
+func bankctlInput(t *testing.T, db *gorm.DB, stdin string, args ...string) (string, error) {
+	t.Helper()
+
+	var stdout bytes.Buffer
+	err := run(db, args, strings.NewReader(stdin), &stdout)
+	return stdout.String(), err
+}
+
+// operateAsAdmin creates the first admin, which needs no operator, and runs
+// the test's later commands as them through BANKCTL_OPERATOR.
+func operateAsAdmin(t *testing.T, db *gorm.DB) {
+	t.Helper()
+
+	_, err := bankctl(t, db, "user", "create", "-email", "admin@example.com", "-first", "Ada", "-last", "Admin", "-role", "admin", "-verified")
+	assert.Nil(t, err)
+	t.Setenv("BANKCTL_OPERATOR", "admin@example.com")
+}
+
+// bankctlJSON runs a command that must succeed and decodes its JSON output.
+func bankctlJSON(t *testing.T, db *gorm.DB, value interface{}, args ...string) {
+	t.Helper()
+
+	out, err := bankctl(t, db, append([]string{"-o", "json"}, args...)...)
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal([]byte(out), value), out)
+}
+
+func TestUsersAndAccounts(t *testing.T) {
+	db := testdb.Open(t)
+
+	var admin models.UserResponse
+	bankctlJSON(t, db, &admin, "user", "create", "-email", "admin@example.com", "-first", "Ada", "-last", "Admin", "-role", "admin", "-verified")
+	assert.Equal(t, models.ROLE_ADMIN, admin.Role)
+	assert.NotNil(t, admin.EmailVerifiedAt)
+
+	var customer models.UserResponse
+	bankctlJSON(t, db, &customer, "-as", "admin@example.com", "user", "create", "-email", "Carl@Example.com", "-first", "Carl", "-last", "Customer")
+	assert.Equal(t, models.ROLE_CUSTOMER, customer.Role)
+
+	var account models.BankAccount
+	bankctlJSON(t, db, &account, "-as", "admin@example.com", "account", "open", "carl@example.com")
+	assert.Equal(t, customer.ID, account.UserID)
+
+	out, err := bankctl(t, db, "account", "list", "carl@example.com")
+	assert.Nil(t, err)
+	assert.Contains(t, out, "ACCOUNT")
+	assert.Contains(t, out, account.AccountNumber)
+
+	// Changes are attributed to the operator.
+	var entry models.AuditLog
+	assert.Nil(t, db.Where("action = ? AND entity_id = ?", models.AUDIT_ACCOUNT_CREATE, account.AccountNumber).First(&entry).Error)
+	assert.Equal(t, admin.ID, *entry.ActorID)
+	assert.Equal(t, models.AUTH_METHOD_CLI, entry.AuthMethod)
+
+	// Support staff can look things up but not change them.
+	_, err = bankctl(t, db, "-as", "admin@example.com", "user", "create", "-email", "sue@example.com", "-first", "Sue", "-last", "Support", "-role", "support")
+	assert.Nil(t, err)
+
+	_, err = bankctl(t, db, "-as", "sue@example.com", "account", "show", account.AccountNumber)
+	assert.Nil(t, err)
+
+	_, err = bankctl(t, db, "-as", "sue@example.com", "account", "freeze", account.AccountNumber)
+	assert.EqualError(t, err, "sue@example.com does not have the accounts:freeze permission")
+
+	_, err = bankctl(t, db, "-as", "nobody@example.com", "account", "show", account.AccountNumber)
+	assert.EqualError(t, err, "operator nobody@example.com not found")
+
+	_, err = bankctl(t, db, "-as", "admin@example.com", "account", "open", "nobody@example.com")
+	assert.EqualError(t, err, "user not found")
+
+	_, err = bankctl(t, db, "account", "bogus")
+	assert.ErrorIs(t, err, errUsage)
+}
+
+func TestAdjustAndClose(t *testing.T) {
+	db := testdb.Open(t)
+	operateAsAdmin(t, db)
+
+	_, err := bankctl(t, db, "user", "create", "-email", "carl@example.com", "-first", "Carl", "-last", "Customer")
+	assert.Nil(t, err)
+
+	var account models.BankAccount
+	bankctlJSON(t, db, &account, "account", "open", "carl@example.com")
+	number := account.AccountNumber
+
+	var adjusted struct {
+		Account     models.BankAccount `json:"account"`
+		Transaction models.Transaction `json:"transaction"`
+	}
+	bankctlJSON(t, db, &adjusted, "account", "adjust", number, "-amount", "100", "-reason", "goodwill credit")
+	assert.Equal(t, 100.0, adjusted.Account.Balance)
+	assert.Equal(t, services.ADJUSTMENT_CREDIT, adjusted.Transaction.Type)
+
+	bankctlJSON(t, db, &adjusted, "account", "adjust", number, "-amount", "-30", "-reason", "reverse duplicate credit")
+	assert.Equal(t, 70.0, adjusted.Account.Balance)
+	assert.Equal(t, services.ADJUSTMENT_DEBIT, adjusted.Transaction.Type)
+	assert.Equal(t, 30.0, adjusted.Transaction.Amount)
+
+	var entry models.AuditLog
+	assert.Nil(t, db.Where("action = ? AND entity_id = ?", models.AUDIT_ACCOUNT_ADJUST, adjusted.Transaction.TransactionID).First(&entry).Error)
+	assert.Contains(t, string(entry.After), `"reason":"reverse duplicate credit"`)
+
+	_, err = bankctl(t, db, "account", "adjust", number, "-amount", "5")
+	assert.ErrorIs(t, err, services.ErrReasonRequired)
+
+	_, err = bankctl(t, db, "account", "adjust", number, "-amount", "-500", "-reason", "too much")
+	assert.ErrorIs(t, err, services.ErrInsufficientBalance)
+
+	out, err := bankctl(t, db, "account", "history", number)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, strings.Count(out, "\n"), out)
+	assert.Contains(t, out, "ADJUSTMENT_DEBIT   30.00")
+
+	_, err = bankctl(t, db, "account", "close", number)
+	assert.ErrorIs(t, err, services.ErrAccountNotEmpty)
+
+	_, err = bankctl(t, db, "account", "adjust", number, "-amount", "-70", "-reason", "pay out before closing")
+	assert.Nil(t, err)
+
+	bankctlJSON(t, db, &account, "account", "close", number)
+	assert.True(t, account.IsClosed())
+
+	_, err = bankctl(t, db, "account", "adjust", number, "-amount", "10", "-reason", "after closing")
+	assert.ErrorIs(t, err, services.ErrAccountClosed)
+
+	result, err := services.NewLedgerService(db).Verify(number)
+	assert.Nil(t, err)
+	assert.True(t, result.Valid)
+}
+
+func TestExpireTransfers(t *testing.T) {
+	db := testdb.Open(t)
+	operateAsAdmin(t, db)
+
+	var sender, receiver models.UserResponse
+	bankctlJSON(t, db, &sender, "user", "create", "-email", "sender@example.com", "-first", "Sam", "-last", "Sender")
+	bankctlJSON(t, db, &receiver, "user", "create", "-email", "receiver@example.com", "-first", "Rita", "-last", "Receiver")
+
+	var senderAccount, receiverAccount models.BankAccount
+	bankctlJSON(t, db, &senderAccount, "account", "open", "sender@example.com")
+	bankctlJSON(t, db, &receiverAccount, "account", "open", "receiver@example.com")
+	_, err := bankctl(t, db, "account", "adjust", senderAccount.AccountNumber, "-amount", "50", "-reason", "opening balance")
+	assert.Nil(t, err)
+
+	bankService := services.NewBankService(repository.NewGormStore(db))
+	_, err = bankService.SendTransfer(models.OutgoingTransfer{Amount: 50, AccountNumber: senderAccount.AccountNumber, ReceiverID: receiver.ID}, models.Actor{UserID: sender.ID})
+	assert.Nil(t, err)
+
+	_, err = bankctl(t, db, "account", "close", senderAccount.AccountNumber)
+	assert.ErrorIs(t, err, services.ErrPendingTransfers)
+
+	// Nothing has expired yet.
+	out, err := bankctl(t, db, "-o", "json", "transfer", "expire")
+	assert.Nil(t, err)
+	assert.Equal(t, "[]\n", out)
+
+	var transfer models.Transfer
+	assert.Nil(t, db.Where("sender_id = ?", sender.ID).First(&transfer).Error)
+	assert.Nil(t, db.Model(&transfer).Update("expires_on", time.Now().Add(-time.Hour)).Error)
+
+	var expired []models.Transfer
+	bankctlJSON(t, db, &expired, "transfer", "expire")
+	assert.Len(t, expired, 1)
+	assert.Equal(t, services.EXPIRED, expired[0].Status)
+
+	bankctlJSON(t, db, &senderAccount, "account", "show", senderAccount.AccountNumber)
+	assert.Equal(t, 50.0, senderAccount.Balance)
+
+	_, err = bankService.AcceptTransfer(models.IncomingTransfer{TransactionID: transfer.TransactionID, AccountNumber: receiverAccount.AccountNumber}, models.Actor{UserID: receiver.ID})
+	assert.ErrorIs(t, err, services.ErrTransferNotPending)
+
+	// Expiring again finds nothing left to refund.
+	bankctlJSON(t, db, &expired, "transfer", "expire")
+	assert.Len(t, expired, 0)
+
+	result, err := services.NewLedgerService(db).Verify(senderAccount.AccountNumber)
+	assert.Nil(t, err)
+	assert.True(t, result.Valid)
+}
+
+func TestChangesNeedAnOperator(t *testing.T) {
+	db := testdb.Open(t)
+
+	_, err := bankctl(t, db, "user", "create", "-email", "carl@example.com", "-first", "Carl", "-last", "Customer")
+	assert.ErrorIs(t, err, errOperatorRequired)
+
+	// Only the first admin can be created without one.
+	var admin models.UserResponse
+	bankctlJSON(t, db, &admin, "user", "create", "-email", "admin@example.com", "-first", "Ada", "-last", "Admin", "-role", "admin")
+	assert.Equal(t, models.ROLE_ADMIN, admin.Role)
+
+	_, err = bankctl(t, db, "user", "create", "-email", "eve@example.com", "-first", "Eve", "-last", "Admin", "-role", "admin")
+	assert.ErrorIs(t, err, errOperatorRequired)
+
+	_, err = bankctl(t, db, "account", "open", "admin@example.com")
+	assert.ErrorIs(t, err, errOperatorRequired)
+
+	_, err = bankctl(t, db, "transfer", "expire")
+	assert.ErrorIs(t, err, errOperatorRequired)
+
+	// Looking things up still works.
+	_, err = bankctl(t, db, "user", "show", "admin@example.com")
+	assert.Nil(t, err)
+}
+
+func TestUserCreateReadsPasswordFromStdin(t *testing.T) {
+	db := testdb.Open(t)
+	operateAsAdmin(t, db)
+
+	_, err := bankctlInput(t, db, "correct horse battery staple\n", "user", "create", "-email", "carl@example.com", "-first", "Carl", "-last", "Customer", "-password-stdin")
+	assert.Nil(t, err)
+
+	var user models.User
+	assert.Nil(t, db.Where("email = ?", "carl@example.com").First(&user).Error)
+	assert.Nil(t, bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("correct horse battery staple")))
+
+	_, err = bankctlInput(t, db, "", "user", "create", "-email", "dana@example.com", "-first", "Dana", "-last", "Customer", "-password-stdin")
+	assert.EqualError(t, err, "no password on stdin")
+
+	_, err = bankctl(t, db, "user", "create", "-email", "dana@example.com", "-first", "Dana", "-last", "Customer", "-password", "hunter2")
+	assert.ErrorIs(t, err, errUsage)
+}
+
+func TestMigrateStatus(t *testing.T) {
+	db := testdb.Open(t)
+
+	out, err := bankctl(t, db, "migrate", "status")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(out, "VERSION"), out)
+	assert.Contains(t, out, "admin_operations")
+	assert.NotContains(t, out, "pending")
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/database"
+	"github.com/FaizanAC/Go-Banking/internal/models"
+)
+
+const (
+	FORMAT_TABLE = "table"
+	FORMAT_JSON  = "json"
+)
+
+type output struct {
+	w      io.Writer
+	format string
+}
+
+// print writes value as indented JSON, or the rows under header as a table.
+func (o output) print(value interface{}, header []string, rows ...[]string) error {
+	if o.format == FORMAT_JSON {
+		encoder := json.NewEncoder(o.w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+
+	w := tabwriter.NewWriter(o.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+var (
+	userHeader        = []string{"ID", "EMAIL", "NAME", "ROLE", "VERIFIED", "CREATED AT"}
+	accountHeader     = []string{"ACCOUNT", "OWNER", "BALANCE", "STATUS", "CREATED AT"}
+	transactionHeader = []string{"SEQ", "TRANSACTION", "TYPE", "AMOUNT", "CREATED AT"}
+	transferHeader    = []string{"TRANSACTION", "SENDER", "RECEIVER", "AMOUNT", "STATUS", "EXPIRES ON"}
+	migrationHeader   = []string{"VERSION", "NAME", "APPLIED AT"}
+)
+
+func userRow(user models.User) []string {
+	return []string{
+		strconv.FormatUint(uint64(user.ID), 10),
+		user.Email,
+		user.FirstName + " " + user.LastName,
+		user.Role,
+		strconv.FormatBool(user.IsEmailVerified()),
+		formatTime(user.CreatedAt),
+	}
+}
+
+func accountRow(account models.BankAccount) []string {
+	status := "open"
+	if account.IsClosed() {
+		status = "closed"
+	} else if account.IsFrozen() {
+		status = "frozen"
+	}
+
+	return []string{
+		account.AccountNumber,
+		strconv.FormatUint(uint64(account.UserID), 10),
+		formatAmount(account.Balance),
+		status,
+		formatTime(account.CreatedAt),
+	}
+}
+
+func transactionRow(transaction models.Transaction) []string {
+	return []string{
+		strconv.FormatUint(transaction.Sequence, 10),
+		transaction.TransactionID,
+		transaction.Type,
+		formatAmount(transaction.Amount),
+		formatTime(transaction.CreatedAt),
+	}
+}
+
+func transferRow(transfer models.Transfer) []string {
+	return []string{
+		transfer.TransactionID,
+		strconv.FormatUint(uint64(transfer.SenderID), 10),
+		strconv.FormatUint(uint64(transfer.ReceiverID), 10),
+		formatAmount(transfer.Amount),
+		transfer.Status,
+		formatTime(transfer.ExpiresOn),
+	}
+}
+
+func migrationRow(status database.MigrationStatus) []string {
+	appliedAt := "pending"
+	if status.AppliedAt != nil {
+		appliedAt = formatTime(*status.AppliedAt)
+	}
+
+	return []string{fmt.Sprintf("%04d", status.Version), status.Name, appliedAt}
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+func formatTime(t time.Time) string {
+	return t.Local().Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,96 @@
+// Command ledger verifies the transaction hash chain and manages signed
+// checkpoints of it.
+//
+//	ledger verify [-account NUMBER]
+//	ledger checkpoint [-dir DIR]
+//	ledger verify-checkpoint FILE
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/FaizanAC/Go-Banking/internal/database"
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/server/services"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("no .env file loaded")
+	}
+
+	ledgerService := services.NewLedgerService(database.NewDatabase())
+
+	switch os.Args[1] {
+	case "verify":
+		flags := flag.NewFlagSet("verify", flag.ExitOnError)
+		account := flags.String("account", "", "only verify this account")
+		flags.Parse(os.Args[2:])
+
+		result, err := ledgerService.Verify(*account)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if !result.Valid {
+			broken := result.BrokenLink
+			fmt.Printf("BROKEN: account %s, transaction %d (%s): %s\n",
+				broken.AccountNumber, broken.Sequence, broken.TransactionID, broken.Reason)
+			os.Exit(1)
+		}
+		fmt.Printf("OK: %d transactions across %d accounts\n", result.Transactions, result.Accounts)
+
+	case "checkpoint":
+		flags := flag.NewFlagSet("checkpoint", flag.ExitOnError)
+		dir := flags.String("dir", os.Getenv("LEDGER_CHECKPOINT_DIR"), "directory to write the checkpoint to")
+		flags.Parse(os.Args[2:])
+
+		if *dir == "" {
+			*dir = "."
+		}
+
+		path, err := ledgerService.WriteCheckpoint(*dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(path)
+
+	case "verify-checkpoint":
+		if len(os.Args) < 3 {
+			usage()
+		}
+
+		document, err := os.ReadFile(os.Args[2])
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		var checkpoint models.LedgerCheckpoint
+		if err := json.Unmarshal(document, &checkpoint); err != nil {
+			log.Fatal("malformed checkpoint: ", err)
+		}
+
+		if err := ledgerService.VerifyCheckpoint(checkpoint); err != nil {
+			fmt.Println("BROKEN:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("OK: %d account heads match\n", len(checkpoint.Heads))
+
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: ledger verify [-account NUMBER] | checkpoint [-dir DIR] | verify-checkpoint FILE")
+	os.Exit(2)
+}
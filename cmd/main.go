@@ -2,11 +2,19 @@ package main
 
 import (
 	"log"
+	"net"
 	"os"
+	"time"
 
 	"github.com/FaizanAC/Go-Banking/internal/database"
+	"github.com/FaizanAC/Go-Banking/internal/events"
+	"github.com/FaizanAC/Go-Banking/internal/grpcapi"
+	"github.com/FaizanAC/Go-Banking/internal/mailer"
 	"github.com/FaizanAC/Go-Banking/internal/server"
+	"github.com/FaizanAC/Go-Banking/internal/server/services"
+	"github.com/FaizanAC/Go-Banking/internal/util"
 	"github.com/joho/godotenv"
+	"gorm.io/gorm"
 )
 
 func main() {
@@ -15,11 +23,98 @@ func main() {
 		log.Fatal("Error loading .env file")
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(database.NewDatabase(), os.Args[2:])
+		return
+	}
+
+	keySet, err := util.DefaultKeySet()
+	if err != nil {
+		log.Fatal("Error loading JWT signing keys: ", err)
+	}
+	keySet.StartRotation(keyRotationInterval(), make(chan struct{}))
+
 	db := database.NewDatabase()
-	database.MigrateDB(db)
+	if err := database.CheckSchema(db); err != nil {
+		log.Fatal(err, "; run `migrate up` first")
+	}
+
+	if dir := os.Getenv("LEDGER_CHECKPOINT_DIR"); dir != "" {
+		services.NewLedgerService(db).StartCheckpoints(dir, ledgerCheckpointInterval(), make(chan struct{}))
+	}
+
+	sinks, err := events.NewSinksFromEnv()
+	if err != nil {
+		log.Fatal("Error configuring event sinks: ", err)
+	}
+	webhookService := services.NewWebhookService(db)
+	webhookService.Start(webhookDeliveryInterval(), make(chan struct{}))
 
 	s := server.NewServer(
 		db, os.Getenv("PORT"),
 	)
+
+	stream := s.StreamService()
+	// SQLite runs as a single instance, so only Postgres fans events out.
+	if database.IsPostgres(db) {
+		database.Listen(services.STREAM_CHANNEL, stream.HandleNotification, make(chan struct{}))
+		stream.EnableFanout()
+	}
+
+	sinks = append(sinks, webhookService, stream)
+	services.NewOutboxRelay(db, sinks...).Start(outboxRelayInterval(), make(chan struct{}))
+
+	if port := os.Getenv("GRPC_PORT"); port != "" {
+		go serveGRPC(db, port, stream)
+	}
+
 	s.Start()
 }
+
+func serveGRPC(db *gorm.DB, port string, stream *services.StreamService) {
+	mail, err := mailer.NewMailerFromEnv()
+	if err != nil {
+		log.Fatal("Error configuring the mailer: ", err)
+	}
+
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		log.Fatal("Error listening for gRPC: ", err)
+	}
+
+	if err := grpcapi.NewServer(db, mail, stream).Serve(listener); err != nil {
+		log.Fatal("Error serving gRPC: ", err)
+	}
+}
+
+func keyRotationInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("JWT_KEYS_RELOAD_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return time.Hour
+	}
+	return interval
+}
+
+func ledgerCheckpointInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("LEDGER_CHECKPOINT_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return 24 * time.Hour
+	}
+	return interval
+}
+
+func outboxRelayInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("OUTBOX_RELAY_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return time.Second
+	}
+	return interval
+}
+
+func webhookDeliveryInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("WEBHOOK_DELIVERY_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return 5 * time.Second
+	}
+	return interval
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/FaizanAC/Go-Banking/internal/database"
+	"gorm.io/gorm"
+)
+
+// runMigrate handles `migrate up | down [N] | status | to VERSION`.
+func runMigrate(db *gorm.DB, args []string) {
+	if len(args) == 0 {
+		migrateUsage()
+	}
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var migrated []database.Migration
+	switch args[0] {
+	case "up":
+		migrated, err = migrator.Up()
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				migrateUsage()
+			}
+		}
+		migrated, err = migrator.Down(steps)
+
+	case "to":
+		if len(args) < 2 {
+			migrateUsage()
+		}
+		version, parseErr := strconv.ParseUint(args[1], 10, 32)
+		if parseErr != nil {
+			migrateUsage()
+		}
+		migrated, err = migrator.To(uint(version))
+
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		w.Flush()
+		return
+
+	default:
+		migrateUsage()
+	}
+
+	for _, migration := range migrated {
+		fmt.Printf("migrated %04d_%s\n", migration.Version, migration.Name)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	version, err := migrator.Version()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("schema is at version %d of %d\n", version, migrator.Latest())
+}
+
+func migrateUsage() {
+	fmt.Fprintln(os.Stderr, "usage: migrate up | down [N] | status | to VERSION")
+	os.Exit(2)
+}
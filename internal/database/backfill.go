@@ -0,0 +1,42 @@
+package database
+
+import (
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"gorm.io/gorm"
+)
+
+// chainUnhashedTransactions chains the transactions of accounts recorded
+// before the ledger was hashed, in the order they were created.
+func chainUnhashedTransactions(tx *gorm.DB) error {
+	var accountNumbers []string
+	if err := tx.Unscoped().Model(&models.Transaction{}).Where("hash = '' OR hash IS NULL").
+		Distinct().Pluck("account_number", &accountNumbers).Error; err != nil {
+		return err
+	}
+
+	for _, accountNumber := range accountNumbers {
+		var transactions []models.Transaction
+		if err := tx.Unscoped().Where("account_number = ?", accountNumber).
+			Order("created_at, id").Find(&transactions).Error; err != nil {
+			return err
+		}
+
+		prevHash := models.LEDGER_GENESIS_HASH
+		for i := range transactions {
+			transaction := &transactions[i]
+			transaction.Sequence = uint64(i + 1)
+			transaction.PrevHash = prevHash
+			transaction.Hash = transaction.ComputeHash()
+			prevHash = transaction.Hash
+
+			if err := tx.Unscoped().Model(transaction).UpdateColumns(map[string]interface{}{
+				"sequence":  transaction.Sequence,
+				"prev_hash": transaction.PrevHash,
+				"hash":      transaction.Hash,
+			}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
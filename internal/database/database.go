@@ -4,14 +4,46 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/FaizanAC/Go-Banking/internal/models"
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
+const (
+	DRIVER_POSTGRES = "postgres"
+	DRIVER_SQLITE   = "sqlite"
+)
+
+const defaultSQLitePath = "go-banking.db"
+
+func DSN() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=5432 sslmode=disable", os.Getenv("HOST"), os.Getenv("POSTGRES_USER"), os.Getenv("POSTGRES_PASSWORD"), os.Getenv("POSTGRES_DB"))
+}
+
+// Driver is the backend named by DB_DRIVER, Postgres by default.
+func Driver() string {
+	if driver := os.Getenv("DB_DRIVER"); driver != "" {
+		return driver
+	}
+	return DRIVER_POSTGRES
+}
+
+// SQLiteDSN opens the file at SQLITE_PATH, or an in-memory database for
+// ":memory:". Transactions take the write lock when they begin, and other
+// connections wait for it rather than failing.
+func SQLiteDSN() string {
+	path := os.Getenv("SQLITE_PATH")
+	if path == "" {
+		path = defaultSQLitePath
+	}
+	if path == ":memory:" {
+		return "file::memory:?_foreign_keys=on"
+	}
+	return fmt.Sprintf("file:%s?_foreign_keys=on&_journal_mode=WAL&_busy_timeout=5000&_txlock=immediate", path)
+}
+
 func NewDatabase() *gorm.DB {
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=5432 sslmode=disable", os.Getenv("HOST"), os.Getenv("POSTGRES_USER"), os.Getenv("POSTGRES_PASSWORD"), os.Getenv("POSTGRES_DB"))
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := Open(Driver())
 	if err != nil {
 		panic("Cannot connect to the DB")
 	}
@@ -19,11 +51,44 @@ func NewDatabase() *gorm.DB {
 	return db
 }
 
-func MigrateDB(db *gorm.DB) {
-	db.AutoMigrate(
-		&models.User{},
-		&models.BankAccount{},
-		&models.Transaction{},
-		&models.Transfer{},
-	)
+func Open(driver string) (*gorm.DB, error) {
+	switch driver {
+	case DRIVER_POSTGRES:
+		return gorm.Open(postgres.Open(DSN()), &gorm.Config{})
+	case DRIVER_SQLITE:
+		db, err := gorm.Open(sqlite.Open(SQLiteDSN()), &gorm.Config{})
+		if err != nil {
+			return nil, err
+		}
+
+		// Every connection to ":memory:" is a separate database, so the
+		// pool has to stay at one.
+		if os.Getenv("SQLITE_PATH") == ":memory:" {
+			sqlDB, err := db.DB()
+			if err != nil {
+				return nil, err
+			}
+			sqlDB.SetMaxOpenConns(1)
+		}
+		return db, nil
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", driver)
+	}
+}
+
+// IsPostgres reports whether db is a Postgres connection, for the features
+// SQLite lacks.
+func IsPostgres(db *gorm.DB) bool {
+	return db.Dialector.Name() == DRIVER_POSTGRES
+}
+
+// MigrateDB applies every pending migration.
+func MigrateDB(db *gorm.DB) error {
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	_, err = migrator.Up()
+	return err
 }
@@ -7,9 +7,22 @@ import (
 )
 
 func TestDatabaseConnection(t *testing.T) {
-	db := NewDatabase()
+	db := openTestDB(t)
 
 	sqlDB, err := db.DB()
 	assert.Nil(t, err)
 	assert.Nil(t, sqlDB.Ping())
 }
+
+func TestSQLiteDSN(t *testing.T) {
+	t.Setenv("SQLITE_PATH", ":memory:")
+	assert.Equal(t, "file::memory:?_foreign_keys=on", SQLiteDSN())
+
+	t.Setenv("SQLITE_PATH", "")
+	assert.Contains(t, SQLiteDSN(), "file:go-banking.db?")
+}
+
+func TestOpenUnknownDriver(t *testing.T) {
+	_, err := Open("mysql")
+	assert.EqualError(t, err, `unknown database driver "mysql"`)
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Listen passes every NOTIFY payload on channel to handle until stop is
+// closed, reconnecting if the connection drops. It needs its own connection
+// because a LISTEN session cannot be shared through the pool.
+func Listen(channel string, handle func(payload string), stop <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	go func() {
+		backoff := time.Second
+		for {
+			err := listen(ctx, channel, handle)
+			if ctx.Err() != nil {
+				return
+			}
+
+			log.Printf("lost LISTEN connection on %s, retrying in %s: %v", channel, backoff, err)
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return
+			}
+			if backoff < time.Minute {
+				backoff *= 2
+			}
+		}
+	}()
+}
+
+func listen(ctx context.Context, channel string, handle func(payload string)) error {
+	conn, err := pgx.Connect(ctx, DSN())
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		handle(notification.Payload)
+	}
+}
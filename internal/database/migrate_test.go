@@ -0,0 +1,279 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// schemaModels are the tables the migrations have to keep up with.
+var schemaModels = []interface{}{
+	&models.User{},
+	&models.BankAccount{},
+	&models.Transaction{},
+	&models.Transfer{},
+	&models.RecoveryCode{},
+	&models.LoginChallenge{},
+	&models.PasswordResetToken{},
+	&models.EmailVerification{},
+	&models.LoginAttempt{},
+	&models.LoginUnlockToken{},
+	&models.APIKey{},
+	&models.OAuthClient{},
+	&models.OAuthAuthorizationRequest{},
+	&models.OAuthAuthorizationCode{},
+	&models.OAuthConsent{},
+	&models.OAuthRefreshToken{},
+	&models.AccountMember{},
+	&models.DataRequest{},
+	&models.AuditLog{},
+	&models.OutboxEvent{},
+	&models.WebhookEndpoint{},
+	&models.WebhookDelivery{},
+}
+
+func openTestDB(t *testing.T) *gorm.DB {
+	if os.Getenv("DB_DRIVER") == "" {
+		t.Setenv("DB_DRIVER", DRIVER_SQLITE)
+		t.Setenv("SQLITE_PATH", filepath.Join(t.TempDir(), "test.db"))
+	}
+	db := NewDatabase()
+
+	sqlDB, err := db.DB()
+	assert.Nil(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func TestDriversHaveTheSameMigrations(t *testing.T) {
+	postgres, err := LoadMigrations(DRIVER_POSTGRES)
+	assert.Nil(t, err)
+	sqlite, err := LoadMigrations(DRIVER_SQLITE)
+	assert.Nil(t, err)
+
+	assert.Equal(t, len(postgres), len(sqlite))
+	for i := range postgres {
+		assert.Equal(t, postgres[i].Version, sqlite[i].Version)
+		assert.Equal(t, postgres[i].Name, sqlite[i].Name)
+		assert.NotEmpty(t, postgres[i].Down)
+		assert.NotEmpty(t, sqlite[i].Down)
+	}
+}
+
+func TestMigrationsMatchModels(t *testing.T) {
+	db := openTestDB(t)
+	assert.Nil(t, MigrateDB(db))
+
+	for _, model := range schemaModels {
+		stmt := &gorm.Statement{DB: db}
+		assert.Nil(t, stmt.Parse(model))
+
+		assert.True(t, db.Migrator().HasTable(model), stmt.Schema.Table)
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" {
+				assert.True(t, db.Migrator().HasColumn(model, field.DBName), "%s.%s", stmt.Schema.Table, field.DBName)
+			}
+		}
+		for _, index := range stmt.Schema.ParseIndexes() {
+			assert.True(t, db.Migrator().HasIndex(model, index.Name), "%s %s", stmt.Schema.Table, index.Name)
+		}
+	}
+}
+
+func TestMigrateUpAndDown(t *testing.T) {
+	db := openTestDB(t)
+	migrator, err := NewMigrator(db)
+	assert.Nil(t, err)
+
+	version, err := migrator.Version()
+	assert.Nil(t, err)
+	assert.Zero(t, version)
+
+	applied, err := migrator.Up()
+	assert.Nil(t, err)
+	assert.Len(t, applied, int(migrator.Latest()))
+
+	applied, err = migrator.Up()
+	assert.Nil(t, err)
+	assert.Empty(t, applied)
+
+	reverted, err := migrator.Down(1)
+	assert.Nil(t, err)
+	assert.Len(t, reverted, 1)
+	version, _ = migrator.Version()
+	assert.Equal(t, migrator.Latest()-1, version)
+
+	statuses, err := migrator.Status()
+	assert.Nil(t, err)
+	assert.NotNil(t, statuses[0].AppliedAt)
+	assert.Nil(t, statuses[len(statuses)-1].AppliedAt)
+
+	_, err = migrator.To(0)
+	assert.Nil(t, err)
+	assert.False(t, db.Migrator().HasTable(&models.User{}))
+
+	_, err = migrator.To(migrator.Latest() + 1)
+	assert.EqualError(t, err, fmt.Sprintf("unknown migration version %d", migrator.Latest()+1))
+
+	_, err = migrator.To(migrator.Latest())
+	assert.Nil(t, err)
+	assert.True(t, db.Migrator().HasTable(&models.User{}))
+}
+
+func TestCheckSchema(t *testing.T) {
+	db := openTestDB(t)
+	assert.ErrorIs(t, CheckSchema(db), ErrSchemaBehind)
+
+	assert.Nil(t, MigrateDB(db))
+	assert.Nil(t, CheckSchema(db))
+}
+
+func TestAuditLogIsAppendOnly(t *testing.T) {
+	db := openTestDB(t)
+	assert.Nil(t, MigrateDB(db))
+
+	assert.Nil(t, db.Create(&models.AuditLog{Action: models.AUDIT_USER_CREATE, IP: "192.0.2.1", UserAgent: "curl"}).Error)
+	assert.ErrorContains(t, db.Exec("UPDATE audit_logs SET action = 'rewritten'").Error, "append-only")
+	assert.ErrorContains(t, db.Exec("UPDATE audit_logs SET ip = '198.51.100.1'").Error, "append-only")
+	assert.ErrorContains(t, db.Exec("UPDATE audit_logs SET ip = '', user_agent = '', action = 'rewritten'").Error, "append-only")
+	assert.ErrorContains(t, db.Exec("DELETE FROM audit_logs").Error, "append-only")
+
+	// Erasure may clear the client details and nothing else.
+	assert.Nil(t, db.Exec("UPDATE audit_logs SET ip = '', user_agent = ''").Error)
+	var entry models.AuditLog
+	assert.Nil(t, db.First(&entry).Error)
+	assert.Empty(t, entry.IP)
+	assert.Equal(t, models.AUDIT_USER_CREATE, entry.Action)
+}
+
+func TestExistingUsersAreMarkedVerified(t *testing.T) {
+	db := openTestDB(t)
+	migrator, err := NewMigrator(db)
+	assert.Nil(t, err)
+
+	_, err = migrator.To(5)
+	assert.Nil(t, err)
+	assert.Nil(t, db.Exec("INSERT INTO users (created_at, email, password) VALUES (?, ?, ?)",
+		time.Now().Add(-time.Hour), "legacy@example.com", "x").Error)
+
+	_, err = migrator.Up()
+	assert.Nil(t, err)
+
+	var user models.User
+	assert.Nil(t, db.Where("email = ?", "legacy@example.com").First(&user).Error)
+	assert.True(t, user.IsEmailVerified())
+}
+
+func TestAuditLogPersonalDataIsScrubbed(t *testing.T) {
+	db := openTestDB(t)
+	migrator, err := NewMigrator(db)
+	assert.Nil(t, err)
+
+	_, err = migrator.To(8)
+	assert.Nil(t, err)
+	assert.Nil(t, db.Create(&models.AuditLog{
+		Action: models.AUDIT_USER_CREATE, EntityType: models.ENTITY_USER, EntityID: "1",
+		After: []byte(`{"id":1,"email":"legacy@example.com","firstName":"Leg","lastName":"Acy","role":"customer"}`),
+	}).Error)
+	assert.Nil(t, db.Create(&models.AuditLog{
+		Action: models.AUDIT_LOGIN_FAILURE, EntityType: models.ENTITY_USER, EntityID: "nobody@example.com",
+		After: []byte(`{"email":"nobody@example.com","ip":"192.0.2.1","userAgent":"curl","reason":"INVALID_CREDENTIALS"}`),
+	}).Error)
+
+	_, err = migrator.Up()
+	assert.Nil(t, err)
+
+	var entries []models.AuditLog
+	assert.Nil(t, db.Order("id").Find(&entries).Error)
+	assert.Len(t, entries, 2)
+	assert.JSONEq(t, `{"id":1,"role":"customer"}`, string(entries[0].After))
+	assert.JSONEq(t, `{"reason":"INVALID_CREDENTIALS"}`, string(entries[1].After))
+	assert.Empty(t, entries[1].EntityID)
+
+	assert.ErrorContains(t, db.Exec("UPDATE audit_logs SET action = 'rewritten'").Error, "append-only")
+}
+
+func TestOutboxReceiverIsBackfilled(t *testing.T) {
+	db := openTestDB(t)
+	migrator, err := NewMigrator(db)
+	assert.Nil(t, err)
+
+	_, err = migrator.To(9)
+	assert.Nil(t, err)
+	assert.Nil(t, db.Exec("INSERT INTO outbox_events (event_id, type, aggregate_id, payload) VALUES (?, ?, ?, ?)",
+		"legacy", models.EVENT_TRANSFER_SENT, "123", []byte(`{"accountNumber":"123","receiverId":42}`)).Error)
+
+	_, err = migrator.Up()
+	assert.Nil(t, err)
+
+	var event models.OutboxEvent
+	assert.Nil(t, db.Where("event_id = ?", "legacy").First(&event).Error)
+	assert.NotNil(t, event.ReceiverID)
+	assert.Equal(t, uint(42), *event.ReceiverID)
+}
+
+func TestLegacyDatabaseIsBackfilled(t *testing.T) {
+	db := openTestDB(t)
+
+	// A database AutoMigrate left behind: the tables exist but no migration
+	// has been recorded, and the rows predate joint accounts and the ledger.
+	migrations, err := LoadMigrations(db.Dialector.Name())
+	assert.Nil(t, err)
+	assert.Nil(t, db.Exec(migrations[0].Up).Error)
+
+	createdAt := time.Now().Add(-time.Hour)
+	assert.Nil(t, db.Exec("INSERT INTO users (id, created_at, email, password, role) VALUES (1, ?, 'legacy@example.com', 'x', 'customer')", createdAt).Error)
+	assert.Nil(t, db.Exec("INSERT INTO bank_accounts (created_at, account_number, user_id, balance) VALUES (?, '123', 1, 15)", createdAt).Error)
+	for i, amount := range []float64{20, 5} {
+		assert.Nil(t, db.Exec("INSERT INTO transactions (created_at, account_number, transaction_id, type, amount) VALUES (?, '123', ?, ?, ?)",
+			createdAt.Add(time.Duration(i)*time.Minute), fmt.Sprintf("legacy-%d", i), []string{"DEPOSIT", "WITHDRAW"}[i], amount).Error)
+	}
+
+	assert.Nil(t, MigrateDB(db))
+
+	var member models.AccountMember
+	assert.Nil(t, db.Where("account_number = ?", "123").First(&member).Error)
+	assert.Equal(t, uint(1), member.UserID)
+	assert.Equal(t, models.MEMBER_OWNER, member.Role)
+	assert.True(t, member.IsActive())
+
+	var transactions []models.Transaction
+	assert.Nil(t, db.Order("sequence").Find(&transactions).Error)
+	assert.Len(t, transactions, 2)
+	prevHash := models.LEDGER_GENESIS_HASH
+	for i, transaction := range transactions {
+		assert.Equal(t, uint64(i+1), transaction.Sequence)
+		assert.Equal(t, prevHash, transaction.PrevHash)
+		assert.Equal(t, transaction.ComputeHash(), transaction.Hash)
+		prevHash = transaction.Hash
+	}
+}
+
+func TestPostgresBaselineAddsEveryColumn(t *testing.T) {
+	migrations, err := LoadMigrations(DRIVER_POSTGRES)
+	assert.Nil(t, err)
+	baseline := migrations[0].Up
+
+	// Adopted tables may predate any column, so each one is added if missing.
+	table := regexp.MustCompile(`(?s)CREATE TABLE IF NOT EXISTS (\w+) \((.*?)\n\);`)
+	tables := table.FindAllStringSubmatch(baseline, -1)
+	assert.NotEmpty(t, tables)
+	for _, match := range tables {
+		for _, line := range strings.Split(match[2], "\n") {
+			column := strings.TrimSuffix(strings.TrimSpace(line), ",")
+			if column == "" || strings.HasPrefix(column, "id ") || strings.HasPrefix(column, "CONSTRAINT") {
+				continue
+			}
+			alter := regexp.MustCompile(`ALTER TABLE ` + match[1] + `\n(?:    ADD COLUMN IF NOT EXISTS .*\n)*    ADD COLUMN IF NOT EXISTS ` + regexp.QuoteMeta(column) + `[,;]`)
+			assert.Regexp(t, alter, baseline, "%s.%s", match[1], column)
+		}
+	}
+}
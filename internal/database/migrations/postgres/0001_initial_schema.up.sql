@@ -0,0 +1,561 @@
+-- The schema as AutoMigrate last left it. Tables and indexes that already
+-- exist are kept, so databases created before versioned migrations are
+-- adopted; columns added to the models since they were created are added
+-- to them here, before the indexes that use them.
+
+CREATE TABLE IF NOT EXISTS users (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    email text,
+    first_name text,
+    last_name text,
+    password text,
+    role text DEFAULT 'customer',
+    email_verified_at timestamptz,
+    totp_secret text,
+    totp_enabled boolean,
+    sessions_valid_from timestamptz,
+    erased_at timestamptz,
+    CONSTRAINT uni_users_email UNIQUE (email)
+);
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz,
+    ADD COLUMN IF NOT EXISTS email text,
+    ADD COLUMN IF NOT EXISTS first_name text,
+    ADD COLUMN IF NOT EXISTS last_name text,
+    ADD COLUMN IF NOT EXISTS password text,
+    ADD COLUMN IF NOT EXISTS role text DEFAULT 'customer',
+    ADD COLUMN IF NOT EXISTS email_verified_at timestamptz,
+    ADD COLUMN IF NOT EXISTS totp_secret text,
+    ADD COLUMN IF NOT EXISTS totp_enabled boolean,
+    ADD COLUMN IF NOT EXISTS sessions_valid_from timestamptz,
+    ADD COLUMN IF NOT EXISTS erased_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS bank_accounts (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    account_number text,
+    user_id bigint,
+    balance decimal,
+    frozen_at timestamptz,
+    CONSTRAINT uni_bank_accounts_account_number UNIQUE (account_number)
+);
+ALTER TABLE bank_accounts
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz,
+    ADD COLUMN IF NOT EXISTS account_number text,
+    ADD COLUMN IF NOT EXISTS user_id bigint,
+    ADD COLUMN IF NOT EXISTS balance decimal,
+    ADD COLUMN IF NOT EXISTS frozen_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_bank_accounts_deleted_at ON bank_accounts (deleted_at);
+
+CREATE TABLE IF NOT EXISTS transactions (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    amount decimal,
+    account_number text,
+    transaction_id text,
+    type text,
+    sequence bigint,
+    prev_hash text,
+    hash text,
+    CONSTRAINT uni_transactions_transaction_id UNIQUE (transaction_id)
+);
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz,
+    ADD COLUMN IF NOT EXISTS amount decimal,
+    ADD COLUMN IF NOT EXISTS account_number text,
+    ADD COLUMN IF NOT EXISTS transaction_id text,
+    ADD COLUMN IF NOT EXISTS type text,
+    ADD COLUMN IF NOT EXISTS sequence bigint,
+    ADD COLUMN IF NOT EXISTS prev_hash text,
+    ADD COLUMN IF NOT EXISTS hash text;
+CREATE INDEX IF NOT EXISTS idx_transaction_chain ON transactions (account_number, sequence);
+CREATE INDEX IF NOT EXISTS idx_transactions_deleted_at ON transactions (deleted_at);
+
+CREATE TABLE IF NOT EXISTS transfers (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    sender_id bigint,
+    receiver_id bigint,
+    amount decimal,
+    status text,
+    expires_on timestamptz,
+    transaction_id text,
+    CONSTRAINT uni_transfers_transaction_id UNIQUE (transaction_id)
+);
+ALTER TABLE transfers
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz,
+    ADD COLUMN IF NOT EXISTS sender_id bigint,
+    ADD COLUMN IF NOT EXISTS receiver_id bigint,
+    ADD COLUMN IF NOT EXISTS amount decimal,
+    ADD COLUMN IF NOT EXISTS status text,
+    ADD COLUMN IF NOT EXISTS expires_on timestamptz,
+    ADD COLUMN IF NOT EXISTS transaction_id text;
+CREATE INDEX IF NOT EXISTS idx_transfers_deleted_at ON transfers (deleted_at);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint,
+    code_hash text,
+    used_at timestamptz,
+    CONSTRAINT uni_recovery_codes_code_hash UNIQUE (code_hash)
+);
+ALTER TABLE recovery_codes
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz,
+    ADD COLUMN IF NOT EXISTS user_id bigint,
+    ADD COLUMN IF NOT EXISTS code_hash text,
+    ADD COLUMN IF NOT EXISTS used_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes (user_id);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_deleted_at ON recovery_codes (deleted_at);
+
+CREATE TABLE IF NOT EXISTS login_challenges (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    challenge_hash text,
+    user_id bigint,
+    expires_on timestamptz,
+    attempts bigint,
+    completed_at timestamptz,
+    CONSTRAINT uni_login_challenges_challenge_hash UNIQUE (challenge_hash)
+);
+ALTER TABLE login_challenges
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz,
+    ADD COLUMN IF NOT EXISTS challenge_hash text,
+    ADD COLUMN IF NOT EXISTS user_id bigint,
+    ADD COLUMN IF NOT EXISTS expires_on timestamptz,
+    ADD COLUMN IF NOT EXISTS attempts bigint,
+    ADD COLUMN IF NOT EXISTS completed_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_login_challenges_user_id ON login_challenges (user_id);
+CREATE INDEX IF NOT EXISTS idx_login_challenges_deleted_at ON login_challenges (deleted_at);
+
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint,
+    token_hash text,
+    expires_on timestamptz,
+    used_at timestamptz,
+    CONSTRAINT uni_password_reset_tokens_token_hash UNIQUE (token_hash)
+);
+ALTER TABLE password_reset_tokens
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz,
+    ADD COLUMN IF NOT EXISTS user_id bigint,
+    ADD COLUMN IF NOT EXISTS token_hash text,
+    ADD COLUMN IF NOT EXISTS expires_on timestamptz,
+    ADD COLUMN IF NOT EXISTS used_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_deleted_at ON password_reset_tokens (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON password_reset_tokens (user_id);
+
+CREATE TABLE IF NOT EXISTS email_verifications (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint,
+    email text
+);
+ALTER TABLE email_verifications
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz,
+    ADD COLUMN IF NOT EXISTS user_id bigint,
+    ADD COLUMN IF NOT EXISTS email text;
+CREATE INDEX IF NOT EXISTS idx_email_verifications_user_id ON email_verifications (user_id);
+CREATE INDEX IF NOT EXISTS idx_email_verifications_deleted_at ON email_verifications (deleted_at);
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    email text,
+    user_id bigint,
+    ip text,
+    user_agent text,
+    success boolean,
+    reason text
+);
+ALTER TABLE login_attempts
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz,
+    ADD COLUMN IF NOT EXISTS email text,
+    ADD COLUMN IF NOT EXISTS user_id bigint,
+    ADD COLUMN IF NOT EXISTS ip text,
+    ADD COLUMN IF NOT EXISTS user_agent text,
+    ADD COLUMN IF NOT EXISTS success boolean,
+    ADD COLUMN IF NOT EXISTS reason text;
+CREATE INDEX IF NOT EXISTS idx_login_attempts_deleted_at ON login_attempts (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_ip ON login_attempts (ip);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_user_id ON login_attempts (user_id);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_email ON login_attempts (email);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint,
+    name text,
+    prefix text,
+    key_hash text,
+    scopes text,
+    allowed_ips text,
+    expires_at timestamptz,
+    last_used_at timestamptz,
+    revoked_at timestamptz,
+    CONSTRAINT uni_api_keys_key_hash UNIQUE (key_hash)
+);
+ALTER TABLE api_keys
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz,
+    ADD COLUMN IF NOT EXISTS user_id bigint,
+    ADD COLUMN IF NOT EXISTS name text,
+    ADD COLUMN IF NOT EXISTS prefix text,
+    ADD COLUMN IF NOT EXISTS key_hash text,
+    ADD COLUMN IF NOT EXISTS scopes text,
+    ADD COLUMN IF NOT EXISTS allowed_ips text,
+    ADD COLUMN IF NOT EXISTS expires_at timestamptz,
+    ADD COLUMN IF NOT EXISTS last_used_at timestamptz,
+    ADD COLUMN IF NOT EXISTS revoked_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
+CREATE INDEX IF NOT EXISTS idx_api_keys_deleted_at ON api_keys (deleted_at);
+
+CREATE TABLE IF NOT EXISTS o_auth_clients (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    client_id text,
+    client_secret_hash text,
+    name text,
+    redirect_uris text,
+    scopes text,
+    owner_id bigint,
+    CONSTRAINT uni_o_auth_clients_client_id UNIQUE (client_id)
+);
+ALTER TABLE o_auth_clients
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz,
+    ADD COLUMN IF NOT EXISTS client_id text,
+    ADD COLUMN IF NOT EXISTS client_secret_hash text,
+    ADD COLUMN IF NOT EXISTS name text,
+    ADD COLUMN IF NOT EXISTS redirect_uris text,
+    ADD COLUMN IF NOT EXISTS scopes text,
+    ADD COLUMN IF NOT EXISTS owner_id bigint;
+CREATE INDEX IF NOT EXISTS idx_o_auth_clients_deleted_at ON o_auth_clients (deleted_at);
+
+CREATE TABLE IF NOT EXISTS o_auth_authorization_requests (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    request_hash text,
+    user_id bigint,
+    client_id text,
+    redirect_uri text,
+    scopes text,
+    state text,
+    code_challenge text,
+    expires_on timestamptz,
+    CONSTRAINT uni_o_auth_authorization_requests_request_hash UNIQUE (request_hash)
+);
+ALTER TABLE o_auth_authorization_requests
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz,
+    ADD COLUMN IF NOT EXISTS request_hash text,
+    ADD COLUMN IF NOT EXISTS user_id bigint,
+    ADD COLUMN IF NOT EXISTS client_id text,
+    ADD COLUMN IF NOT EXISTS redirect_uri text,
+    ADD COLUMN IF NOT EXISTS scopes text,
+    ADD COLUMN IF NOT EXISTS state text,
+    ADD COLUMN IF NOT EXISTS code_challenge text,
+    ADD COLUMN IF NOT EXISTS expires_on timestamptz;
+CREATE INDEX IF NOT EXISTS idx_o_auth_authorization_requests_user_id ON o_auth_authorization_requests (user_id);
+CREATE INDEX IF NOT EXISTS idx_o_auth_authorization_requests_deleted_at ON o_auth_authorization_requests (deleted_at);
+
+CREATE TABLE IF NOT EXISTS o_auth_authorization_codes (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    code_hash text,
+    consent_id bigint,
+    user_id bigint,
+    client_id text,
+    redirect_uri text,
+    scopes text,
+    code_challenge text,
+    expires_on timestamptz,
+    used_at timestamptz,
+    CONSTRAINT uni_o_auth_authorization_codes_code_hash UNIQUE (code_hash)
+);
+ALTER TABLE o_auth_authorization_codes
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz,
+    ADD COLUMN IF NOT EXISTS code_hash text,
+    ADD COLUMN IF NOT EXISTS consent_id bigint,
+    ADD COLUMN IF NOT EXISTS user_id bigint,
+    ADD COLUMN IF NOT EXISTS client_id text,
+    ADD COLUMN IF NOT EXISTS redirect_uri text,
+    ADD COLUMN IF NOT EXISTS scopes text,
+    ADD COLUMN IF NOT EXISTS code_challenge text,
+    ADD COLUMN IF NOT EXISTS expires_on timestamptz,
+    ADD COLUMN IF NOT EXISTS used_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_o_auth_authorization_codes_deleted_at ON o_auth_authorization_codes (deleted_at);
+
+CREATE TABLE IF NOT EXISTS o_auth_consents (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint,
+    client_id text,
+    scopes text,
+    revoked_at timestamptz
+);
+ALTER TABLE o_auth_consents
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz,
+    ADD COLUMN IF NOT EXISTS user_id bigint,
+    ADD COLUMN IF NOT EXISTS client_id text,
+    ADD COLUMN IF NOT EXISTS scopes text,
+    ADD COLUMN IF NOT EXISTS revoked_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_o_auth_consents_client_id ON o_auth_consents (client_id);
+CREATE INDEX IF NOT EXISTS idx_o_auth_consents_user_id ON o_auth_consents (user_id);
+CREATE INDEX IF NOT EXISTS idx_o_auth_consents_deleted_at ON o_auth_consents (deleted_at);
+
+CREATE TABLE IF NOT EXISTS o_auth_refresh_tokens (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    token_hash text,
+    consent_id bigint,
+    expires_on timestamptz,
+    used_at timestamptz,
+    CONSTRAINT uni_o_auth_refresh_tokens_token_hash UNIQUE (token_hash)
+);
+ALTER TABLE o_auth_refresh_tokens
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz,
+    ADD COLUMN IF NOT EXISTS token_hash text,
+    ADD COLUMN IF NOT EXISTS consent_id bigint,
+    ADD COLUMN IF NOT EXISTS expires_on timestamptz,
+    ADD COLUMN IF NOT EXISTS used_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_o_auth_refresh_tokens_consent_id ON o_auth_refresh_tokens (consent_id);
+CREATE INDEX IF NOT EXISTS idx_o_auth_refresh_tokens_deleted_at ON o_auth_refresh_tokens (deleted_at);
+
+CREATE TABLE IF NOT EXISTS account_members (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    account_number text,
+    user_id bigint,
+    role text,
+    transaction_limit decimal,
+    invited_by bigint,
+    accepted_at timestamptz
+);
+ALTER TABLE account_members
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz,
+    ADD COLUMN IF NOT EXISTS account_number text,
+    ADD COLUMN IF NOT EXISTS user_id bigint,
+    ADD COLUMN IF NOT EXISTS role text,
+    ADD COLUMN IF NOT EXISTS transaction_limit decimal,
+    ADD COLUMN IF NOT EXISTS invited_by bigint,
+    ADD COLUMN IF NOT EXISTS accepted_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_account_members_user_id ON account_members (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_member ON account_members (account_number, user_id);
+CREATE INDEX IF NOT EXISTS idx_account_members_deleted_at ON account_members (deleted_at);
+
+CREATE TABLE IF NOT EXISTS data_requests (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint,
+    requested_by bigint,
+    type text,
+    status text,
+    ip text,
+    user_agent text,
+    error text,
+    completed_at timestamptz,
+    expires_at timestamptz,
+    archive bytea
+);
+ALTER TABLE data_requests
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz,
+    ADD COLUMN IF NOT EXISTS user_id bigint,
+    ADD COLUMN IF NOT EXISTS requested_by bigint,
+    ADD COLUMN IF NOT EXISTS type text,
+    ADD COLUMN IF NOT EXISTS status text,
+    ADD COLUMN IF NOT EXISTS ip text,
+    ADD COLUMN IF NOT EXISTS user_agent text,
+    ADD COLUMN IF NOT EXISTS error text,
+    ADD COLUMN IF NOT EXISTS completed_at timestamptz,
+    ADD COLUMN IF NOT EXISTS expires_at timestamptz,
+    ADD COLUMN IF NOT EXISTS archive bytea;
+CREATE INDEX IF NOT EXISTS idx_data_requests_user_id ON data_requests (user_id);
+CREATE INDEX IF NOT EXISTS idx_data_requests_deleted_at ON data_requests (deleted_at);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    actor_id bigint,
+    auth_method text,
+    ip text,
+    user_agent text,
+    request_id text,
+    action text,
+    entity_type text,
+    entity_id text,
+    before bytea,
+    after bytea
+);
+ALTER TABLE audit_logs
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS actor_id bigint,
+    ADD COLUMN IF NOT EXISTS auth_method text,
+    ADD COLUMN IF NOT EXISTS ip text,
+    ADD COLUMN IF NOT EXISTS user_agent text,
+    ADD COLUMN IF NOT EXISTS request_id text,
+    ADD COLUMN IF NOT EXISTS action text,
+    ADD COLUMN IF NOT EXISTS entity_type text,
+    ADD COLUMN IF NOT EXISTS entity_id text,
+    ADD COLUMN IF NOT EXISTS before bytea,
+    ADD COLUMN IF NOT EXISTS after bytea;
+CREATE INDEX IF NOT EXISTS idx_audit_logs_request_id ON audit_logs (request_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_id ON audit_logs (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_entity ON audit_logs (entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs (action);
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id bigserial PRIMARY KEY,
+    event_id text,
+    type text,
+    aggregate_id text,
+    payload bytea,
+    occurred_at timestamptz,
+    published_at timestamptz,
+    attempts bigint,
+    last_error text,
+    CONSTRAINT uni_outbox_events_event_id UNIQUE (event_id)
+);
+ALTER TABLE outbox_events
+    ADD COLUMN IF NOT EXISTS event_id text,
+    ADD COLUMN IF NOT EXISTS type text,
+    ADD COLUMN IF NOT EXISTS aggregate_id text,
+    ADD COLUMN IF NOT EXISTS payload bytea,
+    ADD COLUMN IF NOT EXISTS occurred_at timestamptz,
+    ADD COLUMN IF NOT EXISTS published_at timestamptz,
+    ADD COLUMN IF NOT EXISTS attempts bigint,
+    ADD COLUMN IF NOT EXISTS last_error text;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_type ON outbox_events (type);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_published_at ON outbox_events (published_at);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_aggregate_id ON outbox_events (aggregate_id);
+
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint,
+    url text,
+    secret text,
+    event_types text,
+    low_balance_threshold decimal,
+    consecutive_failures bigint,
+    disabled_at timestamptz
+);
+ALTER TABLE webhook_endpoints
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz,
+    ADD COLUMN IF NOT EXISTS user_id bigint,
+    ADD COLUMN IF NOT EXISTS url text,
+    ADD COLUMN IF NOT EXISTS secret text,
+    ADD COLUMN IF NOT EXISTS event_types text,
+    ADD COLUMN IF NOT EXISTS low_balance_threshold decimal,
+    ADD COLUMN IF NOT EXISTS consecutive_failures bigint,
+    ADD COLUMN IF NOT EXISTS disabled_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_webhook_endpoints_user_id ON webhook_endpoints (user_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_endpoints_deleted_at ON webhook_endpoints (deleted_at);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    endpoint_id bigint,
+    event_id text,
+    event_type text,
+    payload bytea,
+    status text,
+    attempts bigint,
+    next_attempt_at timestamptz,
+    last_status_code bigint,
+    last_error text,
+    delivered_at timestamptz
+);
+ALTER TABLE webhook_deliveries
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz,
+    ADD COLUMN IF NOT EXISTS endpoint_id bigint,
+    ADD COLUMN IF NOT EXISTS event_id text,
+    ADD COLUMN IF NOT EXISTS event_type text,
+    ADD COLUMN IF NOT EXISTS payload bytea,
+    ADD COLUMN IF NOT EXISTS status text,
+    ADD COLUMN IF NOT EXISTS attempts bigint,
+    ADD COLUMN IF NOT EXISTS next_attempt_at timestamptz,
+    ADD COLUMN IF NOT EXISTS last_status_code bigint,
+    ADD COLUMN IF NOT EXISTS last_error text,
+    ADD COLUMN IF NOT EXISTS delivered_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status ON webhook_deliveries (status);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_delivery ON webhook_deliveries (endpoint_id, event_id, event_type);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_deleted_at ON webhook_deliveries (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_next_attempt_at ON webhook_deliveries (next_attempt_at);
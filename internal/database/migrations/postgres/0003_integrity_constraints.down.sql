@@ -0,0 +1,27 @@
+ALTER TABLE account_members
+    DROP CONSTRAINT IF EXISTS chk_account_members_limit,
+    DROP CONSTRAINT IF EXISTS chk_account_members_role,
+    DROP CONSTRAINT IF EXISTS fk_account_members_user,
+    DROP CONSTRAINT IF EXISTS fk_account_members_account;
+
+DROP INDEX IF EXISTS idx_transfer_expiry;
+DROP INDEX IF EXISTS idx_transfers_receiver_id;
+DROP INDEX IF EXISTS idx_transfers_sender_id;
+ALTER TABLE transfers
+    DROP CONSTRAINT IF EXISTS chk_transfers_status,
+    DROP CONSTRAINT IF EXISTS chk_transfers_amount,
+    DROP CONSTRAINT IF EXISTS fk_transfers_receiver,
+    DROP CONSTRAINT IF EXISTS fk_transfers_sender;
+
+ALTER TABLE transactions
+    DROP CONSTRAINT IF EXISTS chk_transactions_type,
+    DROP CONSTRAINT IF EXISTS chk_transactions_amount,
+    DROP CONSTRAINT IF EXISTS fk_transactions_account;
+
+DROP INDEX IF EXISTS idx_bank_accounts_user_id;
+ALTER TABLE bank_accounts
+    DROP CONSTRAINT IF EXISTS chk_bank_accounts_balance,
+    DROP CONSTRAINT IF EXISTS fk_bank_accounts_user;
+
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS chk_users_role;
@@ -0,0 +1,29 @@
+-- Fails if existing rows break a constraint; fix them and migrate again.
+
+ALTER TABLE users
+    ADD CONSTRAINT chk_users_role CHECK (role IN ('customer', 'support', 'admin'));
+
+ALTER TABLE bank_accounts
+    ADD CONSTRAINT fk_bank_accounts_user FOREIGN KEY (user_id) REFERENCES users (id),
+    ADD CONSTRAINT chk_bank_accounts_balance CHECK (balance >= 0);
+CREATE INDEX IF NOT EXISTS idx_bank_accounts_user_id ON bank_accounts (user_id);
+
+ALTER TABLE transactions
+    ADD CONSTRAINT fk_transactions_account FOREIGN KEY (account_number) REFERENCES bank_accounts (account_number),
+    ADD CONSTRAINT chk_transactions_amount CHECK (amount > 0),
+    ADD CONSTRAINT chk_transactions_type CHECK (type IN ('DEPOSIT', 'WITHDRAW', 'TRANSFER'));
+
+ALTER TABLE transfers
+    ADD CONSTRAINT fk_transfers_sender FOREIGN KEY (sender_id) REFERENCES users (id),
+    ADD CONSTRAINT fk_transfers_receiver FOREIGN KEY (receiver_id) REFERENCES users (id),
+    ADD CONSTRAINT chk_transfers_amount CHECK (amount > 0),
+    ADD CONSTRAINT chk_transfers_status CHECK (status IN ('PENDING', 'ACCEPTED', 'EXPIRED'));
+CREATE INDEX IF NOT EXISTS idx_transfers_sender_id ON transfers (sender_id);
+CREATE INDEX IF NOT EXISTS idx_transfers_receiver_id ON transfers (receiver_id);
+CREATE INDEX IF NOT EXISTS idx_transfer_expiry ON transfers (status, expires_on);
+
+ALTER TABLE account_members
+    ADD CONSTRAINT fk_account_members_account FOREIGN KEY (account_number) REFERENCES bank_accounts (account_number),
+    ADD CONSTRAINT fk_account_members_user FOREIGN KEY (user_id) REFERENCES users (id),
+    ADD CONSTRAINT chk_account_members_role CHECK (role IN ('owner', 'co-owner', 'viewer', 'transactor')),
+    ADD CONSTRAINT chk_account_members_limit CHECK (transaction_limit >= 0);
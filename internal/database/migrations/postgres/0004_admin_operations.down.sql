@@ -0,0 +1,7 @@
+-- Fails once adjustments or refunds have been posted.
+
+ALTER TABLE transactions
+    DROP CONSTRAINT IF EXISTS chk_transactions_type,
+    ADD CONSTRAINT chk_transactions_type CHECK (type IN ('DEPOSIT', 'WITHDRAW', 'TRANSFER'));
+
+ALTER TABLE bank_accounts DROP COLUMN IF EXISTS closed_at;
@@ -0,0 +1,5 @@
+ALTER TABLE bank_accounts ADD COLUMN IF NOT EXISTS closed_at timestamptz;
+
+ALTER TABLE transactions
+    DROP CONSTRAINT IF EXISTS chk_transactions_type,
+    ADD CONSTRAINT chk_transactions_type CHECK (type IN ('DEPOSIT', 'WITHDRAW', 'TRANSFER', 'ADJUSTMENT_CREDIT', 'ADJUSTMENT_DEBIT', 'REFUND'));
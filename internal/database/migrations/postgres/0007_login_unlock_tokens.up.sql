@@ -0,0 +1,14 @@
+CREATE TABLE login_unlock_tokens (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint NOT NULL,
+    token_hash text NOT NULL,
+    expires_on timestamptz NOT NULL,
+    used_at timestamptz,
+    CONSTRAINT uni_login_unlock_tokens_token_hash UNIQUE (token_hash),
+    CONSTRAINT fk_login_unlock_tokens_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX idx_login_unlock_tokens_user_id ON login_unlock_tokens (user_id);
+CREATE INDEX idx_login_unlock_tokens_deleted_at ON login_unlock_tokens (deleted_at);
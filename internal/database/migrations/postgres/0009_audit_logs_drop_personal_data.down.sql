@@ -0,0 +1 @@
+-- The removed personal data cannot be restored; nothing to undo.
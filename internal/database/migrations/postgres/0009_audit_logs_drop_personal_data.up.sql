@@ -0,0 +1,17 @@
+-- Audit snapshots no longer carry names, email addresses, IPs or user
+-- agents, which would otherwise outlive an erasure. Strip them from the
+-- entries written before, pausing the append-only trigger to do so.
+ALTER TABLE audit_logs DISABLE TRIGGER audit_logs_append_only;
+
+UPDATE audit_logs
+SET before = convert_to((convert_from(before, 'UTF8')::jsonb - 'email' - 'firstName' - 'lastName' - 'ip' - 'userAgent')::text, 'UTF8')
+WHERE before IS NOT NULL AND jsonb_typeof(convert_from(before, 'UTF8')::jsonb) = 'object';
+
+UPDATE audit_logs
+SET after = convert_to((convert_from(after, 'UTF8')::jsonb - 'email' - 'firstName' - 'lastName' - 'ip' - 'userAgent')::text, 'UTF8')
+WHERE after IS NOT NULL AND jsonb_typeof(convert_from(after, 'UTF8')::jsonb) = 'object';
+
+-- Failed logins for unknown addresses used the address as the entity ID.
+UPDATE audit_logs SET entity_id = '' WHERE entity_type = 'user' AND entity_id LIKE '%@%';
+
+ALTER TABLE audit_logs ENABLE TRIGGER audit_logs_append_only;
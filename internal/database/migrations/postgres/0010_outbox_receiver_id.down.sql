@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_outbox_events_receiver_id;
+ALTER TABLE outbox_events DROP COLUMN IF EXISTS receiver_id;
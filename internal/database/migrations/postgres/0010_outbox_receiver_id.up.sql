@@ -0,0 +1,5 @@
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS receiver_id bigint;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_receiver_id ON outbox_events (receiver_id);
+UPDATE outbox_events
+SET receiver_id = (convert_from(payload, 'UTF8')::jsonb ->> 'receiverId')::bigint
+WHERE type = 'TransferSent' AND receiver_id IS NULL;
@@ -0,0 +1,7 @@
+-- Accounts created before joint accounts existed get their owner as the
+-- sole holder. Transactions recorded before the ledger was hashed are
+-- chained by the Go step of this migration.
+INSERT INTO account_members (created_at, updated_at, account_number, user_id, role, invited_by, accepted_at)
+SELECT CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, b.account_number, b.user_id, 'owner', b.user_id, CURRENT_TIMESTAMP
+FROM bank_accounts b
+WHERE NOT EXISTS (SELECT 1 FROM account_members m WHERE m.account_number = b.account_number);
@@ -0,0 +1 @@
+ALTER TABLE o_auth_refresh_tokens DROP COLUMN IF EXISTS scopes;
@@ -0,0 +1,5 @@
+-- Tokens issued before refresh tokens kept their own scopes get those of
+-- their consent.
+ALTER TABLE o_auth_refresh_tokens ADD COLUMN IF NOT EXISTS scopes text;
+UPDATE o_auth_refresh_tokens
+SET scopes = (SELECT c.scopes FROM o_auth_consents c WHERE c.id = o_auth_refresh_tokens.consent_id);
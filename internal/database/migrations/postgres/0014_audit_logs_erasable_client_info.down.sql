@@ -0,0 +1,3 @@
+CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+    BEGIN RAISE EXCEPTION 'audit_logs is append-only'; END;
+$$ LANGUAGE plpgsql;
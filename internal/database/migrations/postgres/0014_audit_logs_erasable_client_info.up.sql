@@ -0,0 +1,12 @@
+-- Erasure clears the IP and user agent of a user's audit entries. Allow an
+-- update that blanks exactly those two columns and leaves the rest as it was;
+-- every other update or delete is still rejected.
+CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+    BEGIN
+        IF TG_OP = 'UPDATE' AND NEW.ip = '' AND NEW.user_agent = ''
+            AND to_jsonb(NEW) - 'ip' - 'user_agent' = to_jsonb(OLD) - 'ip' - 'user_agent' THEN
+            RETURN NEW;
+        END IF;
+        RAISE EXCEPTION 'audit_logs is append-only';
+    END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,21 @@
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_endpoints;
+DROP TABLE IF EXISTS outbox_events;
+DROP TABLE IF EXISTS audit_logs;
+DROP TABLE IF EXISTS data_requests;
+DROP TABLE IF EXISTS account_members;
+DROP TABLE IF EXISTS o_auth_refresh_tokens;
+DROP TABLE IF EXISTS o_auth_consents;
+DROP TABLE IF EXISTS o_auth_authorization_codes;
+DROP TABLE IF EXISTS o_auth_authorization_requests;
+DROP TABLE IF EXISTS o_auth_clients;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS login_attempts;
+DROP TABLE IF EXISTS email_verifications;
+DROP TABLE IF EXISTS password_reset_tokens;
+DROP TABLE IF EXISTS login_challenges;
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS transfers;
+DROP TABLE IF EXISTS transactions;
+DROP TABLE IF EXISTS bank_accounts;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,343 @@
+-- The schema as AutoMigrate last left it. Tables and indexes that already
+-- exist are kept, so databases created before versioned migrations are
+-- adopted as they are.
+
+CREATE TABLE IF NOT EXISTS users (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    email text,
+    first_name text,
+    last_name text,
+    password text,
+    role text DEFAULT 'customer',
+    email_verified_at datetime,
+    totp_secret text,
+    totp_enabled numeric,
+    sessions_valid_from datetime,
+    erased_at datetime,
+    CONSTRAINT uni_users_email UNIQUE (email)
+);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS bank_accounts (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    account_number text,
+    user_id integer,
+    balance real,
+    frozen_at datetime,
+    CONSTRAINT uni_bank_accounts_account_number UNIQUE (account_number)
+);
+CREATE INDEX IF NOT EXISTS idx_bank_accounts_deleted_at ON bank_accounts (deleted_at);
+
+CREATE TABLE IF NOT EXISTS transactions (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    amount real,
+    account_number text,
+    transaction_id text,
+    type text,
+    sequence integer,
+    prev_hash text,
+    hash text,
+    CONSTRAINT uni_transactions_transaction_id UNIQUE (transaction_id)
+);
+CREATE INDEX IF NOT EXISTS idx_transaction_chain ON transactions (account_number, sequence);
+CREATE INDEX IF NOT EXISTS idx_transactions_deleted_at ON transactions (deleted_at);
+
+CREATE TABLE IF NOT EXISTS transfers (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    sender_id integer,
+    receiver_id integer,
+    amount real,
+    status text,
+    expires_on datetime,
+    transaction_id text,
+    CONSTRAINT uni_transfers_transaction_id UNIQUE (transaction_id)
+);
+CREATE INDEX IF NOT EXISTS idx_transfers_deleted_at ON transfers (deleted_at);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    user_id integer,
+    code_hash text,
+    used_at datetime,
+    CONSTRAINT uni_recovery_codes_code_hash UNIQUE (code_hash)
+);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes (user_id);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_deleted_at ON recovery_codes (deleted_at);
+
+CREATE TABLE IF NOT EXISTS login_challenges (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    challenge_hash text,
+    user_id integer,
+    expires_on datetime,
+    attempts integer,
+    completed_at datetime,
+    CONSTRAINT uni_login_challenges_challenge_hash UNIQUE (challenge_hash)
+);
+CREATE INDEX IF NOT EXISTS idx_login_challenges_user_id ON login_challenges (user_id);
+CREATE INDEX IF NOT EXISTS idx_login_challenges_deleted_at ON login_challenges (deleted_at);
+
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    user_id integer,
+    token_hash text,
+    expires_on datetime,
+    used_at datetime,
+    CONSTRAINT uni_password_reset_tokens_token_hash UNIQUE (token_hash)
+);
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_deleted_at ON password_reset_tokens (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON password_reset_tokens (user_id);
+
+CREATE TABLE IF NOT EXISTS email_verifications (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    user_id integer,
+    email text
+);
+CREATE INDEX IF NOT EXISTS idx_email_verifications_user_id ON email_verifications (user_id);
+CREATE INDEX IF NOT EXISTS idx_email_verifications_deleted_at ON email_verifications (deleted_at);
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    email text,
+    user_id integer,
+    ip text,
+    user_agent text,
+    success numeric,
+    reason text
+);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_deleted_at ON login_attempts (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_ip ON login_attempts (ip);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_user_id ON login_attempts (user_id);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_email ON login_attempts (email);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    user_id integer,
+    name text,
+    prefix text,
+    key_hash text,
+    scopes text,
+    allowed_ips text,
+    expires_at datetime,
+    last_used_at datetime,
+    revoked_at datetime,
+    CONSTRAINT uni_api_keys_key_hash UNIQUE (key_hash)
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
+CREATE INDEX IF NOT EXISTS idx_api_keys_deleted_at ON api_keys (deleted_at);
+
+CREATE TABLE IF NOT EXISTS o_auth_clients (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    client_id text,
+    client_secret_hash text,
+    name text,
+    redirect_uris text,
+    scopes text,
+    owner_id integer,
+    CONSTRAINT uni_o_auth_clients_client_id UNIQUE (client_id)
+);
+CREATE INDEX IF NOT EXISTS idx_o_auth_clients_deleted_at ON o_auth_clients (deleted_at);
+
+CREATE TABLE IF NOT EXISTS o_auth_authorization_requests (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    request_hash text,
+    user_id integer,
+    client_id text,
+    redirect_uri text,
+    scopes text,
+    state text,
+    code_challenge text,
+    expires_on datetime,
+    CONSTRAINT uni_o_auth_authorization_requests_request_hash UNIQUE (request_hash)
+);
+CREATE INDEX IF NOT EXISTS idx_o_auth_authorization_requests_user_id ON o_auth_authorization_requests (user_id);
+CREATE INDEX IF NOT EXISTS idx_o_auth_authorization_requests_deleted_at ON o_auth_authorization_requests (deleted_at);
+
+CREATE TABLE IF NOT EXISTS o_auth_authorization_codes (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    code_hash text,
+    consent_id integer,
+    user_id integer,
+    client_id text,
+    redirect_uri text,
+    scopes text,
+    code_challenge text,
+    expires_on datetime,
+    used_at datetime,
+    CONSTRAINT uni_o_auth_authorization_codes_code_hash UNIQUE (code_hash)
+);
+CREATE INDEX IF NOT EXISTS idx_o_auth_authorization_codes_deleted_at ON o_auth_authorization_codes (deleted_at);
+
+CREATE TABLE IF NOT EXISTS o_auth_consents (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    user_id integer,
+    client_id text,
+    scopes text,
+    revoked_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_o_auth_consents_client_id ON o_auth_consents (client_id);
+CREATE INDEX IF NOT EXISTS idx_o_auth_consents_user_id ON o_auth_consents (user_id);
+CREATE INDEX IF NOT EXISTS idx_o_auth_consents_deleted_at ON o_auth_consents (deleted_at);
+
+CREATE TABLE IF NOT EXISTS o_auth_refresh_tokens (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    token_hash text,
+    consent_id integer,
+    expires_on datetime,
+    used_at datetime,
+    CONSTRAINT uni_o_auth_refresh_tokens_token_hash UNIQUE (token_hash)
+);
+CREATE INDEX IF NOT EXISTS idx_o_auth_refresh_tokens_consent_id ON o_auth_refresh_tokens (consent_id);
+CREATE INDEX IF NOT EXISTS idx_o_auth_refresh_tokens_deleted_at ON o_auth_refresh_tokens (deleted_at);
+
+CREATE TABLE IF NOT EXISTS account_members (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    account_number text,
+    user_id integer,
+    role text,
+    transaction_limit real,
+    invited_by integer,
+    accepted_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_account_members_user_id ON account_members (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_member ON account_members (account_number, user_id);
+CREATE INDEX IF NOT EXISTS idx_account_members_deleted_at ON account_members (deleted_at);
+
+CREATE TABLE IF NOT EXISTS data_requests (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    user_id integer,
+    requested_by integer,
+    type text,
+    status text,
+    ip text,
+    user_agent text,
+    error text,
+    completed_at datetime,
+    expires_at datetime,
+    archive blob
+);
+CREATE INDEX IF NOT EXISTS idx_data_requests_user_id ON data_requests (user_id);
+CREATE INDEX IF NOT EXISTS idx_data_requests_deleted_at ON data_requests (deleted_at);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    actor_id integer,
+    auth_method text,
+    ip text,
+    user_agent text,
+    request_id text,
+    action text,
+    entity_type text,
+    entity_id text,
+    before blob,
+    after blob
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_request_id ON audit_logs (request_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_id ON audit_logs (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_entity ON audit_logs (entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs (action);
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    event_id text,
+    type text,
+    aggregate_id text,
+    payload blob,
+    occurred_at datetime,
+    published_at datetime,
+    attempts integer,
+    last_error text,
+    CONSTRAINT uni_outbox_events_event_id UNIQUE (event_id)
+);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_type ON outbox_events (type);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_published_at ON outbox_events (published_at);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_aggregate_id ON outbox_events (aggregate_id);
+
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    user_id integer,
+    url text,
+    secret text,
+    event_types text,
+    low_balance_threshold real,
+    consecutive_failures integer,
+    disabled_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_endpoints_user_id ON webhook_endpoints (user_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_endpoints_deleted_at ON webhook_endpoints (deleted_at);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    endpoint_id integer,
+    event_id text,
+    event_type text,
+    payload blob,
+    status text,
+    attempts integer,
+    next_attempt_at datetime,
+    last_status_code integer,
+    last_error text,
+    delivered_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status ON webhook_deliveries (status);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_delivery ON webhook_deliveries (endpoint_id, event_id, event_type);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_deleted_at ON webhook_deliveries (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_next_attempt_at ON webhook_deliveries (next_attempt_at);
@@ -0,0 +1,6 @@
+-- The audit log is append-only: reject any attempt to rewrite history.
+CREATE TRIGGER IF NOT EXISTS audit_logs_append_only_update BEFORE UPDATE ON audit_logs
+BEGIN SELECT RAISE(ABORT, 'audit_logs is append-only'); END;
+
+CREATE TRIGGER IF NOT EXISTS audit_logs_append_only_delete BEFORE DELETE ON audit_logs
+BEGIN SELECT RAISE(ABORT, 'audit_logs is append-only'); END;
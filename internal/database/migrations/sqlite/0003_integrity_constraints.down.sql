@@ -0,0 +1,101 @@
+-- Rebuilds the tables without the constraints, children first.
+
+CREATE TABLE account_members_new (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    account_number text,
+    user_id integer,
+    role text,
+    transaction_limit real,
+    invited_by integer,
+    accepted_at datetime
+);
+INSERT INTO account_members_new (id, created_at, updated_at, deleted_at, account_number, user_id, role, transaction_limit, invited_by, accepted_at)
+    SELECT id, created_at, updated_at, deleted_at, account_number, user_id, role, transaction_limit, invited_by, accepted_at FROM account_members;
+DROP TABLE account_members;
+ALTER TABLE account_members_new RENAME TO account_members;
+CREATE INDEX idx_account_members_user_id ON account_members (user_id);
+CREATE UNIQUE INDEX idx_account_member ON account_members (account_number, user_id);
+CREATE INDEX idx_account_members_deleted_at ON account_members (deleted_at);
+
+CREATE TABLE transfers_new (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    sender_id integer,
+    receiver_id integer,
+    amount real,
+    status text,
+    expires_on datetime,
+    transaction_id text,
+    CONSTRAINT uni_transfers_transaction_id UNIQUE (transaction_id)
+);
+INSERT INTO transfers_new (id, created_at, updated_at, deleted_at, sender_id, receiver_id, amount, status, expires_on, transaction_id)
+    SELECT id, created_at, updated_at, deleted_at, sender_id, receiver_id, amount, status, expires_on, transaction_id FROM transfers;
+DROP TABLE transfers;
+ALTER TABLE transfers_new RENAME TO transfers;
+CREATE INDEX idx_transfers_deleted_at ON transfers (deleted_at);
+
+CREATE TABLE transactions_new (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    amount real,
+    account_number text,
+    transaction_id text,
+    type text,
+    sequence integer,
+    prev_hash text,
+    hash text,
+    CONSTRAINT uni_transactions_transaction_id UNIQUE (transaction_id)
+);
+INSERT INTO transactions_new (id, created_at, updated_at, deleted_at, amount, account_number, transaction_id, type, sequence, prev_hash, hash)
+    SELECT id, created_at, updated_at, deleted_at, amount, account_number, transaction_id, type, sequence, prev_hash, hash FROM transactions;
+DROP TABLE transactions;
+ALTER TABLE transactions_new RENAME TO transactions;
+CREATE INDEX idx_transaction_chain ON transactions (account_number, sequence);
+CREATE INDEX idx_transactions_deleted_at ON transactions (deleted_at);
+
+CREATE TABLE bank_accounts_new (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    account_number text,
+    user_id integer,
+    balance real,
+    frozen_at datetime,
+    CONSTRAINT uni_bank_accounts_account_number UNIQUE (account_number)
+);
+INSERT INTO bank_accounts_new (id, created_at, updated_at, deleted_at, account_number, user_id, balance, frozen_at)
+    SELECT id, created_at, updated_at, deleted_at, account_number, user_id, balance, frozen_at FROM bank_accounts;
+DROP TABLE bank_accounts;
+ALTER TABLE bank_accounts_new RENAME TO bank_accounts;
+CREATE INDEX idx_bank_accounts_deleted_at ON bank_accounts (deleted_at);
+
+CREATE TABLE users_new (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    email text,
+    first_name text,
+    last_name text,
+    password text,
+    role text DEFAULT 'customer',
+    email_verified_at datetime,
+    totp_secret text,
+    totp_enabled numeric,
+    sessions_valid_from datetime,
+    erased_at datetime,
+    CONSTRAINT uni_users_email UNIQUE (email)
+);
+INSERT INTO users_new (id, created_at, updated_at, deleted_at, email, first_name, last_name, password, role, email_verified_at, totp_secret, totp_enabled, sessions_valid_from, erased_at)
+    SELECT id, created_at, updated_at, deleted_at, email, first_name, last_name, password, role, email_verified_at, totp_secret, totp_enabled, sessions_valid_from, erased_at FROM users;
+DROP TABLE users;
+ALTER TABLE users_new RENAME TO users;
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
@@ -0,0 +1,121 @@
+-- SQLite cannot add constraints to a table, so each table is rebuilt with
+-- them. Parents come before the tables referencing them. Fails if existing
+-- rows break a constraint; fix them and migrate again.
+
+CREATE TABLE users_new (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    email text,
+    first_name text,
+    last_name text,
+    password text,
+    role text DEFAULT 'customer',
+    email_verified_at datetime,
+    totp_secret text,
+    totp_enabled numeric,
+    sessions_valid_from datetime,
+    erased_at datetime,
+    CONSTRAINT uni_users_email UNIQUE (email),
+    CONSTRAINT chk_users_role CHECK (role IN ('customer', 'support', 'admin'))
+);
+INSERT INTO users_new (id, created_at, updated_at, deleted_at, email, first_name, last_name, password, role, email_verified_at, totp_secret, totp_enabled, sessions_valid_from, erased_at)
+    SELECT id, created_at, updated_at, deleted_at, email, first_name, last_name, password, role, email_verified_at, totp_secret, totp_enabled, sessions_valid_from, erased_at FROM users;
+DROP TABLE users;
+ALTER TABLE users_new RENAME TO users;
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE bank_accounts_new (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    account_number text,
+    user_id integer,
+    balance real,
+    frozen_at datetime,
+    CONSTRAINT uni_bank_accounts_account_number UNIQUE (account_number),
+    CONSTRAINT fk_bank_accounts_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT chk_bank_accounts_balance CHECK (balance >= 0)
+);
+INSERT INTO bank_accounts_new (id, created_at, updated_at, deleted_at, account_number, user_id, balance, frozen_at)
+    SELECT id, created_at, updated_at, deleted_at, account_number, user_id, balance, frozen_at FROM bank_accounts;
+DROP TABLE bank_accounts;
+ALTER TABLE bank_accounts_new RENAME TO bank_accounts;
+CREATE INDEX idx_bank_accounts_deleted_at ON bank_accounts (deleted_at);
+CREATE INDEX idx_bank_accounts_user_id ON bank_accounts (user_id);
+
+CREATE TABLE transactions_new (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    amount real,
+    account_number text,
+    transaction_id text,
+    type text,
+    sequence integer,
+    prev_hash text,
+    hash text,
+    CONSTRAINT uni_transactions_transaction_id UNIQUE (transaction_id),
+    CONSTRAINT fk_transactions_account FOREIGN KEY (account_number) REFERENCES bank_accounts (account_number),
+    CONSTRAINT chk_transactions_amount CHECK (amount > 0),
+    CONSTRAINT chk_transactions_type CHECK (type IN ('DEPOSIT', 'WITHDRAW', 'TRANSFER'))
+);
+INSERT INTO transactions_new (id, created_at, updated_at, deleted_at, amount, account_number, transaction_id, type, sequence, prev_hash, hash)
+    SELECT id, created_at, updated_at, deleted_at, amount, account_number, transaction_id, type, sequence, prev_hash, hash FROM transactions;
+DROP TABLE transactions;
+ALTER TABLE transactions_new RENAME TO transactions;
+CREATE INDEX idx_transaction_chain ON transactions (account_number, sequence);
+CREATE INDEX idx_transactions_deleted_at ON transactions (deleted_at);
+
+CREATE TABLE transfers_new (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    sender_id integer,
+    receiver_id integer,
+    amount real,
+    status text,
+    expires_on datetime,
+    transaction_id text,
+    CONSTRAINT uni_transfers_transaction_id UNIQUE (transaction_id),
+    CONSTRAINT fk_transfers_sender FOREIGN KEY (sender_id) REFERENCES users (id),
+    CONSTRAINT fk_transfers_receiver FOREIGN KEY (receiver_id) REFERENCES users (id),
+    CONSTRAINT chk_transfers_amount CHECK (amount > 0),
+    CONSTRAINT chk_transfers_status CHECK (status IN ('PENDING', 'ACCEPTED', 'EXPIRED'))
+);
+INSERT INTO transfers_new (id, created_at, updated_at, deleted_at, sender_id, receiver_id, amount, status, expires_on, transaction_id)
+    SELECT id, created_at, updated_at, deleted_at, sender_id, receiver_id, amount, status, expires_on, transaction_id FROM transfers;
+DROP TABLE transfers;
+ALTER TABLE transfers_new RENAME TO transfers;
+CREATE INDEX idx_transfers_deleted_at ON transfers (deleted_at);
+CREATE INDEX idx_transfers_sender_id ON transfers (sender_id);
+CREATE INDEX idx_transfers_receiver_id ON transfers (receiver_id);
+CREATE INDEX idx_transfer_expiry ON transfers (status, expires_on);
+
+CREATE TABLE account_members_new (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    account_number text,
+    user_id integer,
+    role text,
+    transaction_limit real,
+    invited_by integer,
+    accepted_at datetime,
+    CONSTRAINT fk_account_members_account FOREIGN KEY (account_number) REFERENCES bank_accounts (account_number),
+    CONSTRAINT fk_account_members_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT chk_account_members_role CHECK (role IN ('owner', 'co-owner', 'viewer', 'transactor')),
+    CONSTRAINT chk_account_members_limit CHECK (transaction_limit >= 0)
+);
+INSERT INTO account_members_new (id, created_at, updated_at, deleted_at, account_number, user_id, role, transaction_limit, invited_by, accepted_at)
+    SELECT id, created_at, updated_at, deleted_at, account_number, user_id, role, transaction_limit, invited_by, accepted_at FROM account_members;
+DROP TABLE account_members;
+ALTER TABLE account_members_new RENAME TO account_members;
+CREATE INDEX idx_account_members_user_id ON account_members (user_id);
+CREATE UNIQUE INDEX idx_account_member ON account_members (account_number, user_id);
+CREATE INDEX idx_account_members_deleted_at ON account_members (deleted_at);
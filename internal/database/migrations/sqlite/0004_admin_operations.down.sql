@@ -0,0 +1,27 @@
+-- Fails once adjustments or refunds have been posted.
+
+CREATE TABLE transactions_new (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    amount real,
+    account_number text,
+    transaction_id text,
+    type text,
+    sequence integer,
+    prev_hash text,
+    hash text,
+    CONSTRAINT uni_transactions_transaction_id UNIQUE (transaction_id),
+    CONSTRAINT fk_transactions_account FOREIGN KEY (account_number) REFERENCES bank_accounts (account_number),
+    CONSTRAINT chk_transactions_amount CHECK (amount > 0),
+    CONSTRAINT chk_transactions_type CHECK (type IN ('DEPOSIT', 'WITHDRAW', 'TRANSFER'))
+);
+INSERT INTO transactions_new (id, created_at, updated_at, deleted_at, amount, account_number, transaction_id, type, sequence, prev_hash, hash)
+    SELECT id, created_at, updated_at, deleted_at, amount, account_number, transaction_id, type, sequence, prev_hash, hash FROM transactions;
+DROP TABLE transactions;
+ALTER TABLE transactions_new RENAME TO transactions;
+CREATE INDEX idx_transaction_chain ON transactions (account_number, sequence);
+CREATE INDEX idx_transactions_deleted_at ON transactions (deleted_at);
+
+ALTER TABLE bank_accounts DROP COLUMN closed_at;
@@ -0,0 +1,5 @@
+-- Accounts that predate email verification were never sent a link, and
+-- RequireVerifiedEmail would lock them out. Treat every address present when
+-- this runs as verified; later sign-ups go through the link as usual.
+UPDATE users SET email_verified_at = COALESCE(created_at, CURRENT_TIMESTAMP)
+WHERE email_verified_at IS NULL AND erased_at IS NULL;
@@ -0,0 +1,14 @@
+CREATE TABLE login_unlock_tokens (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    user_id integer NOT NULL,
+    token_hash text NOT NULL,
+    expires_on datetime NOT NULL,
+    used_at datetime,
+    CONSTRAINT uni_login_unlock_tokens_token_hash UNIQUE (token_hash),
+    CONSTRAINT fk_login_unlock_tokens_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX idx_login_unlock_tokens_user_id ON login_unlock_tokens (user_id);
+CREATE INDEX idx_login_unlock_tokens_deleted_at ON login_unlock_tokens (deleted_at);
@@ -0,0 +1,5 @@
+-- Email addresses are matched without regard to case. This fails if two
+-- users differ only in the case of their address; merge or rename one of
+-- them by hand before migrating.
+CREATE UNIQUE INDEX idx_users_email_lower ON users (LOWER(email));
+UPDATE users SET email = LOWER(TRIM(email)) WHERE email <> LOWER(TRIM(email));
@@ -0,0 +1,18 @@
+-- Audit snapshots no longer carry names, email addresses, IPs or user
+-- agents, which would otherwise outlive an erasure. Strip them from the
+-- entries written before, lifting the append-only trigger to do so.
+DROP TRIGGER IF EXISTS audit_logs_append_only_update;
+
+UPDATE audit_logs
+SET before = CAST(json_remove(CAST(before AS TEXT), '$.email', '$.firstName', '$.lastName', '$.ip', '$.userAgent') AS BLOB)
+WHERE before IS NOT NULL AND json_type(CAST(before AS TEXT)) = 'object';
+
+UPDATE audit_logs
+SET after = CAST(json_remove(CAST(after AS TEXT), '$.email', '$.firstName', '$.lastName', '$.ip', '$.userAgent') AS BLOB)
+WHERE after IS NOT NULL AND json_type(CAST(after AS TEXT)) = 'object';
+
+-- Failed logins for unknown addresses used the address as the entity ID.
+UPDATE audit_logs SET entity_id = '' WHERE entity_type = 'user' AND entity_id LIKE '%@%';
+
+CREATE TRIGGER audit_logs_append_only_update BEFORE UPDATE ON audit_logs
+BEGIN SELECT RAISE(ABORT, 'audit_logs is append-only'); END;
@@ -0,0 +1,2 @@
+DROP INDEX idx_outbox_events_receiver_id;
+ALTER TABLE outbox_events DROP COLUMN receiver_id;
@@ -0,0 +1,5 @@
+ALTER TABLE outbox_events ADD COLUMN receiver_id integer;
+CREATE INDEX idx_outbox_events_receiver_id ON outbox_events (receiver_id);
+UPDATE outbox_events
+SET receiver_id = json_extract(CAST(payload AS TEXT), '$.receiverId')
+WHERE type = 'TransferSent' AND receiver_id IS NULL;
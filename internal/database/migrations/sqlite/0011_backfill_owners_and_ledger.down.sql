@@ -0,0 +1 @@
+-- The backfilled owners and ledger hashes are kept.
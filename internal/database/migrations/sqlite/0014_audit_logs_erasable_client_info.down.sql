@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS audit_logs_append_only_update;
+
+CREATE TRIGGER audit_logs_append_only_update BEFORE UPDATE ON audit_logs
+BEGIN SELECT RAISE(ABORT, 'audit_logs is append-only'); END;
@@ -0,0 +1,15 @@
+-- Erasure clears the IP and user agent of a user's audit entries. Allow an
+-- update that blanks exactly those two columns and leaves the rest as it was;
+-- every other update is still rejected.
+DROP TRIGGER IF EXISTS audit_logs_append_only_update;
+
+CREATE TRIGGER audit_logs_append_only_update BEFORE UPDATE ON audit_logs
+WHEN NOT (
+    NEW.ip = '' AND NEW.user_agent = ''
+    AND NEW.id IS OLD.id AND NEW.created_at IS OLD.created_at
+    AND NEW.actor_id IS OLD.actor_id AND NEW.auth_method IS OLD.auth_method
+    AND NEW.request_id IS OLD.request_id AND NEW.action IS OLD.action
+    AND NEW.entity_type IS OLD.entity_type AND NEW.entity_id IS OLD.entity_id
+    AND NEW.before IS OLD.before AND NEW.after IS OLD.after
+)
+BEGIN SELECT RAISE(ABORT, 'audit_logs is append-only'); END;
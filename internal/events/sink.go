@@ -0,0 +1,128 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+)
+
+// Sink receives published domain events. Delivery is at-least-once, so a
+// sink may see the same event (by EventID) more than once.
+type Sink interface {
+	Publish(event models.OutboxEvent) error
+}
+
+// NewSinksFromEnv builds the sinks named in the comma-separated EVENT_SINKS
+// ("log" and "file"). With none configured events are marked published
+// without being sent anywhere.
+func NewSinksFromEnv() ([]Sink, error) {
+	var sinks []Sink
+	for _, name := range strings.Split(os.Getenv("EVENT_SINKS"), ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "":
+		case "log":
+			sinks = append(sinks, NewLogSink())
+		case "file":
+			path := os.Getenv("EVENT_SINK_FILE")
+			if path == "" {
+				path = "events/events.jsonl"
+			}
+			sink, err := NewFileSink(path)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, sink)
+		default:
+			return nil, fmt.Errorf("unknown event sink %q", name)
+		}
+	}
+	return sinks, nil
+}
+
+// LogSink prints events to the standard logger.
+type LogSink struct{}
+
+func NewLogSink() *LogSink {
+	return &LogSink{}
+}
+
+func (s *LogSink) Publish(event models.OutboxEvent) error {
+	log.Printf("event %s %s on %s: %s", event.EventID, event.Type, event.AggregateID, event.Payload)
+	return nil
+}
+
+// FileSink appends each event as a line of JSON to a file.
+type FileSink struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	return &FileSink{path: path}, nil
+}
+
+func (s *FileSink) Publish(event models.OutboxEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// MemorySink keeps published events in memory, for tests.
+type MemorySink struct {
+	mu     sync.Mutex
+	events []models.OutboxEvent
+	fail   error
+}
+
+func NewMemorySink() *MemorySink {
+	return &MemorySink{}
+}
+
+func (s *MemorySink) Publish(event models.OutboxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.fail != nil {
+		return s.fail
+	}
+	s.events = append(s.events, event)
+	return nil
+}
+
+// Events returns a copy of the events published so far.
+func (s *MemorySink) Events() []models.OutboxEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]models.OutboxEvent(nil), s.events...)
+}
+
+// FailWith makes Publish return err until it is called again with nil.
+func (s *MemorySink) FailWith(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fail = err
+}
@@ -0,0 +1,59 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemorySink(t *testing.T) {
+	sink := NewMemorySink()
+
+	sink.FailWith(errors.New("unavailable"))
+	assert.NotNil(t, sink.Publish(models.OutboxEvent{EventID: "1"}))
+	assert.Empty(t, sink.Events())
+
+	sink.FailWith(nil)
+	assert.Nil(t, sink.Publish(models.OutboxEvent{EventID: "1"}))
+	assert.Nil(t, sink.Publish(models.OutboxEvent{EventID: "2"}))
+
+	published := sink.Events()
+	assert.Len(t, published, 2)
+	assert.Equal(t, "2", published[1].EventID)
+}
+
+func TestFileSinkAppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events", "events.jsonl")
+
+	sink, err := NewFileSink(path)
+	assert.Nil(t, err)
+
+	assert.Nil(t, sink.Publish(models.OutboxEvent{EventID: "1", Type: models.EVENT_ACCOUNT_OPENED, Payload: json.RawMessage(`{}`)}))
+	assert.Nil(t, sink.Publish(models.OutboxEvent{EventID: "2", Type: models.EVENT_FUNDS_DEPOSITED, Payload: json.RawMessage(`{}`)}))
+
+	contents, err := os.ReadFile(path)
+	assert.Nil(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(contents)), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[1], `"type":"FundsDeposited"`)
+}
+
+func TestNewSinksFromEnv(t *testing.T) {
+	t.Setenv("EVENT_SINKS", "log, file")
+	t.Setenv("EVENT_SINK_FILE", filepath.Join(t.TempDir(), "events.jsonl"))
+
+	sinks, err := NewSinksFromEnv()
+	assert.Nil(t, err)
+	assert.Len(t, sinks, 2)
+
+	t.Setenv("EVENT_SINKS", "kafka")
+	_, err = NewSinksFromEnv()
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,165 @@
+package graphqlapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+const (
+	MAX_COMPLEXITY = 1000
+	MAX_DEPTH      = 8
+)
+
+// complexityCalculator estimates the cost of an operation before it runs.
+// Every field costs one, and a list field multiplies the cost of its
+// selections by the number of items it can return.
+type complexityCalculator struct {
+	schema    graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	// visiting holds the fragments being expanded, so a cycle, which
+	// validation rejects later, cannot recurse forever here.
+	visiting map[string]bool
+}
+
+func complexity(schema graphql.Schema, doc *ast.Document, operationName string, variables map[string]interface{}) (int, error) {
+	c := &complexityCalculator{
+		schema:    schema,
+		fragments: map[string]*ast.FragmentDefinition{},
+		variables: variables,
+		visiting:  map[string]bool{},
+	}
+
+	var operation *ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		switch definition := definition.(type) {
+		case *ast.FragmentDefinition:
+			c.fragments[definition.Name.Value] = definition
+		case *ast.OperationDefinition:
+			if operationName == "" || (definition.Name != nil && definition.Name.Value == operationName) {
+				operation = definition
+			}
+		}
+	}
+	if operation == nil {
+		// Leave reporting a missing operation to the executor.
+		return 0, nil
+	}
+
+	root := schema.QueryType()
+	if operation.Operation == ast.OperationTypeMutation {
+		root = schema.MutationType()
+	}
+	return c.selectionSet(root, operation.SelectionSet, 1)
+}
+
+func (c *complexityCalculator) selectionSet(parent *graphql.Object, set *ast.SelectionSet, depth int) (int, error) {
+	if set == nil || parent == nil {
+		return 0, nil
+	}
+	if depth > MAX_DEPTH {
+		return 0, fmt.Errorf("query is nested deeper than %d levels", MAX_DEPTH)
+	}
+
+	total := 0
+	for _, selection := range set.Selections {
+		var cost int
+		var err error
+
+		switch selection := selection.(type) {
+		case *ast.Field:
+			cost, err = c.field(parent, selection, depth)
+		case *ast.InlineFragment:
+			cost, err = c.selectionSet(c.fragmentType(parent, selection.TypeCondition), selection.SelectionSet, depth)
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			if fragment, ok := c.fragments[name]; ok && !c.visiting[name] {
+				c.visiting[name] = true
+				cost, err = c.selectionSet(c.fragmentType(parent, fragment.TypeCondition), fragment.SelectionSet, depth)
+				delete(c.visiting, name)
+			}
+		}
+
+		if err != nil {
+			return 0, err
+		}
+		total += cost
+	}
+	return total, nil
+}
+
+func (c *complexityCalculator) field(parent *graphql.Object, field *ast.Field, depth int) (int, error) {
+	definition, ok := parent.Fields()[field.Name.Value]
+	if !ok || strings.HasPrefix(field.Name.Value, "__") {
+		// Introspection is bounded by the schema, and unknown fields are
+		// rejected by validation.
+		return 1, nil
+	}
+
+	child, isList := unwrap(definition.Type)
+	cost, err := c.selectionSet(child, field.SelectionSet, depth+1)
+	if err != nil {
+		return 0, err
+	}
+
+	if isList {
+		cost *= c.listSize(field)
+	}
+	return 1 + cost, nil
+}
+
+// listSize is the page size the resolver will use: the first argument if
+// there is one, otherwise DEFAULT_PAGE_SIZE.
+func (c *complexityCalculator) listSize(field *ast.Field) int {
+	args := map[string]interface{}{}
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "first" {
+			continue
+		}
+
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(value.Value); err == nil {
+				args["first"] = n
+			}
+		case *ast.Variable:
+			switch n := c.variables[value.Name.Value].(type) {
+			case float64:
+				args["first"] = int(n)
+			case int:
+				args["first"] = n
+			}
+		}
+	}
+	return pageSize(args)
+}
+
+func (c *complexityCalculator) fragmentType(parent *graphql.Object, condition *ast.Named) *graphql.Object {
+	if condition == nil {
+		return parent
+	}
+	object, _ := c.schema.Type(condition.Name.Value).(*graphql.Object)
+	return object
+}
+
+// unwrap strips non-null and list wrappers, reporting whether there was a list.
+func unwrap(t graphql.Output) (*graphql.Object, bool) {
+	isList := false
+	for {
+		switch wrapped := t.(type) {
+		case *graphql.NonNull:
+			t = wrapped.OfType
+		case *graphql.List:
+			isList = true
+			t = wrapped.OfType
+		case *graphql.Object:
+			return wrapped, isList
+		default:
+			return nil, isList
+		}
+	}
+}
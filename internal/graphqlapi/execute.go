@@ -0,0 +1,47 @@
+package graphqlapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// Request is the body of a GraphQL POST.
+type Request struct {
+	Query         string                 `json:"query" binding:"required"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Execute runs a request for the viewer. Requests whose estimated
+// complexity exceeds MAX_COMPLEXITY are rejected before any resolver runs.
+func (s *Schema) Execute(ctx context.Context, viewer Viewer, request Request) *graphql.Result {
+	doc, err := parser.Parse(parser.ParseParams{Source: request.Query})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+
+	cost, err := complexity(s.schema, doc, request.OperationName, request.Variables)
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+	if cost > MAX_COMPLEXITY {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(fmt.Errorf("query complexity %d exceeds the limit of %d", cost, MAX_COMPLEXITY))}
+	}
+
+	ctx = context.WithValue(ctx, viewerKey{}, viewer)
+	ctx = context.WithValue(ctx, loadersKey{}, s.newLoaders(viewer))
+
+	result := graphql.Do(graphql.Params{
+		Schema:         s.schema,
+		RequestString:  request.Query,
+		VariableValues: request.Variables,
+		OperationName:  request.OperationName,
+		Context:        ctx,
+	})
+	result.Extensions = map[string]interface{}{"complexity": cost}
+	return result
+}
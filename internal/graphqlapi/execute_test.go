@@ -0,0 +1,80 @@
+package graphqlapi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/FaizanAC/Go-Banking/internal/mailer"
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSchema(t *testing.T) *Schema {
+	s, err := NewSchema(nil, mailer.NewLogMailer())
+	assert.Nil(t, err)
+	return s
+}
+
+func cost(t *testing.T, s *Schema, query string, variables map[string]interface{}) (int, error) {
+	doc, err := parser.Parse(parser.ParseParams{Source: query})
+	assert.Nil(t, err)
+	return complexity(s.schema, doc, "", variables)
+}
+
+func TestComplexityMultipliesLists(t *testing.T) {
+	s := newTestSchema(t)
+
+	// accounts (1) + 20 * (accountNumber (1) + transactions (1) + 5 * amount (1))
+	n, err := cost(t, s, `{ accounts { accountNumber transactions(first: 5) { amount } } }`, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1+20*(1+1+5), n)
+
+	n, err = cost(t, s, `query($n: Int) { transfers(first: $n) { amount } }`, map[string]interface{}{"n": float64(3)})
+	assert.Nil(t, err)
+	assert.Equal(t, 1+3, n)
+
+	// first is capped at MAX_PAGE_SIZE.
+	n, err = cost(t, s, `{ transfers(first: 5000) { amount } }`, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1+MAX_PAGE_SIZE, n)
+}
+
+func TestComplexityFollowsFragments(t *testing.T) {
+	s := newTestSchema(t)
+
+	n, err := cost(t, s, `{ me { ...profile } } fragment profile on User { email firstName }`, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+}
+
+func TestComplexityLimitsDepth(t *testing.T) {
+	s := newTestSchema(t)
+
+	_, err := cost(t, s, `{ transfers { sender { accounts { transactions { account { transactions { account { transactions { amount } } } } } } } } }`, nil)
+	assert.EqualError(t, err, "query is nested deeper than 8 levels")
+}
+
+func TestExecuteRejectsExpensiveQueries(t *testing.T) {
+	s := newTestSchema(t)
+	viewer := Viewer{Actor: models.Actor{UserID: 1, AuthMethod: models.AUTH_METHOD_JWT}}
+
+	result := s.Execute(context.Background(), viewer, Request{
+		Query: `{ accounts { transactions(first: 100) { account { transactions(first: 100) { amount } } } } }`,
+	})
+
+	assert.Nil(t, result.Data)
+	assert.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0].Message, "exceeds the limit of 1000")
+}
+
+func TestExecuteRequiresScope(t *testing.T) {
+	s := newTestSchema(t)
+	viewer := Viewer{Actor: models.Actor{UserID: 1, AuthMethod: models.AUTH_METHOD_API_KEY}}
+
+	result := s.Execute(context.Background(), viewer, Request{Query: `{ accounts { accountNumber } }`})
+
+	assert.Len(t, result.Errors, 1)
+	assert.Equal(t, "credentials are missing the accounts:read scope", result.Errors[0].Message)
+	assert.Equal(t, 1+DEFAULT_PAGE_SIZE, result.Extensions["complexity"])
+}
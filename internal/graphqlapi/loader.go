@@ -0,0 +1,154 @@
+package graphqlapi
+
+import (
+	"context"
+	"sync"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+)
+
+// loader batches lookups by key to avoid N+1 queries. Load queues a key and
+// returns a thunk; the executor resolves every field of a level before
+// calling thunks, so the first thunk called fetches all queued keys at once.
+type loader[K comparable, V any] struct {
+	fetch func(keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending []K
+	results map[K]V
+	errors  map[K]error
+}
+
+func newLoader[K comparable, V any](fetch func(keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{fetch: fetch, results: map[K]V{}, errors: map[K]error{}}
+}
+
+// Load returns a thunk resolving to the value for key, or to V's zero value
+// when the fetch found nothing for it.
+func (l *loader[K, V]) Load(key K) func() (interface{}, error) {
+	l.mu.Lock()
+	if !l.loaded(key) {
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if !l.loaded(key) {
+			l.flush()
+		}
+		if err := l.errors[key]; err != nil {
+			return nil, err
+		}
+		return l.results[key], nil
+	}
+}
+
+func (l *loader[K, V]) loaded(key K) bool {
+	_, found := l.results[key]
+	_, failed := l.errors[key]
+	return found || failed
+}
+
+func (l *loader[K, V]) flush() {
+	keys := []K{}
+	seen := map[K]bool{}
+	for _, key := range l.pending {
+		if !seen[key] && !l.loaded(key) {
+			keys = append(keys, key)
+			seen[key] = true
+		}
+	}
+	l.pending = nil
+
+	if len(keys) == 0 {
+		return
+	}
+
+	results, err := l.fetch(keys)
+	for _, key := range keys {
+		if err != nil {
+			l.errors[key] = err
+		} else if value, ok := results[key]; ok {
+			l.results[key] = value
+		} else {
+			// Remember misses so they are not fetched again.
+			var zero V
+			l.results[key] = zero
+		}
+	}
+}
+
+type transactionsKey struct {
+	accountNumber string
+	first         int
+}
+
+// loaders are created for each request, so cached values never outlive it.
+type loaders struct {
+	accounts     *loader[string, *models.BankAccount]
+	transactions *loader[transactionsKey, []models.Transaction]
+	users        *loader[uint, *models.User]
+}
+
+type loadersKey struct{}
+
+func (s *Schema) newLoaders(viewer Viewer) *loaders {
+	return &loaders{
+		accounts: newLoader(func(accountNumbers []string) (map[string]*models.BankAccount, error) {
+			accounts, err := s.bankService.GetAccountsByNumbers(viewer.Actor.UserID, accountNumbers)
+			if err != nil {
+				return nil, err
+			}
+
+			byNumber := map[string]*models.BankAccount{}
+			for i := range accounts {
+				byNumber[accounts[i].AccountNumber] = &accounts[i]
+			}
+			return byNumber, nil
+		}),
+		transactions: newLoader(func(keys []transactionsKey) (map[transactionsKey][]models.Transaction, error) {
+			accountNumbers, limit := []string{}, 0
+			for _, key := range keys {
+				accountNumbers = append(accountNumbers, key.accountNumber)
+				limit = max(limit, key.first)
+			}
+
+			transactions, err := s.bankService.GetRecentTransactions(accountNumbers, limit)
+			if err != nil {
+				return nil, err
+			}
+
+			byAccount := map[string][]models.Transaction{}
+			for _, transaction := range transactions {
+				byAccount[transaction.AccountNumber] = append(byAccount[transaction.AccountNumber], transaction)
+			}
+
+			byKey := map[transactionsKey][]models.Transaction{}
+			for _, key := range keys {
+				newest := byAccount[key.accountNumber]
+				byKey[key] = newest[:min(len(newest), key.first)]
+			}
+			return byKey, nil
+		}),
+		users: newLoader(func(ids []uint) (map[uint]*models.User, error) {
+			users, err := s.userService.GetUsersByIDs(ids)
+			if err != nil {
+				return nil, err
+			}
+
+			byID := map[uint]*models.User{}
+			for i := range users {
+				byID[users[i].ID] = &users[i]
+			}
+			return byID, nil
+		}),
+	}
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	l, _ := ctx.Value(loadersKey{}).(*loaders)
+	return l
+}
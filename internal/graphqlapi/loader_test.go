@@ -0,0 +1,46 @@
+package graphqlapi
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoaderBatchesKeys(t *testing.T) {
+	calls := [][]int{}
+	l := newLoader(func(keys []int) (map[int]string, error) {
+		calls = append(calls, keys)
+		return map[int]string{1: "one", 2: "two"}, nil
+	})
+
+	one, two, missing, again := l.Load(1), l.Load(2), l.Load(3), l.Load(1)
+
+	value, err := one()
+	assert.Nil(t, err)
+	assert.Equal(t, "one", value)
+
+	value, _ = two()
+	assert.Equal(t, "two", value)
+	value, _ = missing()
+	assert.Equal(t, "", value)
+	value, _ = again()
+	assert.Equal(t, "one", value)
+
+	// Cached keys, including misses, are not fetched again.
+	l.Load(3)()
+	assert.Equal(t, [][]int{{1, 2, 3}}, calls)
+}
+
+func TestLoaderReportsErrorsPerKey(t *testing.T) {
+	l := newLoader(func(keys []int) (map[int]string, error) {
+		return nil, fmt.Errorf("database is down")
+	})
+
+	first, second := l.Load(1), l.Load(2)
+
+	_, err := first()
+	assert.EqualError(t, err, "database is down")
+	_, err = second()
+	assert.EqualError(t, err, "database is down")
+}
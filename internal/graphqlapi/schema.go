@@ -0,0 +1,427 @@
+// Package graphqlapi serves a GraphQL schema over users, accounts,
+// transactions and transfers, resolved with the same services as the REST
+// routes.
+package graphqlapi
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/FaizanAC/Go-Banking/internal/mailer"
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/repository"
+	"github.com/FaizanAC/Go-Banking/internal/server/services"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/graphql-go/graphql"
+	"gorm.io/gorm"
+)
+
+const (
+	DEFAULT_PAGE_SIZE = 20
+	MAX_PAGE_SIZE     = 100
+)
+
+// Viewer is the authenticated caller a request is resolved for.
+type Viewer struct {
+	Actor  models.Actor
+	User   *models.User
+	Scopes []string
+}
+
+type viewerKey struct{}
+
+type Schema struct {
+	schema      graphql.Schema
+	bankService *services.BankService
+	userService *services.UserService
+}
+
+func NewSchema(db *gorm.DB, mail mailer.Mailer) (*Schema, error) {
+	store := repository.NewGormStore(db)
+	s := &Schema{
+		bankService: services.NewBankService(store),
+		userService: services.NewUserService(store, mail),
+	}
+
+	accountType := s.accountType()
+	userType := s.userType(accountType)
+	transferType := s.transferType(userType)
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query:    s.queryType(userType, accountType, transferType),
+		Mutation: s.mutationType(accountType),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.schema = schema
+	return s, nil
+}
+
+// authorize returns the viewer if their credentials carry scope. Like the
+// REST routes, a login session has every scope.
+func authorize(ctx context.Context, scope string) (Viewer, error) {
+	viewer, ok := ctx.Value(viewerKey{}).(Viewer)
+	if !ok {
+		return viewer, fmt.Errorf("unauthorized")
+	}
+
+	if viewer.Actor.AuthMethod != models.AUTH_METHOD_JWT && !slices.Contains(viewer.Scopes, scope) {
+		return viewer, fmt.Errorf("credentials are missing the %s scope", scope)
+	}
+	return viewer, nil
+}
+
+// authorizeMoneyMovement also requires a verified email, as RequireVerifiedEmail does.
+func authorizeMoneyMovement(ctx context.Context, scope string) (Viewer, error) {
+	viewer, err := authorize(ctx, scope)
+	if err != nil {
+		return viewer, err
+	}
+
+	if viewer.User == nil || !viewer.User.IsEmailVerified() {
+		return viewer, fmt.Errorf("email address has not been verified")
+	}
+	return viewer, nil
+}
+
+// canSeeUser allows a user's own profile, and anyone's to staff who can read users.
+func canSeeUser(viewer Viewer, userID uint) bool {
+	if viewer.Actor.UserID == userID {
+		return true
+	}
+	return viewer.User != nil && models.HasPermission(viewer.User.Role, models.PERMISSION_READ_USERS)
+}
+
+// pageSize reads a list field's first argument, capped at MAX_PAGE_SIZE.
+func pageSize(args map[string]interface{}) int {
+	first, ok := args["first"].(int)
+	if !ok || first < 1 {
+		return DEFAULT_PAGE_SIZE
+	}
+	return min(first, MAX_PAGE_SIZE)
+}
+
+var firstArgument = graphql.FieldConfigArgument{
+	"first": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: DEFAULT_PAGE_SIZE},
+}
+
+func parseID(value interface{}) (uint, error) {
+	id, err := strconv.ParseUint(fmt.Sprint(value), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid id")
+	}
+	return uint(id), nil
+}
+
+func (s *Schema) userType(accountType *graphql.Object) *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*models.User).ID, nil
+			}},
+			"email":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"firstName": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"lastName":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"role":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"emailVerified": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*models.User).IsEmailVerified(), nil
+			}},
+			"createdAt": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*models.User).CreatedAt, nil
+			}},
+			"accounts": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(accountType))),
+				Description: "The accounts the user holds. Only available for yourself.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					viewer, err := authorize(p.Context, models.SCOPE_ACCOUNTS_READ)
+					if err != nil {
+						return nil, err
+					}
+
+					if p.Source.(*models.User).ID != viewer.Actor.UserID {
+						return nil, fmt.Errorf("you can only list your own accounts")
+					}
+					return s.accounts(viewer)
+				},
+			},
+		},
+	})
+}
+
+func (s *Schema) accounts(viewer Viewer) ([]*models.BankAccount, error) {
+	accounts, err := s.bankService.GetAccountsByUserID(viewer.Actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*models.BankAccount, 0, len(accounts))
+	for i := range accounts {
+		result = append(result, &accounts[i])
+	}
+	return result, nil
+}
+
+func (s *Schema) accountType() *graphql.Object {
+	accountType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Account",
+		Fields: graphql.Fields{
+			"accountNumber": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"balance":       &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"frozen": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*models.BankAccount).IsFrozen(), nil
+			}},
+			"createdAt": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*models.BankAccount).CreatedAt, nil
+			}},
+		},
+	})
+
+	transactionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Transaction",
+		Fields: graphql.Fields{
+			"transactionId": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"accountNumber": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"type":          &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"amount":        &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"sequence":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"createdAt": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(models.Transaction).CreatedAt, nil
+			}},
+			"account": &graphql.Field{Type: accountType, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				if _, err := authorize(p.Context, models.SCOPE_ACCOUNTS_READ); err != nil {
+					return nil, err
+				}
+				return loadersFrom(p.Context).accounts.Load(p.Source.(models.Transaction).AccountNumber), nil
+			}},
+		},
+	})
+
+	accountType.AddFieldConfig("transactions", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(transactionType))),
+		Description: "The account's newest transactions.",
+		Args:        firstArgument,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			if _, err := authorize(p.Context, models.SCOPE_TRANSACTIONS_READ); err != nil {
+				return nil, err
+			}
+			key := transactionsKey{accountNumber: p.Source.(*models.BankAccount).AccountNumber, first: pageSize(p.Args)}
+			return loadersFrom(p.Context).transactions.Load(key), nil
+		},
+	})
+
+	return accountType
+}
+
+func (s *Schema) transferType(userType *graphql.Object) *graphql.Object {
+	party := func(id func(models.Transfer) uint) graphql.FieldResolveFn {
+		return func(p graphql.ResolveParams) (interface{}, error) {
+			viewer, err := authorize(p.Context, models.SCOPE_USERS_READ)
+			if err != nil {
+				return nil, err
+			}
+
+			userID := id(p.Source.(models.Transfer))
+			if !canSeeUser(viewer, userID) {
+				return nil, nil
+			}
+			return loadersFrom(p.Context).users.Load(userID), nil
+		}
+	}
+
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "Transfer",
+		Fields: graphql.Fields{
+			"transactionId": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"amount":        &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+			"status":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"expiresOn":     &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+			"createdAt": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(models.Transfer).CreatedAt, nil
+			}},
+			"senderId":   &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"receiverId": &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"sender": &graphql.Field{
+				Type:        userType,
+				Description: "The sender, if you are allowed to see them.",
+				Resolve:     party(func(t models.Transfer) uint { return t.SenderID }),
+			},
+			"receiver": &graphql.Field{
+				Type:        userType,
+				Description: "The receiver, if you are allowed to see them.",
+				Resolve:     party(func(t models.Transfer) uint { return t.ReceiverID }),
+			},
+		},
+	})
+}
+
+func (s *Schema) queryType(userType *graphql.Object, accountType *graphql.Object, transferType *graphql.Object) *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"me": &graphql.Field{
+				Type: graphql.NewNonNull(userType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					viewer, err := authorize(p.Context, models.SCOPE_USERS_READ)
+					if err != nil {
+						return nil, err
+					}
+					return loadersFrom(p.Context).users.Load(viewer.Actor.UserID), nil
+				},
+			},
+			"user": &graphql.Field{
+				Type: userType,
+				Args: graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					viewer, err := authorize(p.Context, models.SCOPE_USERS_READ)
+					if err != nil {
+						return nil, err
+					}
+
+					userID, err := parseID(p.Args["id"])
+					if err != nil {
+						return nil, err
+					}
+					if !canSeeUser(viewer, userID) {
+						return nil, fmt.Errorf("forbidden")
+					}
+					return loadersFrom(p.Context).users.Load(userID), nil
+				},
+			},
+			"accounts": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(accountType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					viewer, err := authorize(p.Context, models.SCOPE_ACCOUNTS_READ)
+					if err != nil {
+						return nil, err
+					}
+					return s.accounts(viewer)
+				},
+			},
+			"account": &graphql.Field{
+				Type:        accountType,
+				Description: "An account you hold, or null.",
+				Args:        graphql.FieldConfigArgument{"accountNumber": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if _, err := authorize(p.Context, models.SCOPE_ACCOUNTS_READ); err != nil {
+						return nil, err
+					}
+					return loadersFrom(p.Context).accounts.Load(p.Args["accountNumber"].(string)), nil
+				},
+			},
+			"transfers": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(transferType))),
+				Description: "Your newest sent and received transfers.",
+				Args:        firstArgument,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					viewer, err := authorize(p.Context, models.SCOPE_TRANSACTIONS_READ)
+					if err != nil {
+						return nil, err
+					}
+					return s.bankService.GetTransfers(viewer.Actor.UserID, pageSize(p.Args))
+				},
+			},
+		},
+	})
+}
+
+func (s *Schema) mutationType(accountType *graphql.Object) *graphql.Object {
+	balanceChange := func(change func(models.Transaction, models.Actor) (models.BankAccount, error)) graphql.FieldResolveFn {
+		return func(p graphql.ResolveParams) (interface{}, error) {
+			viewer, err := authorizeMoneyMovement(p.Context, models.SCOPE_TRANSACTIONS_WRITE)
+			if err != nil {
+				return nil, err
+			}
+
+			transaction := models.Transaction{AccountNumber: p.Args["accountNumber"].(string), Amount: p.Args["amount"].(float64)}
+			if err := binding.Validator.ValidateStruct(transaction); err != nil {
+				return nil, err
+			}
+
+			account, err := change(transaction, viewer.Actor)
+			if err != nil {
+				return nil, err
+			}
+			return &account, nil
+		}
+	}
+
+	amountArguments := graphql.FieldConfigArgument{
+		"accountNumber": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+		"amount":        &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Float)},
+	}
+
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"deposit": &graphql.Field{
+				Type:    graphql.NewNonNull(accountType),
+				Args:    amountArguments,
+				Resolve: balanceChange(s.bankService.DepositToAccount),
+			},
+			"withdraw": &graphql.Field{
+				Type:    graphql.NewNonNull(accountType),
+				Args:    amountArguments,
+				Resolve: balanceChange(s.bankService.WithdrawFromAccount),
+			},
+			"sendTransfer": &graphql.Field{
+				Type: graphql.NewNonNull(accountType),
+				Args: graphql.FieldConfigArgument{
+					"accountNumber": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"receiverId":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"amount":        &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Float)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					viewer, err := authorizeMoneyMovement(p.Context, models.SCOPE_TRANSFERS_WRITE)
+					if err != nil {
+						return nil, err
+					}
+
+					receiverID, err := parseID(p.Args["receiverId"])
+					if err != nil {
+						return nil, err
+					}
+
+					transfer := models.OutgoingTransfer{AccountNumber: p.Args["accountNumber"].(string), ReceiverID: receiverID, Amount: p.Args["amount"].(float64)}
+					if err := binding.Validator.ValidateStruct(transfer); err != nil {
+						return nil, err
+					}
+
+					account, err := s.bankService.SendTransfer(transfer, viewer.Actor)
+					if err != nil {
+						return nil, err
+					}
+					return &account, nil
+				},
+			},
+			"acceptTransfer": &graphql.Field{
+				Type: graphql.NewNonNull(accountType),
+				Args: graphql.FieldConfigArgument{
+					"transactionId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"accountNumber": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					viewer, err := authorizeMoneyMovement(p.Context, models.SCOPE_TRANSFERS_WRITE)
+					if err != nil {
+						return nil, err
+					}
+
+					transfer := models.IncomingTransfer{TransactionID: p.Args["transactionId"].(string), AccountNumber: p.Args["accountNumber"].(string)}
+					if err := binding.Validator.ValidateStruct(transfer); err != nil {
+						return nil, err
+					}
+
+					account, err := s.bankService.AcceptTransfer(transfer, viewer.Actor)
+					if err != nil {
+						return nil, err
+					}
+					return &account, nil
+				},
+			},
+		},
+	})
+}
@@ -0,0 +1,35 @@
+package grpcapi
+
+import (
+	"context"
+
+	"github.com/FaizanAC/Go-Banking/internal/grpcapi/bankv1"
+	"github.com/FaizanAC/Go-Banking/internal/server/services"
+)
+
+type accountServer struct {
+	bankv1.UnimplementedAccountServiceServer
+	bankService *services.BankService
+}
+
+func (s *accountServer) CreateAccount(ctx context.Context, request *bankv1.CreateAccountRequest) (*bankv1.Account, error) {
+	account, err := s.bankService.CreateAccount(actor(ctx))
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	return accountMessage(account), nil
+}
+
+func (s *accountServer) ListAccounts(ctx context.Context, request *bankv1.ListAccountsRequest) (*bankv1.ListAccountsResponse, error) {
+	accounts, err := s.bankService.GetAccountsByUserID(callerFromContext(ctx).user.ID)
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	response := &bankv1.ListAccountsResponse{}
+	for _, account := range accounts {
+		response.Accounts = append(response.Accounts, accountMessage(account))
+	}
+	return response, nil
+}
@@ -0,0 +1,205 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/FaizanAC/Go-Banking/internal/grpcapi/bankv1"
+	"github.com/FaizanAC/Go-Banking/internal/middleware"
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// publicMethods can be called without credentials.
+var publicMethods = map[string]bool{
+	bankv1.UserService_CreateUser_FullMethodName: true,
+}
+
+// methodScopes is the scope an API key or OAuth token needs for each method,
+// matching the equivalent REST route. Methods missing here are refused.
+var methodScopes = map[string]string{
+	bankv1.UserService_GetMe_FullMethodName:                 models.SCOPE_USERS_READ,
+	bankv1.AccountService_CreateAccount_FullMethodName:      models.SCOPE_ACCOUNTS_WRITE,
+	bankv1.AccountService_ListAccounts_FullMethodName:       models.SCOPE_ACCOUNTS_READ,
+	bankv1.TransactionService_Deposit_FullMethodName:        models.SCOPE_TRANSACTIONS_WRITE,
+	bankv1.TransactionService_Withdraw_FullMethodName:       models.SCOPE_TRANSACTIONS_WRITE,
+	bankv1.TransactionService_ListActivity_FullMethodName:   models.SCOPE_TRANSACTIONS_READ,
+	bankv1.TransactionService_StreamActivity_FullMethodName: models.SCOPE_TRANSACTIONS_READ,
+	bankv1.TransferService_SendTransfer_FullMethodName:      models.SCOPE_TRANSFERS_WRITE,
+	bankv1.TransferService_AcceptTransfer_FullMethodName:    models.SCOPE_TRANSFERS_WRITE,
+}
+
+// verifiedEmailMethods move money and need a verified email address.
+var verifiedEmailMethods = map[string]bool{
+	bankv1.TransactionService_Deposit_FullMethodName:     true,
+	bankv1.TransactionService_Withdraw_FullMethodName:    true,
+	bankv1.TransferService_SendTransfer_FullMethodName:   true,
+	bankv1.TransferService_AcceptTransfer_FullMethodName: true,
+}
+
+// caller is the authenticated user of a request.
+type caller struct {
+	user       *models.User
+	authMethod string
+	scopes     []string
+}
+
+type callerKey struct{}
+
+func callerFromContext(ctx context.Context) *caller {
+	c, _ := ctx.Value(callerKey{}).(*caller)
+	return c
+}
+
+// authenticator checks credentials the same way as middleware.Authenticate.
+// lookup is replaceable so tests can run without a database.
+type authenticator struct {
+	lookup func(ctx context.Context, token string) (*caller, error)
+}
+
+func newAuthenticator(db *gorm.DB) *authenticator {
+	return &authenticator{lookup: func(ctx context.Context, token string) (*caller, error) {
+		if util.IsAPIKey(token) {
+			user, apiKey, err := middleware.VerifyAPIKey(db, token, clientIP(ctx))
+			if err != nil {
+				return nil, err
+			}
+			return &caller{user: user, authMethod: models.AUTH_METHOD_API_KEY, scopes: apiKey.ScopeList()}, nil
+		}
+
+		claims, err := util.ParseJWT(token)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "the access token is invalid or expired")
+		}
+
+		user, err := middleware.VerifySession(db, claims)
+		if err != nil {
+			return nil, err
+		}
+
+		if claims.ClientID != "" {
+			return &caller{user: user, authMethod: models.AUTH_METHOD_OAUTH, scopes: models.ExpandOAuthScopes(claims.Scope)}, nil
+		}
+		return &caller{user: user, authMethod: models.AUTH_METHOD_JWT}, nil
+	}}
+}
+
+// authorize authenticates the request and applies the method's scope and
+// email verification requirements.
+func (a *authenticator) authorize(ctx context.Context, method string) (context.Context, error) {
+	if publicMethods[method] {
+		return ctx, nil
+	}
+
+	scope, known := methodScopes[method]
+	if !known {
+		return nil, status.Error(codes.PermissionDenied, "method is not available")
+	}
+
+	token, err := bearerToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := a.lookup(ctx, token)
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	if c.authMethod != models.AUTH_METHOD_JWT && !slices.Contains(c.scopes, scope) {
+		return nil, status.Errorf(codes.PermissionDenied, "credentials are missing the %s scope", scope)
+	}
+
+	if verifiedEmailMethods[method] && !c.user.IsEmailVerified() {
+		return nil, status.Error(codes.PermissionDenied, "email address has not been verified")
+	}
+
+	return context.WithValue(ctx, callerKey{}, c), nil
+}
+
+func bearerToken(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", status.Error(codes.Unauthenticated, "missing credentials")
+	}
+
+	scheme, token, found := strings.Cut(values[0], " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", status.Error(codes.Unauthenticated, "authorization must use the Bearer scheme")
+	}
+
+	return strings.TrimSpace(token), nil
+}
+
+func (a *authenticator) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *authenticator) streamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authorize(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+func clientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// clientInfo mirrors the REST handlers' client info, using the
+// x-request-id metadata entry when the client sends one.
+func clientInfo(ctx context.Context) models.ClientInfo {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	requestID := ""
+	if values := md.Get("x-request-id"); len(values) > 0 {
+		requestID = values[0]
+	}
+
+	userAgent := ""
+	if values := md.Get("user-agent"); len(values) > 0 {
+		userAgent = values[0]
+	}
+
+	return models.ClientInfo{IP: clientIP(ctx), UserAgent: userAgent, RequestID: middleware.NormalizeRequestID(requestID)}
+}
+
+func actor(ctx context.Context) models.Actor {
+	c := callerFromContext(ctx)
+	return models.Actor{UserID: c.user.ID, AuthMethod: c.authMethod, ClientInfo: clientInfo(ctx)}
+}
@@ -0,0 +1,84 @@
+// Package grpcapi serves the bank.v1 gRPC API. It calls the same services
+// as the REST routes and authenticates with the same credentials.
+package grpcapi
+
+import (
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/grpcapi/bankv1"
+	"github.com/FaizanAC/Go-Banking/internal/mailer"
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/repository"
+	"github.com/FaizanAC/Go-Banking/internal/server/services"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+// NewServer returns a gRPC server with every bank.v1 service registered.
+func NewServer(db *gorm.DB, mail mailer.Mailer, streamService *services.StreamService) *grpc.Server {
+	return newServer(newAuthenticator(db), db, mail, streamService)
+}
+
+func newServer(auth *authenticator, db *gorm.DB, mail mailer.Mailer, streamService *services.StreamService) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(auth.unaryInterceptor),
+		grpc.ChainStreamInterceptor(auth.streamInterceptor),
+	)
+
+	store := repository.NewGormStore(db)
+	bankService := services.NewBankService(store)
+	bankv1.RegisterUserServiceServer(server, &userServer{userService: services.NewUserService(store, mail)})
+	bankv1.RegisterAccountServiceServer(server, &accountServer{bankService: bankService})
+	bankv1.RegisterTransactionServiceServer(server, &transactionServer{bankService: bankService, streamService: streamService})
+	bankv1.RegisterTransferServiceServer(server, &transferServer{bankService: bankService})
+
+	return server
+}
+
+// serviceError reports a failure from the services layer, whose messages
+// are meant for the caller, as a failed precondition.
+func serviceError(err error) error {
+	return status.Error(codes.FailedPrecondition, err.Error())
+}
+
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func userMessage(user *models.User) *bankv1.User {
+	return &bankv1.User{
+		Id:            uint64(user.ID),
+		Email:         user.Email,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Role:          user.Role,
+		EmailVerified: user.IsEmailVerified(),
+		CreatedAt:     timestamp(user.CreatedAt),
+	}
+}
+
+func accountMessage(account models.BankAccount) *bankv1.Account {
+	return &bankv1.Account{
+		AccountNumber: account.AccountNumber,
+		Balance:       account.Balance,
+		Frozen:        account.IsFrozen(),
+		CreatedAt:     timestamp(account.CreatedAt),
+	}
+}
+
+func transactionMessage(transaction models.Transaction) *bankv1.Transaction {
+	return &bankv1.Transaction{
+		TransactionId: transaction.TransactionID,
+		AccountNumber: transaction.AccountNumber,
+		Type:          transaction.Type,
+		Amount:        transaction.Amount,
+		Sequence:      transaction.Sequence,
+		CreatedAt:     timestamp(transaction.CreatedAt),
+	}
+}
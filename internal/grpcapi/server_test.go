@@ -0,0 +1,129 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/grpcapi/bankv1"
+	"github.com/FaizanAC/Go-Banking/internal/mailer"
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/server/services"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// startTestServer serves the API over an in-memory listener. Without a
+// database, calls only succeed up to the point where a service needs one.
+func startTestServer(t *testing.T, auth *authenticator) *grpc.ClientConn {
+	listener := bufconn.Listen(1 << 20)
+	server := newServer(auth, nil, mailer.NewLogMailer(), services.NewStreamService(nil))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.Nil(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+// stubAuthenticator accepts the token "valid" as the given caller.
+func stubAuthenticator(c *caller) *authenticator {
+	return &authenticator{lookup: func(ctx context.Context, token string) (*caller, error) {
+		if token != "valid" {
+			return nil, status.Error(codes.Unauthenticated, "the access token is invalid or expired")
+		}
+		return c, nil
+	}}
+}
+
+func withToken(t *testing.T, token string) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+func verifiedUser() *models.User {
+	now := time.Now()
+	user := &models.User{Email: "test@example.com", FirstName: "Test", LastName: "User", Role: models.ROLE_CUSTOMER, EmailVerifiedAt: &now}
+	user.ID = 7
+	return user
+}
+
+func TestCreateUserValidatesRequest(t *testing.T) {
+	client := bankv1.NewUserServiceClient(startTestServer(t, stubAuthenticator(nil)))
+
+	_, err := client.CreateUser(context.Background(), &bankv1.CreateUserRequest{Email: "not-an-email", FirstName: "A", LastName: "B", Password: "password"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestRequiresCredentials(t *testing.T) {
+	client := bankv1.NewUserServiceClient(startTestServer(t, stubAuthenticator(nil)))
+
+	_, err := client.GetMe(context.Background(), &bankv1.GetMeRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.GetMe(withToken(t, "wrong"), &bankv1.GetMeRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestRejectsInvalidJWT(t *testing.T) {
+	client := bankv1.NewUserServiceClient(startTestServer(t, newAuthenticator(nil)))
+
+	_, err := client.GetMe(withToken(t, "not.a.jwt"), &bankv1.GetMeRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestGetMeReturnsCaller(t *testing.T) {
+	client := bankv1.NewUserServiceClient(startTestServer(t, stubAuthenticator(&caller{user: verifiedUser(), authMethod: models.AUTH_METHOD_JWT})))
+
+	user, err := client.GetMe(withToken(t, "valid"), &bankv1.GetMeRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(7), user.GetId())
+	assert.Equal(t, "test@example.com", user.GetEmail())
+	assert.True(t, user.GetEmailVerified())
+}
+
+func TestEnforcesAPIKeyScopes(t *testing.T) {
+	apiKeyCaller := &caller{user: verifiedUser(), authMethod: models.AUTH_METHOD_API_KEY, scopes: []string{models.SCOPE_USERS_READ}}
+	conn := startTestServer(t, stubAuthenticator(apiKeyCaller))
+
+	_, err := bankv1.NewUserServiceClient(conn).GetMe(withToken(t, "valid"), &bankv1.GetMeRequest{})
+	assert.Nil(t, err)
+
+	_, err = bankv1.NewTransactionServiceClient(conn).Deposit(withToken(t, "valid"), &bankv1.DepositRequest{AccountNumber: "123", Amount: 10})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	stream, err := bankv1.NewTransactionServiceClient(conn).StreamActivity(withToken(t, "valid"), &bankv1.StreamActivityRequest{})
+	assert.Nil(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestMovingMoneyRequiresVerifiedEmail(t *testing.T) {
+	user := verifiedUser()
+	user.EmailVerifiedAt = nil
+	client := bankv1.NewTransferServiceClient(startTestServer(t, stubAuthenticator(&caller{user: user, authMethod: models.AUTH_METHOD_JWT})))
+
+	_, err := client.SendTransfer(withToken(t, "valid"), &bankv1.SendTransferRequest{AccountNumber: "123", ReceiverId: 2, Amount: 10})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestDepositValidatesRequest(t *testing.T) {
+	client := bankv1.NewTransactionServiceClient(startTestServer(t, stubAuthenticator(&caller{user: verifiedUser(), authMethod: models.AUTH_METHOD_JWT})))
+
+	_, err := client.Deposit(withToken(t, "valid"), &bankv1.DepositRequest{Amount: 10})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
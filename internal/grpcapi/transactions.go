@@ -0,0 +1,115 @@
+package grpcapi
+
+import (
+	"context"
+
+	"github.com/FaizanAC/Go-Banking/internal/grpcapi/bankv1"
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/server/services"
+	"github.com/gin-gonic/gin/binding"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type transactionServer struct {
+	bankv1.UnimplementedTransactionServiceServer
+	bankService   *services.BankService
+	streamService *services.StreamService
+}
+
+func transactionRequest(accountNumber string, amount float64) (models.Transaction, error) {
+	transaction := models.Transaction{AccountNumber: accountNumber, Amount: amount}
+	if err := binding.Validator.ValidateStruct(&transaction); err != nil {
+		return transaction, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return transaction, nil
+}
+
+func (s *transactionServer) Deposit(ctx context.Context, request *bankv1.DepositRequest) (*bankv1.Account, error) {
+	deposit, err := transactionRequest(request.GetAccountNumber(), request.GetAmount())
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.bankService.DepositToAccount(deposit, actor(ctx))
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	return accountMessage(account), nil
+}
+
+func (s *transactionServer) Withdraw(ctx context.Context, request *bankv1.WithdrawRequest) (*bankv1.Account, error) {
+	withdraw, err := transactionRequest(request.GetAccountNumber(), request.GetAmount())
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.bankService.WithdrawFromAccount(withdraw, actor(ctx))
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	return accountMessage(account), nil
+}
+
+func (s *transactionServer) ListActivity(ctx context.Context, request *bankv1.ListActivityRequest) (*bankv1.ListActivityResponse, error) {
+	transactions, err := s.bankService.GetActivityFeed(callerFromContext(ctx).user.ID)
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	response := &bankv1.ListActivityResponse{}
+	for _, transaction := range transactions {
+		response.Transactions = append(response.Transactions, transactionMessage(transaction))
+	}
+	return response, nil
+}
+
+// StreamActivity sends the same events as the REST stream, replaying those
+// after last_event_id first.
+func (s *transactionServer) StreamActivity(request *bankv1.StreamActivityRequest, stream bankv1.TransactionService_StreamActivityServer) error {
+	userID := callerFromContext(stream.Context()).user.ID
+
+	subscription := s.streamService.Subscribe(userID)
+	defer s.streamService.Unsubscribe(subscription)
+
+	replayedUpTo := uint(request.GetLastEventId())
+	if replayedUpTo > 0 {
+		backlog, truncated, err := s.streamService.Replay(userID, replayedUpTo)
+		if err != nil {
+			return serviceError(err)
+		}
+
+		for _, event := range backlog {
+			if err := stream.Send(activityEvent(event)); err != nil {
+				return err
+			}
+			replayedUpTo = event.ID
+		}
+		if truncated {
+			return status.Error(codes.ResourceExhausted, "replay truncated; resume from the last event id")
+		}
+	}
+
+	for {
+		select {
+		case event, ok := <-subscription.Events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "stream fell too far behind; resume from the last event id")
+			}
+			if event.ID <= replayedUpTo {
+				continue
+			}
+			if err := stream.Send(activityEvent(event)); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+func activityEvent(event models.StreamEvent) *bankv1.ActivityEvent {
+	return &bankv1.ActivityEvent{Id: uint64(event.ID), Type: event.Type, Data: string(event.Data)}
+}
@@ -0,0 +1,52 @@
+package grpcapi
+
+import (
+	"context"
+
+	"github.com/FaizanAC/Go-Banking/internal/grpcapi/bankv1"
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/server/services"
+	"github.com/gin-gonic/gin/binding"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type transferServer struct {
+	bankv1.UnimplementedTransferServiceServer
+	bankService *services.BankService
+}
+
+func (s *transferServer) SendTransfer(ctx context.Context, request *bankv1.SendTransferRequest) (*bankv1.Account, error) {
+	transfer := models.OutgoingTransfer{
+		AccountNumber: request.GetAccountNumber(),
+		ReceiverID:    uint(request.GetReceiverId()),
+		Amount:        request.GetAmount(),
+	}
+	if err := binding.Validator.ValidateStruct(&transfer); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	account, err := s.bankService.SendTransfer(transfer, actor(ctx))
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	return accountMessage(account), nil
+}
+
+func (s *transferServer) AcceptTransfer(ctx context.Context, request *bankv1.AcceptTransferRequest) (*bankv1.Account, error) {
+	accept := models.IncomingTransfer{
+		TransactionID: request.GetTransactionId(),
+		AccountNumber: request.GetAccountNumber(),
+	}
+	if err := binding.Validator.ValidateStruct(&accept); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	account, err := s.bankService.AcceptTransfer(accept, actor(ctx))
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	return accountMessage(account), nil
+}
@@ -0,0 +1,48 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/FaizanAC/Go-Banking/internal/grpcapi/bankv1"
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/server/services"
+	"github.com/gin-gonic/gin/binding"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type userServer struct {
+	bankv1.UnimplementedUserServiceServer
+	userService *services.UserService
+}
+
+func (s *userServer) CreateUser(ctx context.Context, request *bankv1.CreateUserRequest) (*bankv1.User, error) {
+	user := models.User{
+		Email:     request.GetEmail(),
+		FirstName: request.GetFirstName(),
+		LastName:  request.GetLastName(),
+		Password:  request.GetPassword(),
+	}
+
+	// The REST route's binding rules apply here too.
+	if err := binding.Validator.ValidateStruct(&user); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := s.userService.CreateUser(&user, clientInfo(ctx)); err != nil {
+		if errors.Is(err, services.ErrBlankName) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Is(err, services.ErrEmailTaken) {
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "failed to create user")
+	}
+
+	return userMessage(&user), nil
+}
+
+func (s *userServer) GetMe(ctx context.Context, request *bankv1.GetMeRequest) (*bankv1.User, error) {
+	return userMessage(callerFromContext(ctx).user), nil
+}
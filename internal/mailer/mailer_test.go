@@ -0,0 +1,45 @@
+package mailer
+
+import (
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileMailerWritesMessage(t *testing.T) {
+	dir := t.TempDir()
+
+	m, err := NewFileMailer(dir)
+	assert.Nil(t, err)
+
+	err = m.Send(Message{To: "test@example.com", Subject: "Hello", Body: "line one\nline two"})
+	assert.Nil(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	assert.Nil(t, err)
+	assert.Len(t, files, 1)
+
+	contents, err := os.ReadFile(files[0])
+	assert.Nil(t, err)
+	assert.Contains(t, string(contents), "To: test@example.com\r\n")
+	assert.Contains(t, string(contents), "line one\r\nline two")
+}
+
+func TestSMTPMailerStripsHeaderInjection(t *testing.T) {
+	m, err := NewSMTPMailer(SMTPConfig{Host: "smtp.example.com", From: "bank@example.com"})
+	assert.Nil(t, err)
+
+	var sent []byte
+	m.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		assert.Equal(t, "smtp.example.com:587", addr)
+		sent = msg
+		return nil
+	}
+
+	err = m.Send(Message{To: "test@example.com", Subject: "Hi\r\nBcc: evil@example.com", Body: "body"})
+	assert.Nil(t, err)
+	assert.NotContains(t, string(sent), "\r\nBcc:")
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPAllowed(t *testing.T) {
+	assert.True(t, ipAllowed("203.0.113.7", nil))
+	assert.True(t, ipAllowed("203.0.113.7", []string{"203.0.113.0/24"}))
+	assert.True(t, ipAllowed("198.51.100.1", []string{"203.0.113.0/24", "198.51.100.1"}))
+	assert.False(t, ipAllowed("198.51.100.2", []string{"203.0.113.0/24", "198.51.100.1"}))
+	assert.False(t, ipAllowed("not-an-ip", []string{"203.0.113.0/24"}))
+}
+
+func TestRequireScopeIgnoresUserSessions(t *testing.T) {
+	c, _ := newTestContext()
+
+	c.Set("authMethod", models.AUTH_METHOD_JWT)
+	RequireScope(models.SCOPE_TRANSFERS_WRITE)(c)
+
+	assert.Equal(t, c.IsAborted(), false)
+}
+
+func TestRequireScopeEnforcesAPIKeyScopes(t *testing.T) {
+	c, _ := newTestContext()
+
+	c.Set("authMethod", models.AUTH_METHOD_API_KEY)
+	c.Set("scopes", []string{"accounts:read", "transactions:read"})
+	RequireScope(models.SCOPE_ACCOUNTS_READ)(c)
+
+	assert.Equal(t, c.IsAborted(), false)
+
+	c, w := newTestContext()
+
+	c.Set("authMethod", models.AUTH_METHOD_API_KEY)
+	c.Set("scopes", []string{"accounts:read", "transactions:read"})
+	RequireScope(models.SCOPE_TRANSFERS_WRITE)(c)
+
+	assert.Equal(t, c.IsAborted(), true)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), `error="insufficient_scope"`)
+}
+
+func TestRequireUserSessionRejectsAPIKeys(t *testing.T) {
+	c, _ := newTestContext()
+
+	c.Set("authMethod", models.AUTH_METHOD_API_KEY)
+	RequireUserSession(c)
+
+	assert.Equal(t, c.IsAborted(), true)
+}
+
+func TestRequireScopeEnforcesOAuthScopes(t *testing.T) {
+	c, _ := newTestContext()
+
+	c.Set("authMethod", models.AUTH_METHOD_OAUTH)
+	c.Set("scopes", models.ExpandOAuthScopes("accounts:read payments:write"))
+	RequireScope(models.SCOPE_TRANSFERS_WRITE)(c)
+
+	assert.Equal(t, c.IsAborted(), false)
+
+	c, _ = newTestContext()
+
+	c.Set("authMethod", models.AUTH_METHOD_OAUTH)
+	c.Set("scopes", models.ExpandOAuthScopes("accounts:read"))
+	RequireScope(models.SCOPE_TRANSACTIONS_WRITE)(c)
+
+	assert.Equal(t, c.IsAborted(), true)
+}
@@ -1,36 +1,126 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
-	"time"
+	"strings"
 
+	"github.com/FaizanAC/Go-Banking/internal/models"
 	"github.com/FaizanAC/Go-Banking/internal/util"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
 )
 
+const authRealm = "go-banking"
+
+// AuthorizeRequest authenticates the request with a JWT taken from the
+// "Authorization: Bearer" header or, failing that, the "token" cookie. It only
+// checks the token itself; Authenticate also consults the database.
 func AuthorizeRequest(c *gin.Context) {
-	tokenString, err := c.Cookie("token")
-	if err != nil {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	tokenString, ok := requireToken(c)
+	if !ok {
 		return
 	}
 
-	jwtToken, err := util.ParseJWT(tokenString)
-	if err != nil {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
+	if authenticateJWT(c, tokenString) {
+		c.Next()
 	}
+}
 
-	if claims, ok := jwtToken.Claims.(jwt.MapClaims); ok {
-		if float64(time.Now().Unix()) > claims["exp"].(float64) {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+// Authenticate accepts either a JWT (checked against revoked sessions) or an
+// API key, and stores the caller's user under "user".
+func Authenticate(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, ok := requireToken(c)
+		if !ok {
+			return
 		}
-		c.Set("userID", uint(jwtToken.Claims.(jwt.MapClaims)["sub"].(float64)))
-		c.Next()
+
+		if util.IsAPIKey(tokenString) {
+			ok = authenticateAPIKey(db, c, tokenString)
+		} else {
+			ok = authenticateJWT(c, tokenString) && checkActiveSession(db, c)
+		}
+
+		if ok {
+			c.Next()
+		}
+	}
+}
+
+func requireToken(c *gin.Context) (string, bool) {
+	tokenString, err := extractToken(c)
+	if err != nil {
+		abortUnauthorized(c, "invalid_request", err.Error())
+		return "", false
+	}
+
+	if tokenString == "" {
+		abortUnauthorized(c, "", "")
+		return "", false
+	}
+
+	return tokenString, true
+}
+
+func authenticateJWT(c *gin.Context, tokenString string) bool {
+	claims, err := util.ParseJWT(tokenString)
+	if err != nil {
+		abortUnauthorized(c, "invalid_token", "the access token is invalid or expired")
+		return false
+	}
+
+	userID, err := claims.UserID()
+	if err != nil {
+		abortUnauthorized(c, "invalid_token", err.Error())
+		return false
+	}
+
+	c.Set("userID", userID)
+	c.Set("role", claims.Role)
+	c.Set("claims", claims)
+
+	if claims.ClientID != "" {
+		c.Set("authMethod", models.AUTH_METHOD_OAUTH)
+		c.Set("scopes", models.ExpandOAuthScopes(claims.Scope))
 	} else {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
+		c.Set("authMethod", models.AUTH_METHOD_JWT)
+	}
+
+	return true
+}
+
+func extractToken(c *gin.Context) (string, error) {
+	if header := c.GetHeader("Authorization"); header != "" {
+		scheme, token, found := strings.Cut(header, " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") {
+			return "", fmt.Errorf("authorization header must use the Bearer scheme")
+		}
+
+		token = strings.TrimSpace(token)
+		if token == "" {
+			return "", fmt.Errorf("bearer token is empty")
+		}
+
+		return token, nil
+	}
+
+	tokenString, err := c.Cookie("token")
+	if err != nil {
+		return "", nil
+	}
+
+	return tokenString, nil
+}
+
+// abortUnauthorized writes a 401 with a RFC 6750 WWW-Authenticate challenge.
+// errorCode is omitted when the request carried no credentials at all.
+func abortUnauthorized(c *gin.Context, errorCode string, description string) {
+	challenge := fmt.Sprintf(`Bearer realm=%q`, authRealm)
+	if errorCode != "" {
+		challenge += fmt.Sprintf(`, error=%q, error_description=%q`, errorCode, description)
 	}
 
+	c.Header("WWW-Authenticate", challenge)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 }
@@ -4,16 +4,16 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
-	"os"
 	"testing"
 	"time"
 
+	"github.com/FaizanAC/Go-Banking/internal/util"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestAuthorizeRequestSucceeds(t *testing.T) {
+func newTestContext() (*gin.Context, *httptest.ResponseRecorder) {
 	gin.SetMode(gin.TestMode)
 
 	w := httptest.NewRecorder()
@@ -23,41 +23,140 @@ func TestAuthorizeRequestSucceeds(t *testing.T) {
 		URL:    &url.URL{},
 	}
 
-	userID := 1
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": userID,
-		"exp": time.Now().Add(time.Hour * 24).Unix(),
-	})
+	return c, w
+}
+
+func signTestToken(t *testing.T, claims jwt.MapClaims) string {
+	keySet, err := util.DefaultKeySet()
+	assert.Nil(t, err)
+
+	key, err := keySet.ActiveKey()
+	assert.Nil(t, err)
 
-	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_KEY")))
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+
+	tokenString, err := token.SignedString(key.PrivateKey)
 	assert.Nil(t, err)
 
+	return tokenString
+}
+
+func TestAuthorizeRequestSucceeds(t *testing.T) {
+	c, _ := newTestContext()
+
+	tokenString := signTestToken(t, jwt.MapClaims{
+		"sub": "1",
+		"iss": util.JWTIssuer(),
+		"aud": util.JWTAudience(),
+		"exp": time.Now().Add(time.Hour * 24).Unix(),
+	})
+
 	c.Request.Header.Set("Cookie", "token="+tokenString)
 	AuthorizeRequest(c)
 
 	assert.Equal(t, c.IsAborted(), false)
+	assert.Equal(t, uint(1), c.GetUint("userID"))
 }
 
 func TestAuthorizeRequestAborts(t *testing.T) {
-	gin.SetMode(gin.TestMode)
+	c, w := newTestContext()
 
-	w := httptest.NewRecorder()
-	c, _ := gin.CreateTestContext(w)
-	c.Request = &http.Request{
-		Header: make(http.Header),
-		URL:    &url.URL{},
-	}
+	tokenString := signTestToken(t, jwt.MapClaims{
+		"sub": "1",
+		"iss": util.JWTIssuer(),
+		"aud": util.JWTAudience(),
+		"exp": time.Now().Add(-(time.Hour * 24)).Unix(),
+	})
+
+	c.Request.Header.Set("Cookie", "token="+tokenString)
+	AuthorizeRequest(c)
+
+	assert.Equal(t, c.IsAborted(), true)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), `error="invalid_token"`)
+}
+
+func TestAuthorizeRequestAcceptsBearerToken(t *testing.T) {
+	c, _ := newTestContext()
+
+	tokenString, err := util.GenerateJWT(7, "customer")
+	assert.Nil(t, err)
+
+	c.Request.Header.Set("Authorization", "Bearer "+tokenString)
+	AuthorizeRequest(c)
+
+	assert.Equal(t, c.IsAborted(), false)
+	assert.Equal(t, uint(7), c.GetUint("userID"))
+}
+
+func TestAuthorizeRequestWithoutCredentials(t *testing.T) {
+	c, w := newTestContext()
+
+	AuthorizeRequest(c)
+
+	assert.Equal(t, c.IsAborted(), true)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer realm="go-banking"`, w.Header().Get("WWW-Authenticate"))
+}
+
+func TestAuthorizeRequestRejectsOtherSchemes(t *testing.T) {
+	c, w := newTestContext()
+
+	c.Request.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	AuthorizeRequest(c)
+
+	assert.Equal(t, c.IsAborted(), true)
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), `error="invalid_request"`)
+}
+
+func TestAuthorizeRequestRejectsWrongAudience(t *testing.T) {
+	c, _ := newTestContext()
+
+	tokenString := signTestToken(t, jwt.MapClaims{
+		"sub": "1",
+		"iss": util.JWTIssuer(),
+		"aud": "another-service",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+
+	c.Request.Header.Set("Authorization", "Bearer "+tokenString)
+	AuthorizeRequest(c)
+
+	assert.Equal(t, c.IsAborted(), true)
+}
+
+func TestAuthorizeRequestRejectsMalformedClaims(t *testing.T) {
+	c, _ := newTestContext()
+
+	tokenString := signTestToken(t, jwt.MapClaims{
+		"sub": "1",
+		"iss": util.JWTIssuer(),
+		"aud": util.JWTAudience(),
+		"exp": "tomorrow",
+	})
+
+	c.Request.Header.Set("Authorization", "Bearer "+tokenString)
+	assert.NotPanics(t, func() { AuthorizeRequest(c) })
+
+	assert.Equal(t, c.IsAborted(), true)
+}
+
+func TestAuthorizeRequestRejectsSymmetricTokens(t *testing.T) {
+	c, _ := newTestContext()
 
-	userID := 1
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": userID,
-		"exp": time.Now().Add(-(time.Hour * 24)).Unix(),
+		"sub": "1",
+		"iss": util.JWTIssuer(),
+		"aud": util.JWTAudience(),
+		"exp": time.Now().Add(time.Hour).Unix(),
 	})
+	token.Header["kid"] = "ephemeral"
 
-	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_KEY")))
+	tokenString, err := token.SignedString([]byte("shared-secret"))
 	assert.Nil(t, err)
 
-	c.Request.Header.Set("Cookie", "token="+tokenString)
+	c.Request.Header.Set("Authorization", "Bearer "+tokenString)
 	AuthorizeRequest(c)
 
 	assert.Equal(t, c.IsAborted(), true)
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission only lets through callers whose role grants permission.
+// It must run after AuthorizeRequest.
+func RequirePermission(permission models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !models.HasPermission(c.GetString("role"), permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+			return
+		}
+
+		c.Next()
+	}
+}
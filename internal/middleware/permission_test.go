@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequirePermissionAllowsGrantedRole(t *testing.T) {
+	c, _ := newTestContext()
+
+	c.Set("role", models.ROLE_SUPPORT)
+	RequirePermission(models.PERMISSION_READ_USERS)(c)
+
+	assert.Equal(t, c.IsAborted(), false)
+}
+
+func TestRequirePermissionRejectsOtherRoles(t *testing.T) {
+	c, w := newTestContext()
+
+	c.Set("role", models.ROLE_SUPPORT)
+	RequirePermission(models.PERMISSION_FREEZE_ACCOUNTS)(c)
+
+	assert.Equal(t, c.IsAborted(), true)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestRequirePermissionTreatsMissingRoleAsCustomer(t *testing.T) {
+	c, _ := newTestContext()
+
+	RequirePermission(models.PERMISSION_READ_TRANSACTIONS)(c)
+
+	assert.Equal(t, c.IsAborted(), true)
+}
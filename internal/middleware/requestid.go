@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const REQUEST_ID_HEADER = "X-Request-ID"
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID tags every request with an ID, reusing a well-formed one sent by
+// the client or a proxy. It is stored under "requestID" and echoed back.
+func RequestID(c *gin.Context) {
+	requestID := NormalizeRequestID(c.GetHeader(REQUEST_ID_HEADER))
+
+	c.Set("requestID", requestID)
+	c.Header(REQUEST_ID_HEADER, requestID)
+	c.Next()
+}
+
+// NormalizeRequestID returns requestID if it is well-formed, or a new one.
+func NormalizeRequestID(requestID string) string {
+	if !validRequestID.MatchString(requestID) {
+		return uuid.New().String()
+	}
+	return requestID
+}
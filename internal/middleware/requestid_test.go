@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestID)
+	r.GET("/", func(c *gin.Context) { c.String(http.StatusOK, c.GetString("requestID")) })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(REQUEST_ID_HEADER, "abc-123")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, "abc-123", w.Body.String())
+	assert.Equal(t, "abc-123", w.Header().Get(REQUEST_ID_HEADER))
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(REQUEST_ID_HEADER, "bad id\n")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Len(t, w.Body.String(), 36)
+}
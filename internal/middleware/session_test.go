@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/util"
+	"github.com/FaizanAC/Go-Banking/tests/integration/testdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireVerifiedEmailAllowsVerifiedUsers(t *testing.T) {
+	c, _ := newTestContext()
+
+	verifiedAt := time.Now()
+	c.Set("user", &models.User{EmailVerifiedAt: &verifiedAt})
+	RequireVerifiedEmail(c)
+
+	assert.Equal(t, c.IsAborted(), false)
+}
+
+func TestRequireVerifiedEmailBlocksUnverifiedUsers(t *testing.T) {
+	c, w := newTestContext()
+
+	c.Set("user", &models.User{})
+	RequireVerifiedEmail(c)
+
+	assert.Equal(t, c.IsAborted(), true)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestVerifySessionRevokesTokensFromTheSameSecond(t *testing.T) {
+	db := testdb.Open(t)
+	user := models.User{Email: "session@example.com", Password: "x", Role: models.ROLE_CUSTOMER}
+	assert.Nil(t, db.Create(&user).Error)
+
+	tokenString, err := util.GenerateJWT(user.ID, user.Role)
+	assert.Nil(t, err)
+	claims, err := util.ParseJWT(tokenString)
+	assert.Nil(t, err)
+
+	_, err = VerifySession(db, claims)
+	assert.Nil(t, err)
+
+	// Revoking a moment after the token was issued must reject it even
+	// though both fall within the same second.
+	assert.Nil(t, db.Model(&user).Update("sessions_valid_from", claims.IssuedAt.Add(time.Millisecond)).Error)
+	_, err = VerifySession(db, claims)
+	assert.EqualError(t, err, "the session has been revoked")
+
+	assert.Nil(t, db.Model(&user).Update("sessions_valid_from", claims.IssuedAt.Time).Error)
+	_, err = VerifySession(db, claims)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	AUTH_METHOD_JWT     = "jwt"
+	AUTH_METHOD_API_KEY = "api_key"
+	AUTH_METHOD_CLI     = "cli"
+)
+
+const (
+	SCOPE_USERS_READ         = "users:read"
+	SCOPE_ACCOUNTS_READ      = "accounts:read"
+	SCOPE_ACCOUNTS_WRITE     = "accounts:write"
+	SCOPE_TRANSACTIONS_READ  = "transactions:read"
+	SCOPE_TRANSACTIONS_WRITE = "transactions:write"
+	SCOPE_TRANSFERS_WRITE    = "transfers:write"
+	SCOPE_ADMIN              = "admin"
+)
+
+var AllScopes = []string{
+	SCOPE_USERS_READ,
+	SCOPE_ACCOUNTS_READ,
+	SCOPE_ACCOUNTS_WRITE,
+	SCOPE_TRANSACTIONS_READ,
+	SCOPE_TRANSACTIONS_WRITE,
+	SCOPE_TRANSFERS_WRITE,
+	SCOPE_ADMIN,
+}
+
+func IsValidScope(scope string) bool {
+	for _, valid := range AllScopes {
+		if scope == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey lets a machine client act as its owner within the listed scopes.
+// Only a hash of the key is stored; Prefix identifies it in listings.
+type APIKey struct {
+	GormModel
+	UserID     uint       `json:"userId" gorm:"index"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-" gorm:"unique"`
+	Scopes     string     `json:"-"`
+	AllowedIPs string     `json:"-"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+}
+
+func (k *APIKey) ScopeList() []string {
+	return splitList(k.Scopes)
+}
+
+func (k *APIKey) AllowedIPList() []string {
+	return splitList(k.AllowedIPs)
+}
+
+func (k *APIKey) HasScope(scope string) bool {
+	for _, granted := range k.ScopeList() {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *APIKey) IsActive() bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || time.Now().Before(*k.ExpiresAt))
+}
+
+type NewAPIKey struct {
+	Name       string     `json:"name" binding:"required,max=100"`
+	Scopes     []string   `json:"scopes" binding:"required,min=1,dive,required"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	AllowedIPs []string   `json:"allowedIps"`
+}
+
+type APIKeyResponse struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	AllowedIPs []string   `json:"allowedIps"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+	// Key is only set in the response to creating the key.
+	Key string `json:"key,omitempty"`
+}
+
+func (k *APIKey) Response() APIKeyResponse {
+	return APIKeyResponse{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     k.ScopeList(),
+		AllowedIPs: k.AllowedIPList(),
+		CreatedAt:  k.CreatedAt,
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+	}
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+	return strings.Split(value, ",")
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AUDIT_USER_CREATE           = "user.create"
+	AUDIT_USER_UPDATE           = "user.update"
+	AUDIT_USER_EMAIL_VERIFY     = "user.email_verify"
+	AUDIT_USER_PASSWORD_CHANGE  = "user.password_change"
+	AUDIT_USER_PASSWORD_RESET   = "user.password_reset"
+	AUDIT_USER_ROLE_UPDATE      = "user.role_update"
+	AUDIT_USER_ERASE            = "user.erase"
+	AUDIT_USER_2FA_ENABLE       = "user.2fa_enable"
+	AUDIT_USER_2FA_DISABLE      = "user.2fa_disable"
+	AUDIT_USER_RECOVERY_CODES   = "user.recovery_codes_regenerate"
+	AUDIT_LOGIN_SUCCESS         = "login.success"
+	AUDIT_LOGIN_FAILURE         = "login.failure"
+	AUDIT_LOGIN_UNLOCK          = "login.unlock"
+	AUDIT_ACCOUNT_CREATE        = "account.create"
+	AUDIT_ACCOUNT_FREEZE        = "account.freeze"
+	AUDIT_ACCOUNT_UNFREEZE      = "account.unfreeze"
+	AUDIT_ACCOUNT_CLOSE         = "account.close"
+	AUDIT_ACCOUNT_ADJUST        = "account.adjust"
+	AUDIT_ACCOUNT_MEMBER_INVITE = "account.member_invite"
+	AUDIT_ACCOUNT_MEMBER_ACCEPT = "account.member_accept"
+	AUDIT_ACCOUNT_MEMBER_UPDATE = "account.member_update"
+	AUDIT_ACCOUNT_MEMBER_REMOVE = "account.member_remove"
+	AUDIT_TRANSACTION_DEPOSIT   = "transaction.deposit"
+	AUDIT_TRANSACTION_WITHDRAW  = "transaction.withdraw"
+	AUDIT_TRANSFER_SEND         = "transfer.send"
+	AUDIT_TRANSFER_ACCEPT       = "transfer.accept"
+	AUDIT_TRANSFER_EXPIRE       = "transfer.expire"
+	AUDIT_API_KEY_CREATE        = "api_key.create"
+	AUDIT_API_KEY_REVOKE        = "api_key.revoke"
+	AUDIT_OAUTH_CLIENT_REGISTER = "oauth.client_register"
+	AUDIT_OAUTH_CONSENT_GRANT   = "oauth.consent_grant"
+	AUDIT_OAUTH_CONSENT_REVOKE  = "oauth.consent_revoke"
+	AUDIT_DATA_EXPORT_REQUEST   = "data.export_request"
+	AUDIT_WEBHOOK_CREATE        = "webhook.create"
+	AUDIT_WEBHOOK_DELETE        = "webhook.delete"
+	AUDIT_WEBHOOK_ENABLE        = "webhook.enable"
+	AUDIT_WEBHOOK_DISABLE       = "webhook.disable"
+	AUDIT_WEBHOOK_REDELIVER     = "webhook.redeliver"
+)
+
+const (
+	ENTITY_USER          = "user"
+	ENTITY_ACCOUNT       = "account"
+	ENTITY_MEMBER        = "account_member"
+	ENTITY_TRANSACTION   = "transaction"
+	ENTITY_TRANSFER      = "transfer"
+	ENTITY_API_KEY       = "api_key"
+	ENTITY_OAUTH_CLIENT  = "oauth_client"
+	ENTITY_OAUTH_CONSENT = "oauth_consent"
+	ENTITY_DATA_REQUEST  = "data_request"
+	ENTITY_WEBHOOK       = "webhook"
+)
+
+// Actor identifies who made a change. UserID is zero for anonymous requests
+// such as logins and password resets.
+type Actor struct {
+	UserID     uint
+	AuthMethod string
+	ClientInfo
+}
+
+// AuditLog is an append-only record of a state change. Before and After hold
+// JSON snapshots of the entity and are null for creations and deletions
+// respectively.
+type AuditLog struct {
+	ID         uint            `json:"id" gorm:"primarykey"`
+	CreatedAt  time.Time       `json:"createdAt" gorm:"index"`
+	ActorID    *uint           `json:"actorId" gorm:"index"`
+	AuthMethod string          `json:"authMethod"`
+	IP         string          `json:"ip"`
+	UserAgent  string          `json:"userAgent"`
+	RequestID  string          `json:"requestId" gorm:"index"`
+	Action     string          `json:"action" gorm:"index"`
+	EntityType string          `json:"entityType" gorm:"index:idx_audit_entity"`
+	EntityID   string          `json:"entityId" gorm:"index:idx_audit_entity"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+}
+
+// AuditLogFilter narrows an audit log query; zero values match everything.
+type AuditLogFilter struct {
+	ActorID    uint      `form:"actorId"`
+	Action     string    `form:"action"`
+	EntityType string    `form:"entityType"`
+	EntityID   string    `form:"entityId"`
+	RequestID  string    `form:"requestId"`
+	From       time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To         time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
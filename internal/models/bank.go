@@ -1,31 +1,60 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
 	"time"
 )
 
 type BankAccount struct {
 	GormModel
-	AccountNumber string  `json:"accountNumber" gorm:"unique"`
-	UserID        uint    `json:"userId"`
-	Balance       float64 `json:"balance"`
+	AccountNumber string     `json:"accountNumber" gorm:"unique"`
+	UserID        uint       `json:"userId" gorm:"index"`
+	Balance       float64    `json:"balance"`
+	FrozenAt      *time.Time `json:"frozenAt"`
+	ClosedAt      *time.Time `json:"closedAt"`
 }
 
+func (a *BankAccount) IsFrozen() bool {
+	return a.FrozenAt != nil
+}
+
+func (a *BankAccount) IsClosed() bool {
+	return a.ClosedAt != nil
+}
+
+// Transaction rows form a hash chain per account: each row's Hash covers its
+// own fields and the Hash of the row before it, so editing or deleting a row
+// breaks every later link.
 type Transaction struct {
 	GormModel
 	Amount        float64 `json:"amount" binding:"required"`
-	AccountNumber string  `json:"accountNumber" binding:"required"`
+	AccountNumber string  `json:"accountNumber" binding:"required" gorm:"index:idx_transaction_chain"`
 	TransactionID string  `json:"transactionId" gorm:"unique"`
 	Type          string  `json:"type"`
+	Sequence      uint64  `json:"sequence" gorm:"index:idx_transaction_chain"`
+	PrevHash      string  `json:"prevHash"`
+	Hash          string  `json:"hash"`
+}
+
+// ComputeHash returns the chain hash of the transaction. CreatedAt is used at
+// microsecond precision, which is what Postgres stores.
+func (t *Transaction) ComputeHash() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d|%s|%s|%s|%d",
+		t.PrevHash, t.AccountNumber, t.Sequence, t.TransactionID, t.Type,
+		strconv.FormatFloat(t.Amount, 'f', -1, 64), t.CreatedAt.UTC().UnixMicro())))
+	return hex.EncodeToString(sum[:])
 }
 
 type Transfer struct {
 	GormModel
-	SenderID      uint      `json:"senderId" binding:"required"`
-	ReceiverID    uint      `json:"receiverId" binding:"required"`
+	SenderID      uint      `json:"senderId" binding:"required" gorm:"index"`
+	ReceiverID    uint      `json:"receiverId" binding:"required" gorm:"index"`
 	Amount        float64   `json:"amount"`
-	Status        string    `json:"status"`
-	ExpiresOn     time.Time `json:"expiresOn"`
+	Status        string    `json:"status" gorm:"index:idx_transfer_expiry"`
+	ExpiresOn     time.Time `json:"expiresOn" gorm:"index:idx_transfer_expiry"`
 	TransactionID string    `gorm:"unique"`
 }
 
@@ -0,0 +1,206 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EVENT_ACCOUNT_OPENED    = "AccountOpened"
+	EVENT_ACCOUNT_FROZEN    = "AccountFrozen"
+	EVENT_ACCOUNT_UNFROZEN  = "AccountUnfrozen"
+	EVENT_ACCOUNT_CLOSED    = "AccountClosed"
+	EVENT_BALANCE_ADJUSTED  = "BalanceAdjusted"
+	EVENT_FUNDS_DEPOSITED   = "FundsDeposited"
+	EVENT_FUNDS_WITHDRAWN   = "FundsWithdrawn"
+	EVENT_TRANSFER_SENT     = "TransferSent"
+	EVENT_TRANSFER_ACCEPTED = "TransferAccepted"
+	EVENT_TRANSFER_EXPIRED  = "TransferExpired"
+)
+
+// DomainEvent is something that happened to an account. AggregateID is the
+// account it happened to; events are published in order per account.
+type DomainEvent interface {
+	EventType() string
+	AggregateID() string
+}
+
+type AccountOpened struct {
+	AccountNumber string `json:"accountNumber"`
+	OwnerID       uint   `json:"ownerId"`
+}
+
+func (e AccountOpened) EventType() string   { return EVENT_ACCOUNT_OPENED }
+func (e AccountOpened) AggregateID() string { return e.AccountNumber }
+
+type AccountFrozen struct {
+	AccountNumber string `json:"accountNumber"`
+}
+
+func (e AccountFrozen) EventType() string   { return EVENT_ACCOUNT_FROZEN }
+func (e AccountFrozen) AggregateID() string { return e.AccountNumber }
+
+type AccountUnfrozen struct {
+	AccountNumber string `json:"accountNumber"`
+}
+
+func (e AccountUnfrozen) EventType() string   { return EVENT_ACCOUNT_UNFROZEN }
+func (e AccountUnfrozen) AggregateID() string { return e.AccountNumber }
+
+type AccountClosed struct {
+	AccountNumber string `json:"accountNumber"`
+}
+
+func (e AccountClosed) EventType() string   { return EVENT_ACCOUNT_CLOSED }
+func (e AccountClosed) AggregateID() string { return e.AccountNumber }
+
+// BalanceAdjusted is a manual correction by staff. Amount is negative for
+// debits.
+type BalanceAdjusted struct {
+	AccountNumber string  `json:"accountNumber"`
+	TransactionID string  `json:"transactionId"`
+	Amount        float64 `json:"amount"`
+	Balance       float64 `json:"balance"`
+	Reason        string  `json:"reason"`
+}
+
+func (e BalanceAdjusted) EventType() string   { return EVENT_BALANCE_ADJUSTED }
+func (e BalanceAdjusted) AggregateID() string { return e.AccountNumber }
+
+type FundsDeposited struct {
+	AccountNumber string  `json:"accountNumber"`
+	TransactionID string  `json:"transactionId"`
+	UserID        uint    `json:"userId"`
+	Amount        float64 `json:"amount"`
+	Balance       float64 `json:"balance"`
+}
+
+func (e FundsDeposited) EventType() string   { return EVENT_FUNDS_DEPOSITED }
+func (e FundsDeposited) AggregateID() string { return e.AccountNumber }
+
+type FundsWithdrawn struct {
+	AccountNumber string  `json:"accountNumber"`
+	TransactionID string  `json:"transactionId"`
+	UserID        uint    `json:"userId"`
+	Amount        float64 `json:"amount"`
+	Balance       float64 `json:"balance"`
+}
+
+func (e FundsWithdrawn) EventType() string   { return EVENT_FUNDS_WITHDRAWN }
+func (e FundsWithdrawn) AggregateID() string { return e.AccountNumber }
+
+type TransferSent struct {
+	AccountNumber string    `json:"accountNumber"`
+	TransactionID string    `json:"transactionId"`
+	SenderID      uint      `json:"senderId"`
+	ReceiverID    uint      `json:"receiverId"`
+	Amount        float64   `json:"amount"`
+	Balance       float64   `json:"balance"`
+	ExpiresOn     time.Time `json:"expiresOn"`
+}
+
+func (e TransferSent) EventType() string   { return EVENT_TRANSFER_SENT }
+func (e TransferSent) AggregateID() string { return e.AccountNumber }
+
+type TransferAccepted struct {
+	AccountNumber string  `json:"accountNumber"`
+	TransactionID string  `json:"transactionId"`
+	SenderID      uint    `json:"senderId"`
+	ReceiverID    uint    `json:"receiverId"`
+	Amount        float64 `json:"amount"`
+	Balance       float64 `json:"balance"`
+}
+
+func (e TransferAccepted) EventType() string   { return EVENT_TRANSFER_ACCEPTED }
+func (e TransferAccepted) AggregateID() string { return e.AccountNumber }
+
+// TransferExpired is published to the sending account when an unaccepted
+// transfer is refunded to it.
+type TransferExpired struct {
+	AccountNumber string  `json:"accountNumber"`
+	TransactionID string  `json:"transactionId"`
+	SenderID      uint    `json:"senderId"`
+	ReceiverID    uint    `json:"receiverId"`
+	Amount        float64 `json:"amount"`
+	Balance       float64 `json:"balance"`
+}
+
+func (e TransferExpired) EventType() string   { return EVENT_TRANSFER_EXPIRED }
+func (e TransferExpired) AggregateID() string { return e.AccountNumber }
+
+// OutboxEvent is a domain event waiting to be, or already, published. It is
+// written in the same transaction as the change it describes. ClaimedUntil is
+// when a relay's claim on the event lapses.
+type OutboxEvent struct {
+	ID           uint            `json:"-" gorm:"primarykey"`
+	EventID      string          `json:"id" gorm:"unique"`
+	Type         string          `json:"type" gorm:"index"`
+	AggregateID  string          `json:"aggregateId" gorm:"index"`
+	ReceiverID   *uint           `json:"-" gorm:"index"`
+	Payload      json.RawMessage `json:"payload"`
+	OccurredAt   time.Time       `json:"occurredAt"`
+	PublishedAt  *time.Time      `json:"-" gorm:"index"`
+	ClaimedUntil *time.Time      `json:"-"`
+	Attempts     int             `json:"-"`
+	LastError    string          `json:"-"`
+}
+
+func NewOutboxEvent(event DomainEvent) (OutboxEvent, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return OutboxEvent{}, err
+	}
+
+	outboxEvent := OutboxEvent{
+		EventID:     uuid.New().String(),
+		Type:        event.EventType(),
+		AggregateID: event.AggregateID(),
+		Payload:     payload,
+		OccurredAt:  time.Now(),
+	}
+	// The receiver does not hold the sending account, so the stream needs
+	// the column to find their transfers without decoding every payload.
+	if sent, ok := event.(TransferSent); ok {
+		outboxEvent.ReceiverID = &sent.ReceiverID
+	}
+	return outboxEvent, nil
+}
+
+// Decode returns the typed event stored in the outbox row.
+func (e *OutboxEvent) Decode() (DomainEvent, error) {
+	switch e.Type {
+	case EVENT_ACCOUNT_OPENED:
+		return decodeEvent[AccountOpened](e.Payload)
+	case EVENT_ACCOUNT_FROZEN:
+		return decodeEvent[AccountFrozen](e.Payload)
+	case EVENT_ACCOUNT_UNFROZEN:
+		return decodeEvent[AccountUnfrozen](e.Payload)
+	case EVENT_ACCOUNT_CLOSED:
+		return decodeEvent[AccountClosed](e.Payload)
+	case EVENT_BALANCE_ADJUSTED:
+		return decodeEvent[BalanceAdjusted](e.Payload)
+	case EVENT_FUNDS_DEPOSITED:
+		return decodeEvent[FundsDeposited](e.Payload)
+	case EVENT_FUNDS_WITHDRAWN:
+		return decodeEvent[FundsWithdrawn](e.Payload)
+	case EVENT_TRANSFER_SENT:
+		return decodeEvent[TransferSent](e.Payload)
+	case EVENT_TRANSFER_ACCEPTED:
+		return decodeEvent[TransferAccepted](e.Payload)
+	case EVENT_TRANSFER_EXPIRED:
+		return decodeEvent[TransferExpired](e.Payload)
+	default:
+		return nil, fmt.Errorf("unknown event type %q", e.Type)
+	}
+}
+
+func decodeEvent[T DomainEvent](payload json.RawMessage) (DomainEvent, error) {
+	var event T
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
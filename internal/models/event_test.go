@@ -0,0 +1,25 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboxEventRoundTrip(t *testing.T) {
+	deposited := FundsDeposited{AccountNumber: "123", TransactionID: "abc", UserID: 1, Amount: 10, Balance: 25}
+
+	outboxEvent, err := NewOutboxEvent(deposited)
+	assert.Nil(t, err)
+	assert.Equal(t, EVENT_FUNDS_DEPOSITED, outboxEvent.Type)
+	assert.Equal(t, "123", outboxEvent.AggregateID)
+	assert.NotEmpty(t, outboxEvent.EventID)
+
+	decoded, err := outboxEvent.Decode()
+	assert.Nil(t, err)
+	assert.Equal(t, deposited, decoded)
+
+	outboxEvent.Type = "Unknown"
+	_, err = outboxEvent.Decode()
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LEDGER_GENESIS_HASH is the PrevHash of the first transaction of an account.
+const LEDGER_GENESIS_HASH = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// LedgerHead is the latest link of an account's chain.
+type LedgerHead struct {
+	AccountNumber string `json:"accountNumber"`
+	Sequence      uint64 `json:"sequence"`
+	Hash          string `json:"hash"`
+}
+
+// LedgerCheckpoint commits to the head of every chain at a point in time.
+// It is signed with the active JWT signing key, whose public half is
+// published in the JWKS.
+type LedgerCheckpoint struct {
+	CreatedAt time.Time    `json:"createdAt"`
+	Heads     []LedgerHead `json:"heads"`
+	KeyID     string       `json:"kid"`
+	Signature string       `json:"signature"`
+}
+
+// SigningPayload is the canonical form of the checkpoint that is signed.
+func (c *LedgerCheckpoint) SigningPayload() []byte {
+	var b strings.Builder
+	b.WriteString(c.CreatedAt.UTC().Format(time.RFC3339Nano))
+	for _, head := range c.Heads {
+		fmt.Fprintf(&b, "\n%s|%d|%s", head.AccountNumber, head.Sequence, head.Hash)
+	}
+	return []byte(b.String())
+}
+
+// LedgerBreak describes the first link that failed verification.
+type LedgerBreak struct {
+	AccountNumber string `json:"accountNumber"`
+	Sequence      uint64 `json:"sequence"`
+	TransactionID string `json:"transactionId"`
+	Reason        string `json:"reason"`
+}
+
+type LedgerVerification struct {
+	Valid        bool         `json:"valid"`
+	Accounts     int          `json:"accounts"`
+	Transactions int          `json:"transactions"`
+	BrokenLink   *LedgerBreak `json:"brokenLink,omitempty"`
+}
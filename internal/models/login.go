@@ -1,6 +1,50 @@
 package models
 
+import (
+	"time"
+)
+
 type Login struct {
 	Email    string `json:"email" binding:"required"`
 	Password string `json:"password" binding:"required"`
 }
+
+// LoginResult holds either a signed token or, for accounts with two-factor
+// authentication enabled, the challenge that must be completed to get one.
+type LoginResult struct {
+	Token       string
+	ChallengeID string
+}
+
+type UnlockLogin struct {
+	Token string `json:"token" form:"token" binding:"required"`
+}
+
+// LoginUnlockToken is the single-use token behind the link emailed when an
+// account is locked.
+type LoginUnlockToken struct {
+	GormModel
+	UserID    uint       `json:"userId" gorm:"index"`
+	TokenHash string     `json:"-" gorm:"unique"`
+	ExpiresOn time.Time  `json:"expiresOn"`
+	UsedAt    *time.Time `json:"usedAt"`
+}
+
+// ClientInfo describes where a request came from.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+// LoginAttempt is the history of every login attempt, successful or not. It
+// also drives throttling and lockout.
+type LoginAttempt struct {
+	GormModel
+	Email     string `json:"email" gorm:"index"`
+	UserID    *uint  `json:"userId" gorm:"index"`
+	IP        string `json:"ip" gorm:"index"`
+	UserAgent string `json:"userAgent"`
+	Success   bool   `json:"success"`
+	Reason    string `json:"reason"`
+}
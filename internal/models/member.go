@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	MEMBER_OWNER      = "owner"
+	MEMBER_CO_OWNER   = "co-owner"
+	MEMBER_VIEWER     = "viewer"
+	MEMBER_TRANSACTOR = "transactor"
+)
+
+// AccountMember gives a user access to a bank account. Members invited by
+// another holder stay pending until they accept.
+type AccountMember struct {
+	GormModel
+	AccountNumber string     `json:"accountNumber" gorm:"uniqueIndex:idx_account_member"`
+	UserID        uint       `json:"userId" gorm:"uniqueIndex:idx_account_member;index"`
+	Role          string     `json:"role"`
+	Limit         float64    `json:"limit" gorm:"column:transaction_limit"`
+	InvitedBy     uint       `json:"invitedBy"`
+	AcceptedAt    *time.Time `json:"acceptedAt"`
+}
+
+func (m *AccountMember) IsActive() bool {
+	return m.AcceptedAt != nil
+}
+
+func (m *AccountMember) CanTransact() bool {
+	return m.IsActive() && m.Role != MEMBER_VIEWER
+}
+
+// CanTransactAmount checks the per-transaction limit of transactors; other
+// roles that can transact are unlimited.
+func (m *AccountMember) CanTransactAmount(amount float64) bool {
+	if !m.CanTransact() {
+		return false
+	}
+	return m.Role != MEMBER_TRANSACTOR || amount <= m.Limit
+}
+
+func (m *AccountMember) CanManageMembers() bool {
+	return m.IsActive() && (m.Role == MEMBER_OWNER || m.Role == MEMBER_CO_OWNER)
+}
+
+type InviteMember struct {
+	Email string  `json:"email" binding:"required,email"`
+	Role  string  `json:"role" binding:"required,oneof=co-owner viewer transactor"`
+	Limit float64 `json:"limit" binding:"gte=0"`
+}
+
+type UpdateMember struct {
+	Role  string  `json:"role" binding:"required,oneof=co-owner viewer transactor"`
+	Limit float64 `json:"limit" binding:"gte=0"`
+}
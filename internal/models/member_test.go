@@ -0,0 +1,29 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountMemberPermissions(t *testing.T) {
+	now := time.Now()
+
+	pending := AccountMember{Role: MEMBER_CO_OWNER}
+	assert.False(t, pending.CanTransact())
+	assert.False(t, pending.CanManageMembers())
+
+	viewer := AccountMember{Role: MEMBER_VIEWER, AcceptedAt: &now}
+	assert.False(t, viewer.CanTransact())
+	assert.False(t, viewer.CanManageMembers())
+
+	transactor := AccountMember{Role: MEMBER_TRANSACTOR, Limit: 100, AcceptedAt: &now}
+	assert.True(t, transactor.CanTransactAmount(100))
+	assert.False(t, transactor.CanTransactAmount(100.01))
+	assert.False(t, transactor.CanManageMembers())
+
+	coOwner := AccountMember{Role: MEMBER_CO_OWNER, AcceptedAt: &now}
+	assert.True(t, coOwner.CanTransactAmount(1000000))
+	assert.True(t, coOwner.CanManageMembers())
+}
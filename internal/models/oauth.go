@@ -0,0 +1,184 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+const AUTH_METHOD_OAUTH = "oauth"
+
+const (
+	OAUTH_SCOPE_ACCOUNTS_READ     = "accounts:read"
+	OAUTH_SCOPE_TRANSACTIONS_READ = "transactions:read"
+	OAUTH_SCOPE_PAYMENTS_WRITE    = "payments:write"
+)
+
+// oauthScopes maps each scope a third-party app can request to its
+// description on the consent screen and the route scopes it unlocks.
+var oauthScopes = map[string]struct {
+	Description string
+	Grants      []string
+}{
+	OAUTH_SCOPE_ACCOUNTS_READ: {
+		Description: "View your accounts and balances",
+		Grants:      []string{SCOPE_ACCOUNTS_READ},
+	},
+	OAUTH_SCOPE_TRANSACTIONS_READ: {
+		Description: "View your transaction history",
+		Grants:      []string{SCOPE_TRANSACTIONS_READ},
+	},
+	OAUTH_SCOPE_PAYMENTS_WRITE: {
+		Description: "Send and accept transfers on your behalf",
+		Grants:      []string{SCOPE_TRANSFERS_WRITE},
+	},
+}
+
+func IsValidOAuthScope(scope string) bool {
+	_, ok := oauthScopes[scope]
+	return ok
+}
+
+func OAuthScopeDescription(scope string) string {
+	return oauthScopes[scope].Description
+}
+
+// ExpandOAuthScopes returns the route scopes granted by a space separated
+// list of OAuth scopes.
+func ExpandOAuthScopes(scope string) []string {
+	granted := []string{}
+	for _, oauthScope := range strings.Fields(scope) {
+		granted = append(granted, oauthScopes[oauthScope].Grants...)
+	}
+	return granted
+}
+
+type OAuthClient struct {
+	GormModel
+	ClientID         string `json:"clientId" gorm:"unique"`
+	ClientSecretHash string `json:"-"`
+	Name             string `json:"name"`
+	RedirectURIs     string `json:"redirectUris"`
+	Scopes           string `json:"scopes"`
+	OwnerID          uint   `json:"ownerId"`
+}
+
+func (c *OAuthClient) IsConfidential() bool {
+	return c.ClientSecretHash != ""
+}
+
+func (c *OAuthClient) AllowsRedirectURI(uri string) bool {
+	for _, allowed := range strings.Fields(c.RedirectURIs) {
+		if allowed == uri {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *OAuthClient) AllowsScope(scope string) bool {
+	for _, allowed := range strings.Fields(c.Scopes) {
+		if allowed == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// OAuthAuthorizationRequest is a validated /oauth/authorize request waiting
+// for the user's decision on the consent screen.
+type OAuthAuthorizationRequest struct {
+	GormModel
+	RequestHash   string `gorm:"unique"`
+	UserID        uint   `gorm:"index"`
+	ClientID      string
+	RedirectURI   string
+	Scopes        string
+	State         string
+	CodeChallenge string
+	ExpiresOn     time.Time
+}
+
+type OAuthAuthorizationCode struct {
+	GormModel
+	CodeHash      string `gorm:"unique"`
+	ConsentID     uint
+	UserID        uint
+	ClientID      string
+	RedirectURI   string
+	Scopes        string
+	CodeChallenge string
+	ExpiresOn     time.Time
+	UsedAt        *time.Time
+}
+
+// OAuthConsent is a user's grant of scopes to a client. Revoking it
+// invalidates every access and refresh token issued under it.
+type OAuthConsent struct {
+	GormModel
+	UserID    uint       `json:"userId" gorm:"index"`
+	ClientID  string     `json:"clientId" gorm:"index"`
+	Scopes    string     `json:"scopes"`
+	RevokedAt *time.Time `json:"revokedAt"`
+}
+
+// OAuthRefreshToken carries the scopes of the authorization it descends
+// from, which stay the same through rotation even if the consent grows.
+type OAuthRefreshToken struct {
+	GormModel
+	TokenHash string `gorm:"unique"`
+	ConsentID uint   `gorm:"index"`
+	Scopes    string
+	ExpiresOn time.Time
+	UsedAt    *time.Time
+}
+
+type NewOAuthClient struct {
+	Name         string   `json:"name" binding:"required,max=100"`
+	RedirectURIs []string `json:"redirectUris" binding:"required,min=1,dive,url"`
+	Scopes       []string `json:"scopes" binding:"required,min=1"`
+	Confidential bool     `json:"confidential"`
+}
+
+type OAuthClientResponse struct {
+	ClientID     string   `json:"clientId"`
+	ClientSecret string   `json:"clientSecret,omitempty"`
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirectUris"`
+	Scopes       []string `json:"scopes"`
+}
+
+type OAuthAuthorize struct {
+	ResponseType        string `form:"response_type" binding:"required"`
+	ClientID            string `form:"client_id" binding:"required"`
+	RedirectURI         string `form:"redirect_uri" binding:"required"`
+	Scope               string `form:"scope" binding:"required"`
+	State               string `form:"state"`
+	CodeChallenge       string `form:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method"`
+}
+
+type OAuthTokenRequest struct {
+	GrantType    string `form:"grant_type" binding:"required"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	RefreshToken string `form:"refresh_token"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+type OAuthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+}
+
+type OAuthConsentResponse struct {
+	ID         uint      `json:"id"`
+	ClientID   string    `json:"clientId"`
+	ClientName string    `json:"clientName"`
+	Scopes     []string  `json:"scopes"`
+	GrantedAt  time.Time `json:"grantedAt"`
+}
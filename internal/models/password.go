@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+type PasswordResetToken struct {
+	GormModel
+	UserID    uint       `json:"userId" gorm:"index"`
+	TokenHash string     `json:"-" gorm:"unique"`
+	ExpiresOn time.Time  `json:"expiresOn"`
+	UsedAt    *time.Time `json:"usedAt"`
+}
+
+type ForgotPassword struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPassword struct {
+	Token    string `json:"token" form:"token" binding:"required"`
+	Password string `json:"password" form:"password" binding:"required,min=8"`
+}
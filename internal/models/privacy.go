@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	DATA_REQUEST_EXPORT  = "export"
+	DATA_REQUEST_ERASURE = "erasure"
+)
+
+const (
+	DATA_REQUEST_PENDING   = "PENDING"
+	DATA_REQUEST_COMPLETED = "COMPLETED"
+	DATA_REQUEST_FAILED    = "FAILED"
+)
+
+// DataRequest records every export and erasure request. Rows are kept after
+// erasure as evidence that the request was handled.
+type DataRequest struct {
+	GormModel
+	UserID      uint       `json:"userId" gorm:"index"`
+	RequestedBy uint       `json:"requestedBy"`
+	Type        string     `json:"type"`
+	Status      string     `json:"status"`
+	IP          string     `json:"ip"`
+	UserAgent   string     `json:"userAgent"`
+	Error       string     `json:"error,omitempty"`
+	CompletedAt *time.Time `json:"completedAt"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+	Archive     []byte     `json:"-"`
+}
+
+func (r *DataRequest) IsDownloadable() bool {
+	return r.Type == DATA_REQUEST_EXPORT && r.Status == DATA_REQUEST_COMPLETED &&
+		r.ExpiresAt != nil && time.Now().Before(*r.ExpiresAt) && len(r.Archive) > 0
+}
+
+type RequestErasure struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// DataExport is the content of an export archive.
+type DataExport struct {
+	GeneratedAt  time.Time       `json:"generatedAt"`
+	Profile      UserResponse    `json:"profile"`
+	Accounts     []BankAccount   `json:"accounts"`
+	Memberships  []AccountMember `json:"memberships"`
+	Transactions []Transaction   `json:"transactions"`
+	Transfers    []Transfer      `json:"transfers"`
+}
@@ -0,0 +1,56 @@
+package models
+
+const (
+	ROLE_CUSTOMER = "customer"
+	ROLE_SUPPORT  = "support"
+	ROLE_ADMIN    = "admin"
+)
+
+type Permission string
+
+const (
+	PERMISSION_READ_USERS        Permission = "users:read"
+	PERMISSION_MANAGE_USERS      Permission = "users:manage"
+	PERMISSION_READ_TRANSACTIONS Permission = "transactions:read"
+	PERMISSION_FREEZE_ACCOUNTS   Permission = "accounts:freeze"
+	PERMISSION_MANAGE_ACCOUNTS   Permission = "accounts:manage"
+	PERMISSION_MANAGE_OAUTH      Permission = "oauth:manage"
+	PERMISSION_READ_AUDIT_LOG    Permission = "audit:read"
+)
+
+var rolePermissions = map[string][]Permission{
+	ROLE_CUSTOMER: {},
+	ROLE_SUPPORT: {
+		PERMISSION_READ_USERS,
+		PERMISSION_READ_TRANSACTIONS,
+	},
+	ROLE_ADMIN: {
+		PERMISSION_READ_USERS,
+		PERMISSION_MANAGE_USERS,
+		PERMISSION_READ_TRANSACTIONS,
+		PERMISSION_FREEZE_ACCOUNTS,
+		PERMISSION_MANAGE_ACCOUNTS,
+		PERMISSION_MANAGE_OAUTH,
+		PERMISSION_READ_AUDIT_LOG,
+	},
+}
+
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasPermission reports whether role grants permission. Unknown or empty
+// roles are treated as customers.
+func HasPermission(role string, permission Permission) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+type UpdateRole struct {
+	Role string `json:"role" binding:"required,oneof=customer support admin"`
+}
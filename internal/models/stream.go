@@ -0,0 +1,25 @@
+package models
+
+import "encoding/json"
+
+// STREAM_TRANSFER_RECEIVED is streamed to the receiver of a TransferSent
+// event, who does not hold the sending account.
+const STREAM_TRANSFER_RECEIVED = "TransferReceived"
+
+// STREAM_REPLAY_TRUNCATED ends a replay that hit the limit. The client should
+// reconnect from the last event ID to fetch the rest.
+const STREAM_REPLAY_TRUNCATED = "ReplayTruncated"
+
+// StreamEvent is pushed to clients of the real-time stream. ID is the outbox
+// event ID, which clients send back as Last-Event-ID to resume.
+type StreamEvent struct {
+	ID   uint            `json:"id"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// ReplayTruncated returns the marker sent after a truncated replay that ended
+// at lastID.
+func ReplayTruncated(lastID uint) StreamEvent {
+	return StreamEvent{ID: lastID, Type: STREAM_REPLAY_TRUNCATED, Data: json.RawMessage(`{}`)}
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+)
+
+type RecoveryCode struct {
+	GormModel
+	UserID   uint       `json:"userId" gorm:"index"`
+	CodeHash string     `json:"-" gorm:"unique"`
+	UsedAt   *time.Time `json:"usedAt"`
+}
+
+type LoginChallenge struct {
+	GormModel
+	ChallengeHash string     `json:"-" gorm:"unique"`
+	UserID        uint       `json:"userId" gorm:"index"`
+	ExpiresOn     time.Time  `json:"expiresOn"`
+	Attempts      int        `json:"attempts"`
+	CompletedAt   *time.Time `json:"completedAt"`
+}
+
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+	QRCode string `json:"qrCode"`
+}
+
+type TwoFactorCode struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorLogin struct {
+	ChallengeID string `json:"challengeId" binding:"required"`
+	Code        string `json:"code" binding:"required"`
+}
+
+type DisableTwoFactor struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
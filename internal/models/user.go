@@ -1,9 +1,79 @@
 package models
 
+import (
+	"time"
+)
+
 type User struct {
 	GormModel
-	Email     string `json:"email" binding:"required" gorm:"unique"`
-	FirstName string `json:"firstName" binding:"required"`
-	LastName  string `json:"lastName" binding:"required"`
-	Password  string `json:"password" binding:"required"`
+	Email           string     `json:"email" binding:"required,email" gorm:"unique"`
+	FirstName       string     `json:"firstName" binding:"required,max=100"`
+	LastName        string     `json:"lastName" binding:"required,max=100"`
+	Password        string     `json:"password,omitempty" binding:"required,max=72"`
+	Role            string     `json:"role" gorm:"default:customer"`
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt"`
+	TOTPSecret      string     `json:"-"`
+	TOTPEnabled     bool       `json:"totpEnabled"`
+	// TOTPLastStep is the time step of the last code accepted. Codes for it
+	// or an earlier step are rejected, so a code cannot be replayed.
+	TOTPLastStep int64 `json:"-"`
+	// SessionsValidFrom revokes every token issued before it.
+	SessionsValidFrom time.Time `json:"-"`
+	// ErasedAt is set once personal data has been pseudonymised.
+	ErasedAt *time.Time `json:"erasedAt"`
+}
+
+func (u *User) IsErased() bool {
+	return u.ErasedAt != nil
+}
+
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// UserResponse is the public view of a user, without credentials.
+type UserResponse struct {
+	ID              uint       `json:"id"`
+	Email           string     `json:"email"`
+	FirstName       string     `json:"firstName"`
+	LastName        string     `json:"lastName"`
+	Role            string     `json:"role"`
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt"`
+	TOTPEnabled     bool       `json:"totpEnabled"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+func (u *User) Response() UserResponse {
+	return UserResponse{
+		ID:              u.ID,
+		Email:           u.Email,
+		FirstName:       u.FirstName,
+		LastName:        u.LastName,
+		Role:            u.Role,
+		EmailVerifiedAt: u.EmailVerifiedAt,
+		TOTPEnabled:     u.TOTPEnabled,
+		CreatedAt:       u.CreatedAt,
+		UpdatedAt:       u.UpdatedAt,
+	}
+}
+
+// UpdateProfile changes only the fields that are set. A new email address
+// has to be verified again.
+type UpdateProfile struct {
+	FirstName *string `json:"firstName" binding:"omitempty,min=1,max=100"`
+	LastName  *string `json:"lastName" binding:"omitempty,min=1,max=100"`
+	Email     *string `json:"email" binding:"omitempty,email,max=254"`
+}
+
+type ChangePassword struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required,min=8,max=72"`
+}
+
+// EmailVerification records each verification email sent, for rate limiting.
+type EmailVerification struct {
+	GormModel
+	UserID uint   `json:"userId" gorm:"index"`
+	Email  string `json:"email"`
 }
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserResponseOmitsCredentials(t *testing.T) {
+	user := User{Email: "test@example.com", FirstName: "Test", Password: "hash", TOTPSecret: "secret"}
+
+	body, err := json.Marshal(user.Response())
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), "test@example.com")
+	assert.NotContains(t, string(body), "hash")
+	assert.NotContains(t, string(body), "secret")
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	WEBHOOK_TRANSFER_RECEIVED = "transfer.received"
+	WEBHOOK_TRANSFER_ACCEPTED = "transfer.accepted"
+	WEBHOOK_DEPOSIT           = "deposit"
+	WEBHOOK_LOW_BALANCE       = "balance.low"
+)
+
+var AllWebhookEvents = []string{
+	WEBHOOK_TRANSFER_RECEIVED,
+	WEBHOOK_TRANSFER_ACCEPTED,
+	WEBHOOK_DEPOSIT,
+	WEBHOOK_LOW_BALANCE,
+}
+
+func IsValidWebhookEvent(eventType string) bool {
+	for _, valid := range AllWebhookEvents {
+		if eventType == valid {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	DELIVERY_PENDING   = "pending"
+	DELIVERY_SUCCEEDED = "succeeded"
+	DELIVERY_FAILED    = "failed"
+)
+
+// WebhookEndpoint is a URL a user wants events POSTed to. Secret signs every
+// delivery and is only shown when the endpoint is created.
+type WebhookEndpoint struct {
+	GormModel
+	UserID              uint       `json:"userId" gorm:"index"`
+	URL                 string     `json:"url"`
+	Secret              string     `json:"-"`
+	EventTypes          string     `json:"-"`
+	LowBalanceThreshold float64    `json:"lowBalanceThreshold"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	DisabledAt          *time.Time `json:"disabledAt"`
+}
+
+func (w *WebhookEndpoint) EventTypeList() []string {
+	return splitList(w.EventTypes)
+}
+
+func (w *WebhookEndpoint) IsSubscribed(eventType string) bool {
+	for _, subscribed := range w.EventTypeList() {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *WebhookEndpoint) IsEnabled() bool {
+	return w.DisabledAt == nil
+}
+
+type NewWebhookEndpoint struct {
+	URL                 string   `json:"url" binding:"required,url,max=2048"`
+	EventTypes          []string `json:"eventTypes" binding:"required,min=1,dive,required"`
+	LowBalanceThreshold float64  `json:"lowBalanceThreshold" binding:"gte=0"`
+}
+
+type WebhookEndpointResponse struct {
+	ID                  uint       `json:"id"`
+	URL                 string     `json:"url"`
+	EventTypes          []string   `json:"eventTypes"`
+	LowBalanceThreshold float64    `json:"lowBalanceThreshold"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	CreatedAt           time.Time  `json:"createdAt"`
+	DisabledAt          *time.Time `json:"disabledAt"`
+	// Secret is only set in the response to creating the endpoint.
+	Secret string `json:"secret,omitempty"`
+}
+
+func (w *WebhookEndpoint) Response() WebhookEndpointResponse {
+	return WebhookEndpointResponse{
+		ID:                  w.ID,
+		URL:                 w.URL,
+		EventTypes:          w.EventTypeList(),
+		LowBalanceThreshold: w.LowBalanceThreshold,
+		ConsecutiveFailures: w.ConsecutiveFailures,
+		CreatedAt:           w.CreatedAt,
+		DisabledAt:          w.DisabledAt,
+	}
+}
+
+// WebhookDelivery is one event queued for one endpoint, together with the
+// outcome of its latest attempt.
+type WebhookDelivery struct {
+	GormModel
+	EndpointID     uint            `json:"endpointId" gorm:"uniqueIndex:idx_webhook_delivery"`
+	EventID        string          `json:"eventId" gorm:"uniqueIndex:idx_webhook_delivery"`
+	EventType      string          `json:"eventType" gorm:"uniqueIndex:idx_webhook_delivery"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status" gorm:"index"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"nextAttemptAt" gorm:"index"`
+	LastStatusCode int             `json:"lastStatusCode"`
+	LastError      string          `json:"lastError"`
+	DeliveredAt    *time.Time      `json:"deliveredAt"`
+}
+
+// WebhookPayload is the JSON body POSTed to an endpoint.
+type WebhookPayload struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	Data       json.RawMessage `json:"data"`
+}
@@ -0,0 +1,258 @@
+package openapi
+
+import (
+	"database/sql"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	nullTimeType      = reflect.TypeOf(sql.NullTime{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Generator builds schemas from Go types the way encoding/json marshals
+// them. Named structs become components and are referenced by name; gin
+// binding tags become validation keywords.
+type Generator struct {
+	Schemas map[string]*Schema
+	types   map[string]reflect.Type
+}
+
+func NewGenerator() *Generator {
+	return &Generator{Schemas: map[string]*Schema{}, types: map[string]reflect.Type{}}
+}
+
+// Schema returns the schema for the type of v.
+func (g *Generator) Schema(v interface{}) *Schema {
+	return g.schemaFor(reflect.TypeOf(v))
+}
+
+// Parameters describes the form-tagged fields of a struct, as bound by
+// ShouldBindQuery, as parameters in the given location.
+func (g *Generator) Parameters(v interface{}, in string) []Parameter {
+	return g.parameters(reflect.TypeOf(v), in)
+}
+
+func (g *Generator) parameters(t reflect.Type, in string) []Parameter {
+	var parameters []Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("form"), ",")[0]
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			parameters = append(parameters, g.parameters(field.Type, in)...)
+			continue
+		}
+		if name == "" || name == "-" {
+			continue
+		}
+
+		schema, required := g.fieldSchema(field)
+		parameters = append(parameters, Parameter{Name: name, In: in, Required: required, Schema: schema})
+	}
+	return parameters
+}
+
+func (g *Generator) schemaFor(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.ConvertibleTo(nullTimeType):
+		return &Schema{Type: []string{"string", "null"}, Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{}
+	case t.Kind() != reflect.Pointer && t.Implements(textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(g.schemaFor(t.Elem()))
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Minimum: float(0)}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return g.reference(t)
+	default:
+		return &Schema{}
+	}
+}
+
+// reference registers a named struct as a component the first time it is
+// seen. Two types with the same name would silently share a schema, so that
+// is treated as a programming error.
+func (g *Generator) reference(t reflect.Type) *Schema {
+	name := componentName(t)
+	ref := &Schema{Ref: "#/components/schemas/" + name}
+
+	if existing, ok := g.types[name]; ok {
+		if existing != t {
+			panic(fmt.Sprintf("openapi: %s and %s both map to schema %s", existing, t, name))
+		}
+		return ref
+	}
+
+	g.types[name] = t
+	g.Schemas[name] = nil // placeholder so recursive types terminate
+	g.Schemas[name] = g.structSchema(t)
+	return ref
+}
+
+func (g *Generator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.addFields(schema, t)
+	return schema
+}
+
+func (g *Generator) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			g.addFields(schema, field.Type)
+			continue
+		}
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fieldSchema, required := g.fieldSchema(field)
+		schema.Properties[name] = fieldSchema
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// fieldSchema applies the field's binding rules to the schema of its type
+// and reports whether the field is required.
+func (g *Generator) fieldSchema(field reflect.StructField) (*Schema, bool) {
+	t := field.Type
+	isPointer := t.Kind() == reflect.Pointer
+	if isPointer {
+		t = t.Elem()
+	}
+
+	schema := g.schemaFor(t)
+	required := false
+
+	for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+		rule, value, _ := strings.Cut(rule, "=")
+		switch rule {
+		case "dive":
+			// Later rules apply to the elements, not the field.
+			return wrapNullable(schema, isPointer), required
+		case "required":
+			required = true
+		case "email":
+			schema.Format = "email"
+		case "url":
+			schema.Format = "uri"
+		case "oneof":
+			schema.Enum = strings.Fields(value)
+		case "min", "max":
+			setLimit(schema, t.Kind(), rule, value)
+		case "gte":
+			schema.Minimum = parseFloat(value)
+		case "lte":
+			schema.Maximum = parseFloat(value)
+		}
+	}
+
+	return wrapNullable(schema, isPointer), required
+}
+
+// setLimit maps gin's min and max, which mean a length for strings and
+// slices and a value for numbers.
+func setLimit(schema *Schema, kind reflect.Kind, rule string, value string) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return
+	}
+
+	switch kind {
+	case reflect.String:
+		if rule == "min" {
+			schema.MinLength = &n
+		} else {
+			schema.MaxLength = &n
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if rule == "min" {
+			schema.MinItems = &n
+		} else {
+			schema.MaxItems = &n
+		}
+	default:
+		if rule == "min" {
+			schema.Minimum = float(float64(n))
+		} else {
+			schema.Maximum = float(float64(n))
+		}
+	}
+}
+
+func wrapNullable(schema *Schema, isPointer bool) *Schema {
+	if !isPointer {
+		return schema
+	}
+	return nullable(schema)
+}
+
+func nullable(schema *Schema) *Schema {
+	if schema.Ref != "" {
+		return &Schema{AnyOf: []*Schema{schema, {Type: "null"}}}
+	}
+	if typeName, ok := schema.Type.(string); ok {
+		schema.Type = []string{typeName, "null"}
+	}
+	return schema
+}
+
+func componentName(t reflect.Type) string {
+	name := []rune(t.Name())
+	name[0] = unicode.ToUpper(name[0])
+	return string(name)
+}
+
+func parseFloat(value string) *float64 {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil
+	}
+	return &n
+}
+
+func float(n float64) *float64 {
+	return &n
+}
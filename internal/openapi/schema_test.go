@@ -0,0 +1,70 @@
+package openapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type embedded struct {
+	ID uint `json:"id"`
+}
+
+type example struct {
+	embedded
+	Email    string     `json:"email" binding:"required,email"`
+	Name     *string    `json:"name" binding:"omitempty,min=1,max=100"`
+	Role     string     `json:"role" binding:"required,oneof=viewer owner"`
+	Limit    float64    `json:"limit" binding:"gte=0"`
+	Scopes   []string   `json:"scopes" binding:"required,min=1,dive,required"`
+	Secret   string     `json:"-"`
+	FrozenAt *time.Time `json:"frozenAt"`
+	Child    *child     `json:"child"`
+}
+
+type child struct {
+	Value int `json:"value"`
+}
+
+func TestSchemaFollowsJSONAndBindingTags(t *testing.T) {
+	g := NewGenerator()
+
+	ref := g.Schema(example{})
+	assert.Equal(t, "#/components/schemas/Example", ref.Ref)
+
+	schema := g.Schemas["Example"]
+	assert.Equal(t, []string{"email", "role", "scopes"}, schema.Required)
+	assert.Contains(t, schema.Properties, "id")
+	assert.NotContains(t, schema.Properties, "Secret")
+
+	assert.Equal(t, "email", schema.Properties["email"].Format)
+	assert.Equal(t, []string{"string", "null"}, schema.Properties["name"].Type)
+	assert.Equal(t, 100, *schema.Properties["name"].MaxLength)
+	assert.Equal(t, []string{"viewer", "owner"}, schema.Properties["role"].Enum)
+	assert.Equal(t, 0.0, *schema.Properties["limit"].Minimum)
+	assert.Equal(t, 1, *schema.Properties["scopes"].MinItems)
+	assert.Equal(t, "date-time", schema.Properties["frozenAt"].Format)
+
+	assert.Len(t, schema.Properties["child"].AnyOf, 2)
+	assert.Contains(t, g.Schemas, "Child")
+}
+
+func TestParametersUseFormTags(t *testing.T) {
+	type query struct {
+		Token string `form:"token" binding:"required"`
+		embeddedQuery
+	}
+
+	parameters := NewGenerator().Parameters(query{}, "query")
+
+	assert.Len(t, parameters, 2)
+	assert.Equal(t, "token", parameters[0].Name)
+	assert.True(t, parameters[0].Required)
+	assert.Equal(t, "page", parameters[1].Name)
+	assert.Equal(t, "integer", parameters[1].Schema.Type)
+}
+
+type embeddedQuery struct {
+	Page int `form:"page"`
+}
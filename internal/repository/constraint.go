@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
+)
+
+// Writes rejected by a database constraint fail with a ConstraintError
+// matching one of these.
+var (
+	ErrDuplicate        = errors.New("record already exists")
+	ErrMissingReference = errors.New("referenced record does not exist")
+	ErrInvalidValue     = errors.New("value is not allowed")
+)
+
+// Check constraints the services turn into domain errors. Both stores report
+// them by these names.
+const (
+	CHECK_ACCOUNT_BALANCE    = "chk_bank_accounts_balance"
+	CHECK_TRANSACTION_AMOUNT = "chk_transactions_amount"
+	CHECK_TRANSFER_AMOUNT    = "chk_transfers_amount"
+	CHECK_MEMBER_LIMIT       = "chk_account_members_limit"
+)
+
+// ConstraintError is a write rejected by a constraint. Constraint names it
+// when the database says which one; SQLite does not for foreign keys.
+type ConstraintError struct {
+	Kind       error
+	Constraint string
+}
+
+func (e *ConstraintError) Error() string {
+	if e.Constraint == "" {
+		return e.Kind.Error()
+	}
+	return e.Kind.Error() + ": " + e.Constraint
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return e.Kind
+}
+
+// constraintError recognises constraint violations reported by Postgres and
+// SQLite, returning nil for any other error.
+func constraintError(err error) *ConstraintError {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505":
+			return &ConstraintError{Kind: ErrDuplicate, Constraint: pgErr.ConstraintName}
+		case "23503":
+			return &ConstraintError{Kind: ErrMissingReference, Constraint: pgErr.ConstraintName}
+		case "23514":
+			return &ConstraintError{Kind: ErrInvalidValue, Constraint: pgErr.ConstraintName}
+		}
+		return nil
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		// The message is "<KIND> constraint failed: <name>".
+		_, name, _ := strings.Cut(sqliteErr.Error(), "constraint failed: ")
+		switch sqliteErr.ExtendedCode {
+		case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+			return &ConstraintError{Kind: ErrDuplicate, Constraint: name}
+		case sqlite3.ErrConstraintForeignKey:
+			return &ConstraintError{Kind: ErrMissingReference}
+		case sqlite3.ErrConstraintCheck:
+			return &ConstraintError{Kind: ErrInvalidValue, Constraint: name}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,388 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore wraps db, which may itself be an open transaction.
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+func (s *GormStore) Users() UserRepository               { return gormUsers{s.db} }
+func (s *GormStore) Accounts() AccountRepository         { return gormAccounts{s.db} }
+func (s *GormStore) Transactions() TransactionRepository { return gormTransactions{s.db} }
+func (s *GormStore) Transfers() TransferRepository       { return gormTransfers{s.db} }
+func (s *GormStore) Logins() LoginRepository             { return gormLogins{s.db} }
+func (s *GormStore) Journal() JournalRepository          { return gormJournal{s.db} }
+
+func (s *GormStore) Transaction(fn func(tx Store) error) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return fn(NewGormStore(tx))
+	})
+}
+
+// translate maps GORM's not-found error to ErrNotFound and constraint
+// violations to a ConstraintError.
+func translate(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	if violation := constraintError(err); violation != nil {
+		return violation
+	}
+	return err
+}
+
+type gormUsers struct {
+	db *gorm.DB
+}
+
+func (r gormUsers) Create(user *models.User) error {
+	return translate(r.db.Create(user).Error)
+}
+
+func (r gormUsers) Get(id uint) (models.User, error) {
+	var user models.User
+	err := r.db.First(&user, id).Error
+	return user, translate(err)
+}
+
+func (r gormUsers) GetByEmail(email string) (models.User, error) {
+	var user models.User
+	err := r.db.Where("LOWER(email) = ?", strings.ToLower(strings.TrimSpace(email))).First(&user).Error
+	return user, translate(err)
+}
+
+func (r gormUsers) ListByIDs(ids []uint) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
+func (r gormUsers) List(email string, offset int, limit int) ([]models.User, int64, error) {
+	query := r.db.Model(&models.User{})
+	if email != "" {
+		query = query.Where("email LIKE ?", "%"+email+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []models.User
+	err := query.Order("id").Offset(offset).Limit(limit).Find(&users).Error
+	return users, total, err
+}
+
+func (r gormUsers) ListByRoleForUpdate(role string) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("role = ? AND erased_at IS NULL", role).Order("id").Find(&users).Error
+	return users, err
+}
+
+func (r gormUsers) EmailTaken(email string, exceptID uint) (bool, error) {
+	var taken int64
+	err := r.db.Model(&models.User{}).Where("LOWER(email) = LOWER(?) AND id <> ?", email, exceptID).Count(&taken).Error
+	return taken > 0, err
+}
+
+func (r gormUsers) Update(user *models.User, fields ...string) error {
+	return translate(r.db.Model(user).Select(fields).Updates(user).Error)
+}
+
+func (r gormUsers) CreateEmailVerification(verification *models.EmailVerification) error {
+	return translate(r.db.Create(verification).Error)
+}
+
+func (r gormUsers) ListEmailVerifications(userID uint, since time.Time) ([]models.EmailVerification, error) {
+	var verifications []models.EmailVerification
+	err := r.db.Where("user_id = ? AND created_at > ?", userID, since).Order("created_at desc").Find(&verifications).Error
+	return verifications, err
+}
+
+type gormAccounts struct {
+	db *gorm.DB
+}
+
+func (r gormAccounts) Create(account *models.BankAccount) error {
+	return translate(r.db.Create(account).Error)
+}
+
+func (r gormAccounts) Get(accountNumber string) (models.BankAccount, error) {
+	var account models.BankAccount
+	err := r.db.Where("account_number = ?", accountNumber).First(&account).Error
+	return account, translate(err)
+}
+
+func (r gormAccounts) GetForUpdate(accountNumber string) (models.BankAccount, error) {
+	var account models.BankAccount
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("account_number = ?", accountNumber).First(&account).Error
+	return account, translate(err)
+}
+
+func (r gormAccounts) ListForMember(userID uint) ([]models.BankAccount, error) {
+	var accounts []models.BankAccount
+	err := r.db.Where("account_number IN (?)", r.memberAccountNumbers(userID)).Find(&accounts).Error
+	return accounts, err
+}
+
+func (r gormAccounts) ListForMemberByNumbers(userID uint, accountNumbers []string) ([]models.BankAccount, error) {
+	var accounts []models.BankAccount
+	err := r.db.Where("account_number IN ?", accountNumbers).
+		Where("account_number IN (?)", r.memberAccountNumbers(userID)).
+		Find(&accounts).Error
+	return accounts, err
+}
+
+// memberAccountNumbers is a subquery selecting the accounts a user is an
+// active holder of.
+func (r gormAccounts) memberAccountNumbers(userID uint) *gorm.DB {
+	return r.db.Model(&models.AccountMember{}).Select("account_number").Where("user_id = ? AND accepted_at IS NOT NULL", userID)
+}
+
+func (r gormAccounts) Save(account *models.BankAccount) error {
+	return translate(r.db.Save(account).Error)
+}
+
+func (r gormAccounts) CreateMember(member *models.AccountMember) error {
+	return translate(r.db.Create(member).Error)
+}
+
+func (r gormAccounts) GetMember(accountNumber string, userID uint) (models.AccountMember, error) {
+	var member models.AccountMember
+	err := r.db.Where("account_number = ? AND user_id = ?", accountNumber, userID).First(&member).Error
+	return member, translate(err)
+}
+
+func (r gormAccounts) ListMembers(accountNumber string) ([]models.AccountMember, error) {
+	var members []models.AccountMember
+	err := r.db.Where("account_number = ?", accountNumber).Order("id").Find(&members).Error
+	return members, err
+}
+
+func (r gormAccounts) ListInvitations(userID uint) ([]models.AccountMember, error) {
+	var invitations []models.AccountMember
+	err := r.db.Where("user_id = ? AND accepted_at IS NULL", userID).Find(&invitations).Error
+	return invitations, err
+}
+
+func (r gormAccounts) UpdateMember(member *models.AccountMember, fields ...string) error {
+	return translate(r.db.Model(member).Select(fields).Updates(member).Error)
+}
+
+func (r gormAccounts) DeleteMember(member *models.AccountMember) error {
+	return r.db.Unscoped().Delete(member).Error
+}
+
+type gormTransactions struct {
+	db *gorm.DB
+}
+
+func (r gormTransactions) Create(transaction *models.Transaction) error {
+	return translate(r.db.Create(transaction).Error)
+}
+
+func (r gormTransactions) Get(transactionID string) (models.Transaction, error) {
+	var transaction models.Transaction
+	err := r.db.Where("transaction_id = ?", transactionID).First(&transaction).Error
+	return transaction, translate(err)
+}
+
+// Last uses Find rather than First, since an account with no transactions
+// yet is expected and should not be logged as a missing record.
+func (r gormTransactions) Last(accountNumber string) (models.Transaction, error) {
+	var last models.Transaction
+	res := r.db.Unscoped().Where("account_number = ?", accountNumber).Order("sequence desc").Limit(1).Find(&last)
+	if res.Error != nil {
+		return last, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return last, ErrNotFound
+	}
+	return last, nil
+}
+
+func (r gormTransactions) ListForAccount(accountNumber string, offset int, limit int) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	err := r.db.Where("account_number = ?", accountNumber).Order("created_at desc, id desc").
+		Offset(offset).Limit(limit).Find(&transactions).Error
+	return transactions, err
+}
+
+func (r gormTransactions) ListRecent(accountNumbers []string, limit int) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	err := r.db.Where("account_number IN ?", accountNumbers).Order("created_at desc").Limit(limit).Find(&transactions).Error
+	return transactions, err
+}
+
+func (r gormTransactions) ListRecentPerAccount(accountNumbers []string, limit int) ([]models.Transaction, error) {
+	ranked := r.db.Model(&models.Transaction{}).
+		Select("*, ROW_NUMBER() OVER (PARTITION BY account_number ORDER BY created_at DESC, id DESC) AS row_rank").
+		Where("account_number IN ?", accountNumbers)
+
+	var transactions []models.Transaction
+	err := r.db.Table("(?) AS ranked", ranked).
+		Where("row_rank <= ?", limit).
+		Order("account_number, row_rank").
+		Find(&transactions).Error
+	return transactions, err
+}
+
+type gormTransfers struct {
+	db *gorm.DB
+}
+
+func (r gormTransfers) Create(transfer *models.Transfer) error {
+	return translate(r.db.Create(transfer).Error)
+}
+
+func (r gormTransfers) Get(transactionID string) (models.Transfer, error) {
+	var transfer models.Transfer
+	err := r.db.Where("transaction_id = ?", transactionID).First(&transfer).Error
+	return transfer, translate(err)
+}
+
+func (r gormTransfers) GetForUpdate(transactionID string) (models.Transfer, error) {
+	var transfer models.Transfer
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("transaction_id = ?", transactionID).First(&transfer).Error
+	return transfer, translate(err)
+}
+
+func (r gormTransfers) Save(transfer *models.Transfer) error {
+	return translate(r.db.Save(transfer).Error)
+}
+
+func (r gormTransfers) ListForUser(userID uint, limit int) ([]models.Transfer, error) {
+	var transfers []models.Transfer
+	err := r.db.Where("sender_id = ? OR receiver_id = ?", userID, userID).
+		Order("created_at desc").
+		Limit(limit).
+		Find(&transfers).Error
+	return transfers, err
+}
+
+func (r gormTransfers) ListDue(status string, before time.Time) ([]models.Transfer, error) {
+	var transfers []models.Transfer
+	err := r.db.Where("status = ? AND expires_on < ?", status, before).Order("expires_on").Find(&transfers).Error
+	return transfers, err
+}
+
+// CountSentFrom relies on a transfer sharing its id with the sender's
+// transaction.
+func (r gormTransfers) CountSentFrom(accountNumber string, status string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Transfer{}).Where("status = ? AND transaction_id IN (?)", status,
+		r.db.Model(&models.Transaction{}).Select("transaction_id").Where("account_number = ?", accountNumber),
+	).Count(&count).Error
+	return count, err
+}
+
+type gormLogins struct {
+	db *gorm.DB
+}
+
+func (r gormLogins) CreateAttempt(attempt *models.LoginAttempt) error {
+	return translate(r.db.Create(attempt).Error)
+}
+
+func (r gormLogins) ListAttempts(email string, since time.Time) ([]models.LoginAttempt, error) {
+	var attempts []models.LoginAttempt
+	err := r.db.Where("email = ? AND created_at > ?", email, since).Order("created_at desc").Find(&attempts).Error
+	return attempts, err
+}
+
+func (r gormLogins) CountFailedAttemptsFromIP(ip string, since time.Time) (int64, error) {
+	var failures int64
+	err := r.db.Model(&models.LoginAttempt{}).
+		Where("ip = ? AND success = ? AND created_at > ?", ip, false, since).
+		Count(&failures).Error
+	return failures, err
+}
+
+func (r gormLogins) CreateChallenge(challenge *models.LoginChallenge) error {
+	return translate(r.db.Create(challenge).Error)
+}
+
+func (r gormLogins) GetChallenge(challengeHash string) (models.LoginChallenge, error) {
+	var challenge models.LoginChallenge
+	err := r.db.Where("challenge_hash = ?", challengeHash).First(&challenge).Error
+	return challenge, translate(err)
+}
+
+func (r gormLogins) UpdateChallenge(challenge *models.LoginChallenge, fields ...string) error {
+	return translate(r.db.Model(challenge).Select(fields).Updates(challenge).Error)
+}
+
+func (r gormLogins) CountChallengeFailure(challengeHash string) error {
+	return r.db.Model(&models.LoginChallenge{}).
+		Where("challenge_hash = ? AND completed_at IS NULL", challengeHash).
+		Update("attempts", gorm.Expr("attempts + 1")).Error
+}
+
+func (r gormLogins) DeletePendingChallenges(userID uint) error {
+	return r.db.Where("user_id = ? AND completed_at IS NULL", userID).Delete(&models.LoginChallenge{}).Error
+}
+
+func (r gormLogins) CreateUnlockToken(token *models.LoginUnlockToken) error {
+	return translate(r.db.Create(token).Error)
+}
+
+func (r gormLogins) UseUnlockToken(tokenHash string, now time.Time) (models.LoginUnlockToken, error) {
+	var token models.LoginUnlockToken
+	res := r.db.Model(&models.LoginUnlockToken{}).
+		Where("token_hash = ? AND used_at IS NULL AND expires_on > ?", tokenHash, now).
+		Update("used_at", now)
+	if res.Error != nil {
+		return token, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return token, ErrNotFound
+	}
+
+	err := r.db.Where("token_hash = ?", tokenHash).First(&token).Error
+	return token, translate(err)
+}
+
+func (r gormLogins) RevokeAPIKeys(userID uint, at time.Time) (int64, error) {
+	res := r.db.Model(&models.APIKey{}).Where("user_id = ? AND revoked_at IS NULL", userID).Update("revoked_at", &at)
+	return res.RowsAffected, res.Error
+}
+
+func (r gormLogins) UseRecoveryCode(userID uint, codeHash string) (bool, error) {
+	res := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r gormLogins) UseTOTPStep(userID uint, step int64) (bool, error) {
+	res := r.db.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	return res.RowsAffected > 0, res.Error
+}
+
+type gormJournal struct {
+	db *gorm.DB
+}
+
+func (r gormJournal) CreateAuditLog(entry *models.AuditLog) error {
+	return translate(r.db.Create(entry).Error)
+}
+
+func (r gormJournal) CreateOutboxEvent(event *models.OutboxEvent) error {
+	return translate(r.db.Create(event).Error)
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/database"
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSQLiteStore(t *testing.T) *GormStore {
+	t.Setenv("SQLITE_PATH", filepath.Join(t.TempDir(), "test.db"))
+	db, err := database.Open(database.DRIVER_SQLITE)
+	assert.NoError(t, err)
+	assert.NoError(t, database.MigrateDB(db))
+
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+	return NewGormStore(db)
+}
+
+// The database must report violations the way MemoryStore does.
+func TestGormTranslatesConstraintViolations(t *testing.T) {
+	store := newSQLiteStore(t)
+	user := models.User{Email: "user@example.com", Role: models.ROLE_CUSTOMER}
+	assert.NoError(t, store.Users().Create(&user))
+
+	assert.ErrorIs(t, store.Users().Create(&models.User{Email: "user@example.com", Role: models.ROLE_CUSTOMER}), ErrDuplicate)
+	assert.ErrorIs(t, store.Users().Create(&models.User{Email: "other@example.com", Role: "root"}), ErrInvalidValue)
+	assert.ErrorIs(t, store.Accounts().Create(&models.BankAccount{AccountNumber: "1234", UserID: 999}), ErrMissingReference)
+
+	account := models.BankAccount{AccountNumber: "1234", UserID: user.ID}
+	assert.NoError(t, store.Accounts().Create(&account))
+
+	account.Balance = -1
+	var violation *ConstraintError
+	assert.ErrorAs(t, store.Accounts().Save(&account), &violation)
+	assert.Equal(t, CHECK_ACCOUNT_BALANCE, violation.Constraint)
+
+	err := store.Transactions().Create(&models.Transaction{AccountNumber: "1234", TransactionID: "t1", Type: "DEPOSIT", Amount: 0})
+	assert.ErrorAs(t, err, &violation)
+	assert.Equal(t, CHECK_TRANSACTION_AMOUNT, violation.Constraint)
+
+	err = store.Transactions().Create(&models.Transaction{AccountNumber: "missing", TransactionID: "t2", Type: "DEPOSIT", Amount: 5})
+	assert.ErrorIs(t, err, ErrMissingReference)
+
+	err = store.Transfers().Create(&models.Transfer{SenderID: user.ID, ReceiverID: 999, Amount: 5, Status: "PENDING", TransactionID: "t3"})
+	assert.ErrorIs(t, err, ErrMissingReference)
+}
+
+func TestGormEmailsIgnoreCase(t *testing.T) {
+	store := newSQLiteStore(t)
+	user := models.User{Email: "user@example.com", Role: models.ROLE_CUSTOMER}
+	assert.NoError(t, store.Users().Create(&user))
+
+	found, err := store.Users().GetByEmail(" User@Example.COM")
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+
+	// The unique index is on LOWER(email), so writes that skip normalising
+	// cannot create a second account for the same address either.
+	assert.ErrorIs(t, store.Users().Create(&models.User{Email: "USER@example.com", Role: models.ROLE_CUSTOMER}), ErrDuplicate)
+}
+
+func TestGormTransactionQueries(t *testing.T) {
+	store := newSQLiteStore(t)
+	sender := models.User{Email: "sender@example.com", Role: models.ROLE_CUSTOMER}
+	receiver := models.User{Email: "receiver@example.com", Role: models.ROLE_CUSTOMER}
+	assert.NoError(t, store.Users().Create(&sender))
+	assert.NoError(t, store.Users().Create(&receiver))
+	assert.NoError(t, store.Accounts().Create(&models.BankAccount{AccountNumber: "1234", UserID: sender.ID}))
+
+	_, err := store.Transactions().Last("1234")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.NoError(t, store.Transactions().Create(&models.Transaction{AccountNumber: "1234", TransactionID: "t1", Type: "TRANSFER", Amount: 5, Sequence: 1}))
+	last, err := store.Transactions().Last("1234")
+	assert.NoError(t, err)
+	assert.Equal(t, "t1", last.TransactionID)
+
+	now := time.Now()
+	assert.NoError(t, store.Transfers().Create(&models.Transfer{SenderID: sender.ID, ReceiverID: receiver.ID, Amount: 5,
+		Status: "PENDING", TransactionID: "t1", ExpiresOn: now.Add(-time.Minute)}))
+
+	count, err := store.Transfers().CountSentFrom("1234", "PENDING")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	due, err := store.Transfers().ListDue("PENDING", now)
+	assert.NoError(t, err)
+	assert.Len(t, due, 1)
+
+	due, err = store.Transfers().ListDue("PENDING", now.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Empty(t, due)
+}
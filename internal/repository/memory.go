@@ -0,0 +1,796 @@
+package repository
+
+import (
+	"cmp"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+)
+
+// MemoryStore keeps every repository in memory, for unit tests. Transactions
+// work on a copy of the data that replaces it on commit; they hold the
+// store's lock until then, so they run one at a time.
+type MemoryStore struct {
+	mu   *sync.Mutex
+	data *memoryData
+}
+
+type memoryData struct {
+	nextID        uint
+	users         []models.User
+	verifications []models.EmailVerification
+	accounts      []models.BankAccount
+	members       []models.AccountMember
+	transactions  []models.Transaction
+	transfers     []models.Transfer
+	attempts      []models.LoginAttempt
+	challenges    []models.LoginChallenge
+	recoveryCodes []models.RecoveryCode
+	unlockTokens  []models.LoginUnlockToken
+	apiKeys       []models.APIKey
+	auditLogs     []models.AuditLog
+	outboxEvents  []models.OutboxEvent
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{mu: &sync.Mutex{}, data: &memoryData{}}
+}
+
+func (s *MemoryStore) Users() UserRepository               { return memoryUsers{s} }
+func (s *MemoryStore) Accounts() AccountRepository         { return memoryAccounts{s} }
+func (s *MemoryStore) Transactions() TransactionRepository { return memoryTransactions{s} }
+func (s *MemoryStore) Transfers() TransferRepository       { return memoryTransfers{s} }
+func (s *MemoryStore) Logins() LoginRepository             { return memoryLogins{s} }
+func (s *MemoryStore) Journal() JournalRepository          { return memoryJournal{s} }
+
+func (s *MemoryStore) Transaction(fn func(tx Store) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := &MemoryStore{mu: &sync.Mutex{}, data: s.data.clone()}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	s.data = tx.data
+	return nil
+}
+
+// AddRecoveryCode stores an unused recovery code for a user.
+func (s *MemoryStore) AddRecoveryCode(userID uint, codeHash string) {
+	s.write(func(d *memoryData) {
+		code := models.RecoveryCode{UserID: userID, CodeHash: codeHash}
+		d.stamp(&code.GormModel)
+		d.recoveryCodes = append(d.recoveryCodes, code)
+	})
+}
+
+// AddAPIKey stores an API key for a user.
+func (s *MemoryStore) AddAPIKey(key *models.APIKey) {
+	s.write(func(d *memoryData) {
+		d.stamp(&key.GormModel)
+		d.apiKeys = append(d.apiKeys, *key)
+	})
+}
+
+// APIKeys returns the stored API keys.
+func (s *MemoryStore) APIKeys() []models.APIKey {
+	return read(s, func(d *memoryData) []models.APIKey { return slices.Clone(d.apiKeys) })
+}
+
+func (s *MemoryStore) AuditLogs() []models.AuditLog {
+	return read(s, func(d *memoryData) []models.AuditLog { return slices.Clone(d.auditLogs) })
+}
+
+func (s *MemoryStore) OutboxEvents() []models.OutboxEvent {
+	return read(s, func(d *memoryData) []models.OutboxEvent { return slices.Clone(d.outboxEvents) })
+}
+
+func (s *MemoryStore) write(fn func(d *memoryData)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(s.data)
+}
+
+func read[T any](s *MemoryStore, fn func(d *memoryData) T) T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fn(s.data)
+}
+
+func (d *memoryData) clone() *memoryData {
+	return &memoryData{
+		nextID:        d.nextID,
+		users:         slices.Clone(d.users),
+		verifications: slices.Clone(d.verifications),
+		accounts:      slices.Clone(d.accounts),
+		members:       slices.Clone(d.members),
+		transactions:  slices.Clone(d.transactions),
+		transfers:     slices.Clone(d.transfers),
+		attempts:      slices.Clone(d.attempts),
+		challenges:    slices.Clone(d.challenges),
+		recoveryCodes: slices.Clone(d.recoveryCodes),
+		unlockTokens:  slices.Clone(d.unlockTokens),
+		apiKeys:       slices.Clone(d.apiKeys),
+		auditLogs:     slices.Clone(d.auditLogs),
+		outboxEvents:  slices.Clone(d.outboxEvents),
+	}
+}
+
+// stamp assigns a new row its ID and timestamps, as the database would.
+func (d *memoryData) stamp(m *models.GormModel) {
+	d.nextID++
+	m.ID = d.nextID
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = time.Now()
+	}
+	m.UpdatedAt = m.CreatedAt
+}
+
+// find returns the first row matching, or ErrNotFound.
+func find[T any](rows []T, match func(row *T) bool) (T, error) {
+	for i := range rows {
+		if match(&rows[i]) {
+			return rows[i], nil
+		}
+	}
+
+	var zero T
+	return zero, ErrNotFound
+}
+
+// page returns the rows between offset and offset+limit.
+func page[T any](rows []T, offset int, limit int) []T {
+	offset = min(max(offset, 0), len(rows))
+	return rows[offset:min(len(rows), offset+limit)]
+}
+
+func filter[T any](rows []T, match func(row *T) bool) []T {
+	matches := []T{}
+	for i := range rows {
+		if match(&rows[i]) {
+			matches = append(matches, rows[i])
+		}
+	}
+	return matches
+}
+
+// replace overwrites the row with the same ID as row, or adds it when there
+// is none.
+func replace[T any](rows []T, row T, id func(row *T) uint) []T {
+	for i := range rows {
+		if id(&rows[i]) == id(&row) {
+			rows[i] = row
+			return rows
+		}
+	}
+	return append(rows, row)
+}
+
+// copyFields sets the named fields of dst to their values in src.
+func copyFields[T any](dst *T, src *T, fields []string) {
+	to, from := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem()
+	for _, field := range fields {
+		to.FieldByName(field).Set(from.FieldByName(field))
+	}
+}
+
+func newestFirst(a, b models.GormModel) int {
+	return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+}
+
+type memoryUsers struct {
+	s *MemoryStore
+}
+
+func (r memoryUsers) Create(user *models.User) error {
+	var err error
+	r.s.write(func(d *memoryData) {
+		if err = d.checkUser(user); err != nil {
+			return
+		}
+
+		d.stamp(&user.GormModel)
+		if user.Role == "" {
+			user.Role = models.ROLE_CUSTOMER
+		}
+		d.users = append(d.users, *user)
+	})
+	return err
+}
+
+func (r memoryUsers) Get(id uint) (models.User, error) {
+	return readRow(r.s, func(d *memoryData) []models.User { return d.users }, func(u *models.User) bool { return u.ID == id })
+}
+
+func (r memoryUsers) GetByEmail(email string) (models.User, error) {
+	email = strings.TrimSpace(email)
+	return readRow(r.s, func(d *memoryData) []models.User { return d.users }, func(u *models.User) bool { return strings.EqualFold(u.Email, email) })
+}
+
+func (r memoryUsers) ListByIDs(ids []uint) ([]models.User, error) {
+	return readRows(r.s, func(d *memoryData) []models.User { return d.users }, func(u *models.User) bool { return slices.Contains(ids, u.ID) }), nil
+}
+
+func (r memoryUsers) List(email string, offset int, limit int) ([]models.User, int64, error) {
+	users := readRows(r.s, func(d *memoryData) []models.User { return d.users }, func(u *models.User) bool {
+		return strings.Contains(u.Email, email)
+	})
+	slices.SortFunc(users, func(a, b models.User) int { return cmp.Compare(a.ID, b.ID) })
+	return page(users, offset, limit), int64(len(users)), nil
+}
+
+func (r memoryUsers) ListByRoleForUpdate(role string) ([]models.User, error) {
+	return readRows(r.s, func(d *memoryData) []models.User { return d.users }, func(u *models.User) bool {
+		return u.Role == role && !u.IsErased()
+	}), nil
+}
+
+func (r memoryUsers) EmailTaken(email string, exceptID uint) (bool, error) {
+	_, err := readRow(r.s, func(d *memoryData) []models.User { return d.users }, func(u *models.User) bool {
+		return strings.EqualFold(u.Email, email) && u.ID != exceptID
+	})
+	return err == nil, nil
+}
+
+func (r memoryUsers) Update(user *models.User, fields ...string) error {
+	var err error
+	r.s.write(func(d *memoryData) {
+		i := slices.IndexFunc(d.users, func(u models.User) bool { return u.ID == user.ID })
+		if i < 0 {
+			err = ErrNotFound
+			return
+		}
+
+		updated := d.users[i]
+		copyFields(&updated, user, fields)
+		if err = d.checkUser(&updated); err != nil {
+			return
+		}
+
+		d.users[i] = updated
+		d.users[i].UpdatedAt = time.Now()
+		user.UpdatedAt = d.users[i].UpdatedAt
+	})
+	return err
+}
+
+func (r memoryUsers) CreateEmailVerification(verification *models.EmailVerification) error {
+	r.s.write(func(d *memoryData) {
+		d.stamp(&verification.GormModel)
+		d.verifications = append(d.verifications, *verification)
+	})
+	return nil
+}
+
+func (r memoryUsers) ListEmailVerifications(userID uint, since time.Time) ([]models.EmailVerification, error) {
+	verifications := readRows(r.s, func(d *memoryData) []models.EmailVerification { return d.verifications }, func(v *models.EmailVerification) bool {
+		return v.UserID == userID && v.CreatedAt.After(since)
+	})
+	slices.SortFunc(verifications, func(a, b models.EmailVerification) int { return newestFirst(a.GormModel, b.GormModel) })
+	return verifications, nil
+}
+
+type memoryAccounts struct {
+	s *MemoryStore
+}
+
+func (r memoryAccounts) Create(account *models.BankAccount) error {
+	var err error
+	r.s.write(func(d *memoryData) {
+		if err = d.checkAccount(account); err != nil {
+			return
+		}
+
+		d.stamp(&account.GormModel)
+		d.accounts = append(d.accounts, *account)
+	})
+	return err
+}
+
+func (r memoryAccounts) Get(accountNumber string) (models.BankAccount, error) {
+	return readRow(r.s, func(d *memoryData) []models.BankAccount { return d.accounts }, func(a *models.BankAccount) bool {
+		return a.AccountNumber == accountNumber
+	})
+}
+
+// GetForUpdate needs no lock of its own, since transactions already run one
+// at a time.
+func (r memoryAccounts) GetForUpdate(accountNumber string) (models.BankAccount, error) {
+	return r.Get(accountNumber)
+}
+
+func (r memoryAccounts) ListForMember(userID uint) ([]models.BankAccount, error) {
+	return read(r.s, func(d *memoryData) []models.BankAccount {
+		return filter(d.accounts, func(a *models.BankAccount) bool { return d.isActiveMember(a.AccountNumber, userID) })
+	}), nil
+}
+
+func (r memoryAccounts) ListForMemberByNumbers(userID uint, accountNumbers []string) ([]models.BankAccount, error) {
+	return read(r.s, func(d *memoryData) []models.BankAccount {
+		return filter(d.accounts, func(a *models.BankAccount) bool {
+			return slices.Contains(accountNumbers, a.AccountNumber) && d.isActiveMember(a.AccountNumber, userID)
+		})
+	}), nil
+}
+
+func (d *memoryData) isActiveMember(accountNumber string, userID uint) bool {
+	return slices.ContainsFunc(d.members, func(m models.AccountMember) bool {
+		return m.AccountNumber == accountNumber && m.UserID == userID && m.IsActive()
+	})
+}
+
+func (r memoryAccounts) Save(account *models.BankAccount) error {
+	var err error
+	r.s.write(func(d *memoryData) {
+		if err = d.checkAccount(account); err != nil {
+			return
+		}
+
+		if account.ID == 0 {
+			d.stamp(&account.GormModel)
+		} else {
+			account.UpdatedAt = time.Now()
+		}
+		d.accounts = replace(d.accounts, *account, func(a *models.BankAccount) uint { return a.ID })
+	})
+	return err
+}
+
+func (r memoryAccounts) CreateMember(member *models.AccountMember) error {
+	var err error
+	r.s.write(func(d *memoryData) {
+		if err = d.checkMember(member); err != nil {
+			return
+		}
+
+		d.stamp(&member.GormModel)
+		d.members = append(d.members, *member)
+	})
+	return err
+}
+
+func (r memoryAccounts) GetMember(accountNumber string, userID uint) (models.AccountMember, error) {
+	return readRow(r.s, func(d *memoryData) []models.AccountMember { return d.members }, func(m *models.AccountMember) bool {
+		return m.AccountNumber == accountNumber && m.UserID == userID
+	})
+}
+
+func (r memoryAccounts) ListMembers(accountNumber string) ([]models.AccountMember, error) {
+	return readRows(r.s, func(d *memoryData) []models.AccountMember { return d.members }, func(m *models.AccountMember) bool {
+		return m.AccountNumber == accountNumber
+	}), nil
+}
+
+func (r memoryAccounts) ListInvitations(userID uint) ([]models.AccountMember, error) {
+	return readRows(r.s, func(d *memoryData) []models.AccountMember { return d.members }, func(m *models.AccountMember) bool {
+		return m.UserID == userID && !m.IsActive()
+	}), nil
+}
+
+func (r memoryAccounts) UpdateMember(member *models.AccountMember, fields ...string) error {
+	var err error
+	r.s.write(func(d *memoryData) {
+		i := slices.IndexFunc(d.members, func(m models.AccountMember) bool { return m.ID == member.ID })
+		if i < 0 {
+			err = ErrNotFound
+			return
+		}
+
+		updated := d.members[i]
+		copyFields(&updated, member, fields)
+		if err = d.checkMember(&updated); err != nil {
+			return
+		}
+
+		d.members[i] = updated
+		d.members[i].UpdatedAt = time.Now()
+		member.UpdatedAt = d.members[i].UpdatedAt
+	})
+	return err
+}
+
+func (r memoryAccounts) DeleteMember(member *models.AccountMember) error {
+	r.s.write(func(d *memoryData) {
+		d.members = slices.DeleteFunc(d.members, func(m models.AccountMember) bool { return m.ID == member.ID })
+	})
+	return nil
+}
+
+type memoryTransactions struct {
+	s *MemoryStore
+}
+
+func (r memoryTransactions) Create(transaction *models.Transaction) error {
+	var err error
+	r.s.write(func(d *memoryData) {
+		if err = d.checkTransaction(transaction); err != nil {
+			return
+		}
+
+		d.stamp(&transaction.GormModel)
+		d.transactions = append(d.transactions, *transaction)
+	})
+	return err
+}
+
+func (r memoryTransactions) Get(transactionID string) (models.Transaction, error) {
+	return readRow(r.s, func(d *memoryData) []models.Transaction { return d.transactions }, func(t *models.Transaction) bool {
+		return t.TransactionID == transactionID
+	})
+}
+
+func (r memoryTransactions) Last(accountNumber string) (models.Transaction, error) {
+	chain := readRows(r.s, func(d *memoryData) []models.Transaction { return d.transactions }, func(t *models.Transaction) bool {
+		return t.AccountNumber == accountNumber
+	})
+	if len(chain) == 0 {
+		return models.Transaction{}, ErrNotFound
+	}
+	return slices.MaxFunc(chain, func(a, b models.Transaction) int { return cmp.Compare(a.Sequence, b.Sequence) }), nil
+}
+
+func (r memoryTransactions) ListForAccount(accountNumber string, offset int, limit int) ([]models.Transaction, error) {
+	transactions := readRows(r.s, func(d *memoryData) []models.Transaction { return d.transactions }, func(t *models.Transaction) bool {
+		return t.AccountNumber == accountNumber
+	})
+	slices.SortFunc(transactions, func(a, b models.Transaction) int { return newestFirst(a.GormModel, b.GormModel) })
+	return page(transactions, offset, limit), nil
+}
+
+func (r memoryTransactions) ListRecent(accountNumbers []string, limit int) ([]models.Transaction, error) {
+	transactions := readRows(r.s, func(d *memoryData) []models.Transaction { return d.transactions }, func(t *models.Transaction) bool {
+		return slices.Contains(accountNumbers, t.AccountNumber)
+	})
+	slices.SortFunc(transactions, func(a, b models.Transaction) int { return newestFirst(a.GormModel, b.GormModel) })
+	return transactions[:min(len(transactions), limit)], nil
+}
+
+func (r memoryTransactions) ListRecentPerAccount(accountNumbers []string, limit int) ([]models.Transaction, error) {
+	transactions := readRows(r.s, func(d *memoryData) []models.Transaction { return d.transactions }, func(t *models.Transaction) bool {
+		return slices.Contains(accountNumbers, t.AccountNumber)
+	})
+	slices.SortFunc(transactions, func(a, b models.Transaction) int {
+		return cmp.Or(strings.Compare(a.AccountNumber, b.AccountNumber), newestFirst(a.GormModel, b.GormModel))
+	})
+
+	recent, taken := []models.Transaction{}, map[string]int{}
+	for _, transaction := range transactions {
+		if taken[transaction.AccountNumber] < limit {
+			recent = append(recent, transaction)
+			taken[transaction.AccountNumber]++
+		}
+	}
+	return recent, nil
+}
+
+type memoryTransfers struct {
+	s *MemoryStore
+}
+
+func (r memoryTransfers) Create(transfer *models.Transfer) error {
+	var err error
+	r.s.write(func(d *memoryData) {
+		if err = d.checkTransfer(transfer); err != nil {
+			return
+		}
+
+		d.stamp(&transfer.GormModel)
+		d.transfers = append(d.transfers, *transfer)
+	})
+	return err
+}
+
+func (r memoryTransfers) Get(transactionID string) (models.Transfer, error) {
+	return readRow(r.s, func(d *memoryData) []models.Transfer { return d.transfers }, func(t *models.Transfer) bool {
+		return t.TransactionID == transactionID
+	})
+}
+
+func (r memoryTransfers) GetForUpdate(transactionID string) (models.Transfer, error) {
+	return r.Get(transactionID)
+}
+
+func (r memoryTransfers) Save(transfer *models.Transfer) error {
+	var err error
+	r.s.write(func(d *memoryData) {
+		if err = d.checkTransfer(transfer); err != nil {
+			return
+		}
+
+		if transfer.ID == 0 {
+			d.stamp(&transfer.GormModel)
+		} else {
+			transfer.UpdatedAt = time.Now()
+		}
+		d.transfers = replace(d.transfers, *transfer, func(t *models.Transfer) uint { return t.ID })
+	})
+	return err
+}
+
+func (r memoryTransfers) ListForUser(userID uint, limit int) ([]models.Transfer, error) {
+	transfers := readRows(r.s, func(d *memoryData) []models.Transfer { return d.transfers }, func(t *models.Transfer) bool {
+		return t.SenderID == userID || t.ReceiverID == userID
+	})
+	slices.SortFunc(transfers, func(a, b models.Transfer) int { return newestFirst(a.GormModel, b.GormModel) })
+	return transfers[:min(len(transfers), limit)], nil
+}
+
+func (r memoryTransfers) ListDue(status string, before time.Time) ([]models.Transfer, error) {
+	transfers := readRows(r.s, func(d *memoryData) []models.Transfer { return d.transfers }, func(t *models.Transfer) bool {
+		return t.Status == status && t.ExpiresOn.Before(before)
+	})
+	slices.SortFunc(transfers, func(a, b models.Transfer) int { return a.ExpiresOn.Compare(b.ExpiresOn) })
+	return transfers, nil
+}
+
+func (r memoryTransfers) CountSentFrom(accountNumber string, status string) (int64, error) {
+	return read(r.s, func(d *memoryData) int64 {
+		sent := filter(d.transfers, func(t *models.Transfer) bool {
+			return t.Status == status && slices.ContainsFunc(d.transactions, func(sent models.Transaction) bool {
+				return sent.TransactionID == t.TransactionID && sent.AccountNumber == accountNumber
+			})
+		})
+		return int64(len(sent))
+	}), nil
+}
+
+type memoryLogins struct {
+	s *MemoryStore
+}
+
+func (r memoryLogins) CreateAttempt(attempt *models.LoginAttempt) error {
+	r.s.write(func(d *memoryData) {
+		d.stamp(&attempt.GormModel)
+		d.attempts = append(d.attempts, *attempt)
+	})
+	return nil
+}
+
+func (r memoryLogins) ListAttempts(email string, since time.Time) ([]models.LoginAttempt, error) {
+	attempts := readRows(r.s, func(d *memoryData) []models.LoginAttempt { return d.attempts }, func(a *models.LoginAttempt) bool {
+		return a.Email == email && a.CreatedAt.After(since)
+	})
+	slices.SortFunc(attempts, func(a, b models.LoginAttempt) int { return newestFirst(a.GormModel, b.GormModel) })
+	return attempts, nil
+}
+
+func (r memoryLogins) CountFailedAttemptsFromIP(ip string, since time.Time) (int64, error) {
+	failures := readRows(r.s, func(d *memoryData) []models.LoginAttempt { return d.attempts }, func(a *models.LoginAttempt) bool {
+		return a.IP == ip && !a.Success && a.CreatedAt.After(since)
+	})
+	return int64(len(failures)), nil
+}
+
+func (r memoryLogins) CreateChallenge(challenge *models.LoginChallenge) error {
+	r.s.write(func(d *memoryData) {
+		d.stamp(&challenge.GormModel)
+		d.challenges = append(d.challenges, *challenge)
+	})
+	return nil
+}
+
+func (r memoryLogins) GetChallenge(challengeHash string) (models.LoginChallenge, error) {
+	return readRow(r.s, func(d *memoryData) []models.LoginChallenge { return d.challenges }, func(c *models.LoginChallenge) bool {
+		return c.ChallengeHash == challengeHash
+	})
+}
+
+func (r memoryLogins) UpdateChallenge(challenge *models.LoginChallenge, fields ...string) error {
+	var err error
+	r.s.write(func(d *memoryData) {
+		i := slices.IndexFunc(d.challenges, func(c models.LoginChallenge) bool { return c.ID == challenge.ID })
+		if i < 0 {
+			err = ErrNotFound
+			return
+		}
+
+		copyFields(&d.challenges[i], challenge, fields)
+		d.challenges[i].UpdatedAt = time.Now()
+		challenge.UpdatedAt = d.challenges[i].UpdatedAt
+	})
+	return err
+}
+
+func (r memoryLogins) CountChallengeFailure(challengeHash string) error {
+	r.s.write(func(d *memoryData) {
+		for i := range d.challenges {
+			if d.challenges[i].ChallengeHash == challengeHash && d.challenges[i].CompletedAt == nil {
+				d.challenges[i].Attempts++
+			}
+		}
+	})
+	return nil
+}
+
+func (r memoryLogins) DeletePendingChallenges(userID uint) error {
+	r.s.write(func(d *memoryData) {
+		d.challenges = slices.DeleteFunc(d.challenges, func(c models.LoginChallenge) bool {
+			return c.UserID == userID && c.CompletedAt == nil
+		})
+	})
+	return nil
+}
+
+func (r memoryLogins) CreateUnlockToken(token *models.LoginUnlockToken) error {
+	r.s.write(func(d *memoryData) {
+		d.stamp(&token.GormModel)
+		d.unlockTokens = append(d.unlockTokens, *token)
+	})
+	return nil
+}
+
+func (r memoryLogins) UseUnlockToken(tokenHash string, now time.Time) (models.LoginUnlockToken, error) {
+	var token models.LoginUnlockToken
+	err := ErrNotFound
+	r.s.write(func(d *memoryData) {
+		for i := range d.unlockTokens {
+			t := &d.unlockTokens[i]
+			if t.TokenHash == tokenHash && t.UsedAt == nil && t.ExpiresOn.After(now) {
+				t.UsedAt = &now
+				token, err = *t, nil
+			}
+		}
+	})
+	return token, err
+}
+
+func (r memoryLogins) RevokeAPIKeys(userID uint, at time.Time) (int64, error) {
+	var revoked int64
+	r.s.write(func(d *memoryData) {
+		for i := range d.apiKeys {
+			if key := &d.apiKeys[i]; key.UserID == userID && key.RevokedAt == nil {
+				key.RevokedAt = &at
+				revoked++
+			}
+		}
+	})
+	return revoked, nil
+}
+
+func (r memoryLogins) UseRecoveryCode(userID uint, codeHash string) (bool, error) {
+	used := false
+	r.s.write(func(d *memoryData) {
+		for i := range d.recoveryCodes {
+			code := &d.recoveryCodes[i]
+			if code.UserID == userID && code.CodeHash == codeHash && code.UsedAt == nil {
+				now := time.Now()
+				code.UsedAt = &now
+				used = true
+			}
+		}
+	})
+	return used, nil
+}
+
+func (r memoryLogins) UseTOTPStep(userID uint, step int64) (bool, error) {
+	used := false
+	r.s.write(func(d *memoryData) {
+		for i := range d.users {
+			user := &d.users[i]
+			if user.ID == userID && user.TOTPLastStep < step {
+				user.TOTPLastStep = step
+				used = true
+			}
+		}
+	})
+	return used, nil
+}
+
+type memoryJournal struct {
+	s *MemoryStore
+}
+
+func (r memoryJournal) CreateAuditLog(entry *models.AuditLog) error {
+	r.s.write(func(d *memoryData) {
+		d.nextID++
+		entry.ID, entry.CreatedAt = d.nextID, time.Now()
+		d.auditLogs = append(d.auditLogs, *entry)
+	})
+	return nil
+}
+
+func (r memoryJournal) CreateOutboxEvent(event *models.OutboxEvent) error {
+	r.s.write(func(d *memoryData) {
+		d.nextID++
+		event.ID = d.nextID
+		d.outboxEvents = append(d.outboxEvents, *event)
+	})
+	return nil
+}
+
+// The checks below mirror the constraints of the database schema, reporting
+// violations under the same names.
+
+func (d *memoryData) checkUser(user *models.User) error {
+	if slices.ContainsFunc(d.users, func(u models.User) bool { return u.Email == user.Email && u.ID != user.ID }) {
+		return &ConstraintError{Kind: ErrDuplicate, Constraint: "uni_users_email"}
+	}
+	return nil
+}
+
+func (d *memoryData) checkAccount(account *models.BankAccount) error {
+	if slices.ContainsFunc(d.accounts, func(a models.BankAccount) bool {
+		return a.AccountNumber == account.AccountNumber && a.ID != account.ID
+	}) {
+		return &ConstraintError{Kind: ErrDuplicate, Constraint: "uni_bank_accounts_account_number"}
+	}
+	if !d.hasUser(account.UserID) {
+		return &ConstraintError{Kind: ErrMissingReference, Constraint: "fk_bank_accounts_user"}
+	}
+	if account.Balance < 0 {
+		return &ConstraintError{Kind: ErrInvalidValue, Constraint: CHECK_ACCOUNT_BALANCE}
+	}
+	return nil
+}
+
+func (d *memoryData) checkMember(member *models.AccountMember) error {
+	if slices.ContainsFunc(d.members, func(m models.AccountMember) bool {
+		return m.AccountNumber == member.AccountNumber && m.UserID == member.UserID && m.ID != member.ID
+	}) {
+		return &ConstraintError{Kind: ErrDuplicate, Constraint: "idx_account_member"}
+	}
+	if !d.hasAccount(member.AccountNumber) {
+		return &ConstraintError{Kind: ErrMissingReference, Constraint: "fk_account_members_account"}
+	}
+	if !d.hasUser(member.UserID) {
+		return &ConstraintError{Kind: ErrMissingReference, Constraint: "fk_account_members_user"}
+	}
+	if member.Limit < 0 {
+		return &ConstraintError{Kind: ErrInvalidValue, Constraint: CHECK_MEMBER_LIMIT}
+	}
+	return nil
+}
+
+func (d *memoryData) checkTransaction(transaction *models.Transaction) error {
+	if slices.ContainsFunc(d.transactions, func(t models.Transaction) bool { return t.TransactionID == transaction.TransactionID }) {
+		return &ConstraintError{Kind: ErrDuplicate, Constraint: "uni_transactions_transaction_id"}
+	}
+	if !d.hasAccount(transaction.AccountNumber) {
+		return &ConstraintError{Kind: ErrMissingReference, Constraint: "fk_transactions_account"}
+	}
+	if transaction.Amount <= 0 {
+		return &ConstraintError{Kind: ErrInvalidValue, Constraint: CHECK_TRANSACTION_AMOUNT}
+	}
+	return nil
+}
+
+func (d *memoryData) checkTransfer(transfer *models.Transfer) error {
+	if slices.ContainsFunc(d.transfers, func(t models.Transfer) bool {
+		return t.TransactionID == transfer.TransactionID && t.ID != transfer.ID
+	}) {
+		return &ConstraintError{Kind: ErrDuplicate, Constraint: "uni_transfers_transaction_id"}
+	}
+	if !d.hasUser(transfer.SenderID) {
+		return &ConstraintError{Kind: ErrMissingReference, Constraint: "fk_transfers_sender"}
+	}
+	if !d.hasUser(transfer.ReceiverID) {
+		return &ConstraintError{Kind: ErrMissingReference, Constraint: "fk_transfers_receiver"}
+	}
+	if transfer.Amount <= 0 {
+		return &ConstraintError{Kind: ErrInvalidValue, Constraint: CHECK_TRANSFER_AMOUNT}
+	}
+	return nil
+}
+
+func (d *memoryData) hasUser(id uint) bool {
+	return slices.ContainsFunc(d.users, func(u models.User) bool { return u.ID == id })
+}
+
+func (d *memoryData) hasAccount(accountNumber string) bool {
+	return slices.ContainsFunc(d.accounts, func(a models.BankAccount) bool { return a.AccountNumber == accountNumber })
+}
+
+func readRow[T any](s *MemoryStore, rows func(d *memoryData) []T, match func(row *T) bool) (T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return find(rows(s.data), match)
+}
+
+func readRows[T any](s *MemoryStore, rows func(d *memoryData) []T, match func(row *T) bool) []T {
+	return read(s, func(d *memoryData) []T { return filter(rows(d), match) })
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryTransactionRollsBack(t *testing.T) {
+	store := NewMemoryStore()
+	user := models.User{Email: "user@example.com"}
+	assert.NoError(t, store.Users().Create(&user))
+	account := models.BankAccount{AccountNumber: "1234", UserID: user.ID, Balance: 10}
+	assert.NoError(t, store.Accounts().Create(&account))
+
+	err := store.Transaction(func(tx Store) error {
+		account.Balance = 0
+		assert.NoError(t, tx.Accounts().Save(&account))
+		assert.NoError(t, tx.Transactions().Create(&models.Transaction{AccountNumber: "1234", Amount: 10}))
+		return fmt.Errorf("rolled back")
+	})
+	assert.EqualError(t, err, "rolled back")
+
+	stored, _ := store.Accounts().Get("1234")
+	assert.Equal(t, 10.0, stored.Balance)
+	_, err = store.Transactions().Last("1234")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.NoError(t, store.Transaction(func(tx Store) error {
+		return tx.Accounts().Save(&account)
+	}))
+	stored, _ = store.Accounts().Get("1234")
+	assert.Equal(t, 0.0, stored.Balance)
+}
+
+func TestMemoryUpdateOnlyWritesNamedFields(t *testing.T) {
+	store := NewMemoryStore()
+	user := models.User{Email: "user@example.com", FirstName: "First", LastName: "Last"}
+	assert.NoError(t, store.Users().Create(&user))
+
+	changed := user
+	changed.FirstName, changed.LastName = "Changed", "Changed"
+	assert.NoError(t, store.Users().Update(&changed, "FirstName"))
+
+	stored, _ := store.Users().Get(user.ID)
+	assert.Equal(t, "Changed", stored.FirstName)
+	assert.Equal(t, "Last", stored.LastName)
+}
+
+func TestMemoryEnforcesConstraints(t *testing.T) {
+	store := NewMemoryStore()
+	user := models.User{Email: "user@example.com"}
+	assert.NoError(t, store.Users().Create(&user))
+
+	assert.ErrorIs(t, store.Users().Create(&models.User{Email: "user@example.com"}), ErrDuplicate)
+	assert.ErrorIs(t, store.Accounts().Create(&models.BankAccount{AccountNumber: "1234", UserID: 999}), ErrMissingReference)
+
+	account := models.BankAccount{AccountNumber: "1234", UserID: user.ID}
+	assert.NoError(t, store.Accounts().Create(&account))
+
+	account.Balance = -1
+	var violation *ConstraintError
+	assert.ErrorAs(t, store.Accounts().Save(&account), &violation)
+	assert.Equal(t, CHECK_ACCOUNT_BALANCE, violation.Constraint)
+
+	err := store.Transactions().Create(&models.Transaction{AccountNumber: "1234", TransactionID: "t1", Amount: 0})
+	assert.ErrorAs(t, err, &violation)
+	assert.Equal(t, CHECK_TRANSACTION_AMOUNT, violation.Constraint)
+
+	err = store.Transfers().Create(&models.Transfer{SenderID: user.ID, ReceiverID: 999, Amount: 5, TransactionID: "t2"})
+	assert.ErrorIs(t, err, ErrMissingReference)
+}
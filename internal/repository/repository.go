@@ -0,0 +1,144 @@
+// Package repository is the persistence layer behind the core services: bank,
+// users, logins and admin. Each repository covers one aggregate; a Store
+// groups them and runs transactions. GormStore is backed by the database and
+// MemoryStore keeps everything in memory for unit tests. Other services still
+// use GORM directly, writing only their journal entries through a GormStore.
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+)
+
+var ErrNotFound = errors.New("record not found")
+
+type Store interface {
+	Users() UserRepository
+	Accounts() AccountRepository
+	Transactions() TransactionRepository
+	Transfers() TransferRepository
+	Logins() LoginRepository
+	Journal() JournalRepository
+
+	// Transaction runs fn with a Store whose changes are committed together
+	// if fn returns nil and discarded otherwise.
+	Transaction(fn func(tx Store) error) error
+}
+
+type UserRepository interface {
+	Create(user *models.User) error
+	Get(id uint) (models.User, error)
+	// GetByEmail looks a user up by email address, ignoring case.
+	GetByEmail(email string) (models.User, error)
+	ListByIDs(ids []uint) ([]models.User, error)
+	// List returns a page of users ordered by ID, optionally filtered by an
+	// email substring, and the total number of matches.
+	List(email string, offset int, limit int) ([]models.User, int64, error)
+	// ListByRoleForUpdate returns the users with a role who have not been
+	// erased, locking them until the transaction ends.
+	ListByRoleForUpdate(role string) ([]models.User, error)
+	// EmailTaken reports whether a user other than exceptID has the
+	// address, ignoring case.
+	EmailTaken(email string, exceptID uint) (bool, error)
+	// Update saves the named fields of user, zero values included.
+	Update(user *models.User, fields ...string) error
+
+	CreateEmailVerification(verification *models.EmailVerification) error
+	// ListEmailVerifications returns the verifications sent to a user since
+	// a time, newest first.
+	ListEmailVerifications(userID uint, since time.Time) ([]models.EmailVerification, error)
+}
+
+// AccountRepository stores bank accounts and the users holding them.
+type AccountRepository interface {
+	Create(account *models.BankAccount) error
+	Get(accountNumber string) (models.BankAccount, error)
+	// GetForUpdate also locks the account until the transaction ends.
+	GetForUpdate(accountNumber string) (models.BankAccount, error)
+	// ListForMember returns the accounts the user is an active holder of.
+	ListForMember(userID uint) ([]models.BankAccount, error)
+	// ListForMemberByNumbers is ListForMember limited to the listed accounts.
+	ListForMemberByNumbers(userID uint, accountNumbers []string) ([]models.BankAccount, error)
+	Save(account *models.BankAccount) error
+
+	CreateMember(member *models.AccountMember) error
+	GetMember(accountNumber string, userID uint) (models.AccountMember, error)
+	ListMembers(accountNumber string) ([]models.AccountMember, error)
+	// ListInvitations returns the user's pending memberships.
+	ListInvitations(userID uint) ([]models.AccountMember, error)
+	// UpdateMember saves the named fields of member, zero values included.
+	UpdateMember(member *models.AccountMember, fields ...string) error
+	DeleteMember(member *models.AccountMember) error
+}
+
+type TransactionRepository interface {
+	Create(transaction *models.Transaction) error
+	Get(transactionID string) (models.Transaction, error)
+	// Last returns the end of an account's chain, deleted rows included.
+	Last(accountNumber string) (models.Transaction, error)
+	// ListForAccount returns a page of an account's transactions, newest
+	// first.
+	ListForAccount(accountNumber string, offset int, limit int) ([]models.Transaction, error)
+	// ListRecent returns the newest transactions across the accounts.
+	ListRecent(accountNumbers []string, limit int) ([]models.Transaction, error)
+	// ListRecentPerAccount returns up to limit of the newest transactions of
+	// each account, ordered by account and then newest first.
+	ListRecentPerAccount(accountNumbers []string, limit int) ([]models.Transaction, error)
+}
+
+type TransferRepository interface {
+	Create(transfer *models.Transfer) error
+	Get(transactionID string) (models.Transfer, error)
+	// GetForUpdate also locks the transfer until the transaction ends.
+	GetForUpdate(transactionID string) (models.Transfer, error)
+	Save(transfer *models.Transfer) error
+	// ListForUser returns the newest transfers the user sent or received.
+	ListForUser(userID uint, limit int) ([]models.Transfer, error)
+	// ListDue returns the transfers in a status that expire before a time,
+	// soonest first.
+	ListDue(status string, before time.Time) ([]models.Transfer, error)
+	// CountSentFrom counts the transfers in a status whose money left the
+	// account.
+	CountSentFrom(accountNumber string, status string) (int64, error)
+}
+
+// LoginRepository stores login attempts and two-factor state.
+type LoginRepository interface {
+	CreateAttempt(attempt *models.LoginAttempt) error
+	// ListAttempts returns the attempts against an email address since a
+	// time, newest first.
+	ListAttempts(email string, since time.Time) ([]models.LoginAttempt, error)
+	CountFailedAttemptsFromIP(ip string, since time.Time) (int64, error)
+
+	CreateChallenge(challenge *models.LoginChallenge) error
+	GetChallenge(challengeHash string) (models.LoginChallenge, error)
+	UpdateChallenge(challenge *models.LoginChallenge, fields ...string) error
+	// CountChallengeFailure adds a failed attempt to a pending challenge.
+	CountChallengeFailure(challengeHash string) error
+	DeletePendingChallenges(userID uint) error
+
+	CreateUnlockToken(token *models.LoginUnlockToken) error
+	// UseUnlockToken marks an unused unlock token that has not expired by
+	// now as used and returns it.
+	UseUnlockToken(tokenHash string, now time.Time) (models.LoginUnlockToken, error)
+
+	// RevokeAPIKeys revokes every active API key of a user, returning how
+	// many there were.
+	RevokeAPIKeys(userID uint, at time.Time) (int64, error)
+
+	// UseRecoveryCode marks an unused recovery code as used, reporting
+	// whether there was one.
+	UseRecoveryCode(userID uint, codeHash string) (bool, error)
+	// UseTOTPStep records step as the user's last accepted TOTP time step,
+	// reporting false if it is not newer than the one already recorded.
+	UseTOTPStep(userID uint, step int64) (bool, error)
+}
+
+// JournalRepository appends audit entries and outbox events, which are
+// written alongside the changes they describe and never modified.
+type JournalRepository interface {
+	CreateAuditLog(entry *models.AuditLog) error
+	CreateOutboxEvent(event *models.OutboxEvent) error
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/server/services"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+type AdminHandler struct {
+	adminService *services.AdminService
+}
+
+func NewAdminHandler(adminService *services.AdminService) *AdminHandler {
+	return &AdminHandler{adminService: adminService}
+}
+
+func (h *AdminHandler) HandleListUsers(c *gin.Context) {
+	page, pageSize := pagination(c)
+
+	users, total, err := h.adminService.ListUsers(c.Query("email"), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responses := make([]models.UserResponse, 0, len(users))
+	for _, user := range users {
+		responses = append(responses, user.Response())
+	}
+
+	c.JSON(http.StatusOK, gin.H{"users": responses, "total": total, "page": page, "pageSize": pageSize})
+}
+
+func (h *AdminHandler) HandleGetUser(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	user, err := h.adminService.GetUser(uint(userID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	accounts, err := h.adminService.GetUserAccounts(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user.Response(), "accounts": accounts})
+}
+
+func (h *AdminHandler) HandleUpdateRole(c *gin.Context) {
+	var request models.UpdateRole
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	user, err := h.adminService.UpdateRole(actor(c), uint(userID), request.Role)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user.Response())
+}
+
+func (h *AdminHandler) HandleUnlockLogin(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if err := h.adminService.UnlockLogin(actor(c), uint(userID)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account unlocked"})
+}
+
+func (h *AdminHandler) HandleAccountTransactions(c *gin.Context) {
+	page, pageSize := pagination(c)
+
+	transactions, err := h.adminService.GetAccountTransactions(c.Param("accountNumber"), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transactions": transactions, "page": page, "pageSize": pageSize})
+}
+
+func (h *AdminHandler) HandleFreezeAccount(c *gin.Context) {
+	h.setAccountFrozen(c, true)
+}
+
+func (h *AdminHandler) HandleUnfreezeAccount(c *gin.Context) {
+	h.setAccountFrozen(c, false)
+}
+
+func (h *AdminHandler) setAccountFrozen(c *gin.Context, frozen bool) {
+	account, err := h.adminService.SetAccountFrozen(actor(c), c.Param("accountNumber"), frozen)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+// pagination reads the page and pageSize query parameters, falling back to
+// the first page of defaultPageSize items.
+func pagination(c *gin.Context) (int, int) {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(c.Query("pageSize"))
+	if err != nil || pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	return page, pageSize
+}
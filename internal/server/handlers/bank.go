@@ -17,13 +17,13 @@ func NewBankHandler(bankService *services.BankService) *BankHandler {
 }
 
 func (h *BankHandler) HandleNewAccount(c *gin.Context) {
-	userID, hasKey := c.Get("userID")
+	_, hasKey := c.Get("userID")
 	if !hasKey {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
-	newAccount, err := h.bankService.CreateAccount(userID.(uint))
+	newAccount, err := h.bankService.CreateAccount(actor(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -56,13 +56,13 @@ func (s *BankHandler) HandleDeposit(c *gin.Context) {
 		return
 	}
 
-	userID, hasKey := c.Get("userID")
+	_, hasKey := c.Get("userID")
 	if !hasKey {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
-	account, err := s.bankService.DepositToAccount(deposit, userID.(uint))
+	account, err := s.bankService.DepositToAccount(deposit, actor(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -79,13 +79,13 @@ func (s *BankHandler) HandleWithdraw(c *gin.Context) {
 		return
 	}
 
-	userID, hasKey := c.Get("userID")
+	_, hasKey := c.Get("userID")
 	if !hasKey {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
-	account, err := s.bankService.WithdrawFromAccount(withdraw, userID.(uint))
+	account, err := s.bankService.WithdrawFromAccount(withdraw, actor(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -118,13 +118,13 @@ func (s *BankHandler) HandleSendTransfer(c *gin.Context) {
 		return
 	}
 
-	userID, hasKey := c.Get("userID")
+	_, hasKey := c.Get("userID")
 	if !hasKey {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
-	senderAccount, err := s.bankService.SendTransfer(transfer, userID.(uint))
+	senderAccount, err := s.bankService.SendTransfer(transfer, actor(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -141,13 +141,13 @@ func (s *BankHandler) HandleAcceptTransfer(c *gin.Context) {
 		return
 	}
 
-	userID, hasKey := c.Get("userID")
+	_, hasKey := c.Get("userID")
 	if !hasKey {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
-	userAccount, err := s.bankService.AcceptTransfer(acceptTransfer, userID.(uint))
+	userAccount, err := s.bankService.AcceptTransfer(acceptTransfer, actor(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
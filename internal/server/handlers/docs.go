@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/FaizanAC/Go-Banking/internal/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+// docsPage renders /openapi.json with Swagger UI, loaded from a CDN so no
+// assets have to be vendored.
+const docsPage = `<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<title>Go Banking API</title>
+	<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5.17.14/swagger-ui.css">
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="https://unpkg.com/swagger-ui-dist@5.17.14/swagger-ui-bundle.js" crossorigin></script>
+	<script>
+		window.onload = () => {
+			window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+		};
+	</script>
+</body>
+</html>
+`
+
+type DocsHandler struct {
+	spec *openapi.Document
+}
+
+func NewDocsHandler(spec *openapi.Document) *DocsHandler {
+	return &DocsHandler{spec: spec}
+}
+
+func (h *DocsHandler) HandleOpenAPI(c *gin.Context) {
+	c.JSON(http.StatusOK, h.spec)
+}
+
+func (h *DocsHandler) HandleDocs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsPage))
+}
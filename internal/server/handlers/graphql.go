@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/FaizanAC/Go-Banking/internal/graphqlapi"
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+type GraphQLHandler struct {
+	schema *graphqlapi.Schema
+}
+
+func NewGraphQLHandler(schema *graphqlapi.Schema) *GraphQLHandler {
+	return &GraphQLHandler{schema: schema}
+}
+
+// HandleGraphQL executes a query or mutation. As is usual for GraphQL,
+// errors from resolvers are returned in the body with a 200 status.
+func (h *GraphQLHandler) HandleGraphQL(c *gin.Context) {
+	var request graphqlapi.Request
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	value, _ := c.Get("user")
+	user, _ := value.(*models.User)
+
+	viewer := graphqlapi.Viewer{Actor: actor(c), User: user, Scopes: c.GetStringSlice("scopes")}
+	c.JSON(http.StatusOK, h.schema.Execute(c.Request.Context(), viewer, request))
+}
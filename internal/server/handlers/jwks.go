@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/FaizanAC/Go-Banking/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSHandler struct {
+	keySet *util.KeySet
+}
+
+func NewJWKSHandler(keySet *util.KeySet) *JWKSHandler {
+	return &JWKSHandler{keySet: keySet}
+}
+
+func (h *JWKSHandler) HandleJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keySet.JWKS())
+}
@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/FaizanAC/Go-Banking/internal/models"
 	"github.com/FaizanAC/Go-Banking/internal/server/services"
@@ -24,13 +27,83 @@ func (h *LoginHandler) HandleLogin(c *gin.Context) {
 		return
 	}
 
-	jwtToken, err := h.loginService.Login(login)
+	result, err := h.loginService.Login(login, clientInfo(c))
+	if err != nil {
+		var throttled *services.ThrottledError
+		switch {
+		case errors.As(err, &throttled):
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidCredentials):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if result.ChallengeID != "" {
+		c.JSON(http.StatusAccepted, gin.H{"twoFactorRequired": true, "challengeId": result.ChallengeID})
+		return
+	}
+
+	setTokenCookie(c, result.Token)
+	c.JSON(http.StatusOK, gin.H{"message": "Login Successful"})
+}
+
+func (h *LoginHandler) HandleTwoFactorLogin(c *gin.Context) {
+	var twoFactorLogin models.TwoFactorLogin
+
+	if err := c.ShouldBindJSON(&twoFactorLogin); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	jwtToken, err := h.loginService.CompleteTwoFactorLogin(twoFactorLogin, clientInfo(c))
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
+	setTokenCookie(c, jwtToken)
+	c.JSON(http.StatusOK, gin.H{"message": "Login Successful"})
+}
+
+// HandleUnlockPage is where the emailed unlock link lands.
+func (h *LoginHandler) HandleUnlockPage(c *gin.Context) {
+	renderPage(c, http.StatusOK, tokenFormTemplate, tokenForm{
+		Title:  "Unlock your account",
+		Action: "/login/unlock",
+		Submit: "Unlock",
+		Token:  c.Query("token"),
+	})
+}
+
+func (h *LoginHandler) HandleUnlock(c *gin.Context) {
+	var unlock models.UnlockLogin
+	if err := c.ShouldBind(&unlock); err != nil {
+		respond(c, http.StatusBadRequest, "Unlock failed", "error", err.Error())
+		return
+	}
+
+	if err := h.loginService.Unlock(unlock.Token, clientInfo(c)); err != nil {
+		respond(c, http.StatusBadRequest, "Unlock failed", "error", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, "Account unlocked", "message", "Account unlocked")
+}
+
+func clientInfo(c *gin.Context) models.ClientInfo {
+	return models.ClientInfo{IP: c.ClientIP(), UserAgent: c.Request.UserAgent(), RequestID: c.GetString("requestID")}
+}
+
+// actor describes the authenticated caller for the audit log.
+func actor(c *gin.Context) models.Actor {
+	return models.Actor{UserID: c.GetUint("userID"), AuthMethod: c.GetString("authMethod"), ClientInfo: clientInfo(c)}
+}
+
+func setTokenCookie(c *gin.Context, jwtToken string) {
 	c.SetSameSite(http.SameSiteLaxMode)
 	c.SetCookie("token", jwtToken, 3600, "", "", false, true)
-	c.JSON(http.StatusOK, gin.H{"message": "Login Successful"})
 }
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *BankHandler) HandleListMembers(c *gin.Context) {
+	members, err := h.bankService.ListMembers(c.Param("accountNumber"), c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+func (h *BankHandler) HandleInviteMember(c *gin.Context) {
+	var request models.InviteMember
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := h.bankService.InviteMember(c.Param("accountNumber"), actor(c), request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, member)
+}
+
+func (h *BankHandler) HandleListInvitations(c *gin.Context) {
+	invitations, err := h.bankService.ListInvitations(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitations": invitations})
+}
+
+func (h *BankHandler) HandleAcceptInvitation(c *gin.Context) {
+	member, err := h.bankService.AcceptInvitation(c.Param("accountNumber"), actor(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+func (h *BankHandler) HandleUpdateMember(c *gin.Context) {
+	memberUserID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var request models.UpdateMember
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := h.bankService.UpdateMember(c.Param("accountNumber"), actor(c), uint(memberUserID), request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+func (h *BankHandler) HandleRemoveMember(c *gin.Context) {
+	memberUserID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if err := h.bankService.RemoveMember(c.Param("accountNumber"), actor(c), uint(memberUserID)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Holder removed"})
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/server/services"
+	"github.com/gin-gonic/gin"
+)
+
+var consentTemplate = template.Must(template.New("consent").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Authorize {{.ClientName}}</title></head>
+<body>
+	<h1>{{.ClientName}} wants to access your Go Banking account</h1>
+	<p>It is asking to:</p>
+	<ul>
+		{{range .Scopes}}<li>{{.}}</li>{{end}}
+	</ul>
+	<form method="POST" action="/oauth/authorize">
+		<input type="hidden" name="request_id" value="{{.RequestID}}">
+		<button type="submit" name="decision" value="approve">Allow</button>
+		<button type="submit" name="decision" value="deny">Deny</button>
+	</form>
+</body>
+</html>
+`))
+
+type OAuthHandler struct {
+	oauthService *services.OAuthService
+}
+
+func NewOAuthHandler(oauthService *services.OAuthService) *OAuthHandler {
+	return &OAuthHandler{oauthService: oauthService}
+}
+
+func (h *OAuthHandler) HandleRegisterClient(c *gin.Context) {
+	var request models.NewOAuthClient
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	client, err := h.oauthService.RegisterClient(actor(c), request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, client)
+}
+
+// HandleAuthorize shows the consent screen for a valid authorization request.
+func (h *OAuthHandler) HandleAuthorize(c *gin.Context) {
+	var request models.OAuthAuthorize
+
+	if err := c.ShouldBindQuery(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+
+	requestID, client, scopes, err := h.oauthService.StartAuthorization(c.GetUint("userID"), request)
+	if err != nil {
+		writeOAuthError(c, err)
+		return
+	}
+
+	descriptions := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		descriptions = append(descriptions, models.OAuthScopeDescription(scope))
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Frame-Options", "DENY")
+	c.Status(http.StatusOK)
+	consentTemplate.Execute(c.Writer, gin.H{
+		"ClientName": client.Name,
+		"Scopes":     descriptions,
+		"RequestID":  requestID,
+	})
+}
+
+func (h *OAuthHandler) HandleAuthorizeDecision(c *gin.Context) {
+	requestID := c.PostForm("request_id")
+	if requestID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "request_id is required"})
+		return
+	}
+
+	location, err := h.oauthService.CompleteAuthorization(actor(c), requestID, c.PostForm("decision") == "approve")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+
+	c.Redirect(http.StatusFound, location)
+}
+
+func (h *OAuthHandler) HandleToken(c *gin.Context) {
+	var request models.OAuthTokenRequest
+
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+
+	if clientID, clientSecret, ok := c.Request.BasicAuth(); ok {
+		request.ClientID, request.ClientSecret = clientID, clientSecret
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	response, err := h.oauthService.ExchangeToken(request)
+	if err != nil {
+		writeOAuthError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *OAuthHandler) HandleListConsents(c *gin.Context) {
+	consents, err := h.oauthService.ListConsents(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"consents": consents})
+}
+
+func (h *OAuthHandler) HandleRevokeConsent(c *gin.Context) {
+	consentID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid consent id"})
+		return
+	}
+
+	if err := h.oauthService.RevokeConsent(actor(c), uint(consentID)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Access revoked"})
+}
+
+// writeOAuthError redirects back to the client when the error carries a
+// verified redirect URI and otherwise answers with an RFC 6749 error body.
+func writeOAuthError(c *gin.Context, err error) {
+	var oauthErr *services.OAuthError
+	if !errors.As(err, &oauthErr) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error", "error_description": err.Error()})
+		return
+	}
+
+	if oauthErr.RedirectURI != "" {
+		c.Redirect(http.StatusFound, oauthErr.Location())
+		return
+	}
+
+	status := http.StatusBadRequest
+	if oauthErr.Code == "invalid_client" {
+		status = http.StatusUnauthorized
+	} else if oauthErr.Code == "server_error" {
+		status = http.StatusInternalServerError
+	}
+
+	c.JSON(status, gin.H{"error": oauthErr.Code, "error_description": oauthErr.Description})
+}
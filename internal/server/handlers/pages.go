@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"html/template"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tokenFormTemplate is the page behind an emailed link. Opening the link only
+// shows the form, so a mail scanner that fetches it cannot use up the token.
+var tokenFormTemplate = template.Must(template.New("token-form").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body>
+	<h1>{{.Title}}</h1>
+	<form method="POST" action="{{.Action}}">
+		<input type="hidden" name="token" value="{{.Token}}">
+		{{if .AskPassword}}<label>New password <input type="password" name="password" minlength="8" required autocomplete="new-password"></label>{{end}}
+		<button type="submit">{{.Submit}}</button>
+	</form>
+</body>
+</html>
+`))
+
+var messageTemplate = template.Must(template.New("message").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body>
+	<h1>{{.Title}}</h1>
+	<p>{{.Message}}</p>
+</body>
+</html>
+`))
+
+type tokenForm struct {
+	Title       string
+	Action      string
+	Submit      string
+	Token       string
+	AskPassword bool
+}
+
+func renderPage(c *gin.Context, status int, page *template.Template, data interface{}) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(status)
+	page.Execute(c.Writer, data)
+}
+
+// respond answers a form submitted from one of the pages above with a page,
+// and API clients with JSON.
+func respond(c *gin.Context, status int, title string, key string, message string) {
+	if c.ContentType() == gin.MIMEPOSTForm {
+		renderPage(c, status, messageTemplate, gin.H{"Title": title, "Message": message})
+		return
+	}
+	c.JSON(status, gin.H{key: message})
+}
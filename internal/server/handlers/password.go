@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/server/services"
+	"github.com/gin-gonic/gin"
+)
+
+type PasswordHandler struct {
+	passwordService *services.PasswordService
+}
+
+func NewPasswordHandler(passwordService *services.PasswordService) *PasswordHandler {
+	return &PasswordHandler{passwordService: passwordService}
+}
+
+func (h *PasswordHandler) HandleForgotPassword(c *gin.Context) {
+	var request models.ForgotPassword
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.passwordService.RequestReset(request); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the email is registered, a reset link has been sent"})
+}
+
+// HandleResetPasswordPage is where the emailed reset link lands.
+func (h *PasswordHandler) HandleResetPasswordPage(c *gin.Context) {
+	renderPage(c, http.StatusOK, tokenFormTemplate, tokenForm{
+		Title:       "Reset your password",
+		Action:      "/password/reset",
+		Submit:      "Reset password",
+		Token:       c.Query("token"),
+		AskPassword: true,
+	})
+}
+
+func (h *PasswordHandler) HandleResetPassword(c *gin.Context) {
+	var request models.ResetPassword
+
+	if err := c.ShouldBind(&request); err != nil {
+		respond(c, http.StatusBadRequest, "Password reset failed", "error", err.Error())
+		return
+	}
+
+	if err := h.passwordService.ResetPassword(request, clientInfo(c)); err != nil {
+		respond(c, http.StatusBadRequest, "Password reset failed", "error", err.Error())
+		return
+	}
+
+	respond(c, http.StatusOK, "Password reset", "message", "Password has been reset")
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/server/services"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const streamHeartbeatInterval = 25 * time.Second
+
+type StreamHandler struct {
+	streamService *services.StreamService
+	upgrader      websocket.Upgrader
+}
+
+func NewStreamHandler(streamService *services.StreamService) *StreamHandler {
+	// The upgrader's default origin check rejects cross-site pages, which
+	// could otherwise ride on the token cookie.
+	return &StreamHandler{streamService: streamService}
+}
+
+// lastEventID reads the resume point from the Last-Event-ID header that
+// EventSource sends on reconnect, or the lastEventId query parameter.
+func lastEventID(c *gin.Context) (uint, bool) {
+	value := c.GetHeader("Last-Event-ID")
+	if value == "" {
+		value = c.Query("lastEventId")
+	}
+
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func sseEvent(event models.StreamEvent) sse.Event {
+	return sse.Event{
+		Id:    strconv.FormatUint(uint64(event.ID), 10),
+		Event: event.Type,
+		Data:  string(event.Data),
+	}
+}
+
+// subscribe registers the caller and returns the events they missed since
+// their last event ID, if they sent one. A truncated backlog ends with a
+// ReplayTruncated marker, after which the stream is closed so the client
+// resumes from there.
+func (h *StreamHandler) subscribe(c *gin.Context) (*services.Subscription, []models.StreamEvent, uint, bool, error) {
+	userID := c.GetUint("userID")
+	subscription := h.streamService.Subscribe(userID)
+
+	afterID, resuming := lastEventID(c)
+	if !resuming {
+		return subscription, nil, 0, false, nil
+	}
+
+	backlog, truncated, err := h.streamService.Replay(userID, afterID)
+	if err != nil {
+		h.streamService.Unsubscribe(subscription)
+		return nil, nil, 0, false, err
+	}
+
+	replayedUpTo := afterID
+	if len(backlog) > 0 {
+		replayedUpTo = backlog[len(backlog)-1].ID
+	}
+	if truncated {
+		backlog = append(backlog, models.ReplayTruncated(replayedUpTo))
+	}
+	return subscription, backlog, replayedUpTo, truncated, nil
+}
+
+func (h *StreamHandler) HandleEventStream(c *gin.Context) {
+	subscription, backlog, replayedUpTo, truncated, err := h.subscribe(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer h.streamService.Unsubscribe(subscription)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	writeEvent := func(event models.StreamEvent) {
+		c.Render(-1, sseEvent(event))
+	}
+
+	for _, event := range backlog {
+		writeEvent(event)
+	}
+	c.Writer.Flush()
+	if truncated {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event, ok := <-subscription.Events:
+			if !ok {
+				return
+			}
+			// Events already sent from the backlog can also arrive live.
+			if event.ID <= replayedUpTo {
+				continue
+			}
+			writeEvent(event)
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			c.Writer.WriteString(": ping\n\n")
+			c.Writer.Flush()
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+func (h *StreamHandler) HandleWebSocket(c *gin.Context) {
+	subscription, backlog, replayedUpTo, truncated, err := h.subscribe(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer h.streamService.Unsubscribe(subscription)
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// The stream is one-way; reading only notices when the client goes away.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for _, event := range backlog {
+		if err := conn.WriteJSON(event); err != nil {
+			return
+		}
+	}
+	if truncated {
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "replay truncated"))
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event, ok := <-subscription.Events:
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too far behind"))
+				return
+			}
+			if event.ID <= replayedUpTo {
+				continue
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/FaizanAC/Go-Banking/internal/models"
 	"github.com/FaizanAC/Go-Banking/internal/server/services"
@@ -23,7 +25,15 @@ func (h *UserHandler) HandleUserCreation(c *gin.Context) {
 		return
 	}
 
-	if err := h.userService.CreateUser(&user); err != nil {
+	if err := h.userService.CreateUser(&user, clientInfo(c)); err != nil {
+		if errors.Is(err, services.ErrBlankName) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrEmailTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to Create User"})
 		return
 	}
@@ -34,11 +44,104 @@ func (h *UserHandler) HandleUserCreation(c *gin.Context) {
 func (h *UserHandler) HandleGetUser(c *gin.Context) {
 	id := c.Param("id")
 
+	if id != strconv.FormatUint(uint64(c.GetUint("userID")), 10) &&
+		!models.HasPermission(c.GetString("role"), models.PERMISSION_READ_USERS) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+		return
+	}
+
 	user, err := h.userService.GetUserByID(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not Found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	c.JSON(http.StatusOK, user.Response())
+}
+
+func (h *UserHandler) HandleGetProfile(c *gin.Context) {
+	user, err := h.userService.GetUserByID(strconv.FormatUint(uint64(c.GetUint("userID")), 10))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not Found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user.Response())
+}
+
+func (h *UserHandler) HandleUpdateProfile(c *gin.Context) {
+	var request models.UpdateProfile
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userService.UpdateProfile(actor(c), request)
+	if err != nil {
+		if errors.Is(err, services.ErrEmailTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user.Response())
+}
+
+func (h *UserHandler) HandleChangePassword(c *gin.Context) {
+	var request models.ChangePassword
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, err := h.userService.ChangePassword(actor(c), request)
+	if err != nil {
+		if errors.Is(err, services.ErrIncorrectPassword) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	setTokenCookie(c, token)
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed"})
+}
+
+func (h *UserHandler) HandleVerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	if err := h.userService.VerifyEmail(token, clientInfo(c)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+func (h *UserHandler) HandleResendVerification(c *gin.Context) {
+	userID, hasKey := c.Get("userID")
+	if !hasKey {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	if err := h.userService.ResendVerification(userID.(uint)); err != nil {
+		if errors.Is(err, services.ErrTooManyRequests) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Verification email sent"})
 }
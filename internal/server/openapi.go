@@ -0,0 +1,432 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/FaizanAC/Go-Banking/internal/graphqlapi"
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/openapi"
+)
+
+const (
+	authNone = iota
+	// authToken accepts a JWT, an API key or an OAuth access token.
+	authToken
+	// authSession only accepts a JWT from logging in.
+	authSession
+)
+
+// apiRoute documents one route registered in SetupRouter. Request and
+// response shapes are Go types, so the schemas follow the code; the
+// response types below describe handlers that reply with gin.H.
+type apiRoute struct {
+	Method     string
+	Path       string
+	Tag        string
+	Summary    string
+	Auth       int
+	Scope      string
+	Permission models.Permission
+	// VerifiedEmail is set for routes behind RequireVerifiedEmail.
+	VerifiedEmail bool
+	Query         interface{}
+	Body          interface{}
+	// FormBody reads Body from a form instead of JSON.
+	FormBody bool
+	Status   int
+	Response interface{}
+	// ContentType is set for responses that are not JSON.
+	ContentType string
+	Extra       map[int]interface{}
+}
+
+type graphqlResponse struct {
+	Data       map[string]interface{}   `json:"data"`
+	Errors     []map[string]interface{} `json:"errors,omitempty"`
+	Extensions map[string]interface{}   `json:"extensions,omitempty"`
+}
+
+type messageResponse struct {
+	Message string `json:"message"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type tokenErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type createdUserResponse struct {
+	Email string `json:"email"`
+}
+
+type twoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"twoFactorRequired"`
+	ChallengeID       string `json:"challengeId"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type accountNumberResponse struct {
+	AccountNumber string `json:"Account Number"`
+}
+
+type balanceResponse struct {
+	NewBalance float64 `json:"New Balance"`
+}
+
+type activityFeedResponse struct {
+	LatestActivity []models.Transaction `json:"latestActivity"`
+}
+
+type listAPIKeysResponse struct {
+	APIKeys []models.APIKeyResponse `json:"apiKeys"`
+}
+
+type consentListResponse struct {
+	Consents []models.OAuthConsentResponse `json:"consents"`
+}
+
+type dataRequestListResponse struct {
+	Requests []models.DataRequest `json:"requests"`
+}
+
+type webhookListResponse struct {
+	Webhooks []models.WebhookEndpointResponse `json:"webhooks"`
+}
+
+type memberListResponse struct {
+	Members []models.AccountMember `json:"members"`
+}
+
+type invitationListResponse struct {
+	Invitations []models.AccountMember `json:"invitations"`
+}
+
+type adminUserResponse struct {
+	User     models.UserResponse  `json:"user"`
+	Accounts []models.BankAccount `json:"accounts"`
+}
+
+type userPageResponse struct {
+	Users    []models.UserResponse `json:"users"`
+	Total    int64                 `json:"total"`
+	Page     int                   `json:"page"`
+	PageSize int                   `json:"pageSize"`
+}
+
+type transactionPageResponse struct {
+	Transactions []models.Transaction `json:"transactions"`
+	Page         int                  `json:"page"`
+	PageSize     int                  `json:"pageSize"`
+}
+
+type dataRequestPageResponse struct {
+	Requests []models.DataRequest `json:"requests"`
+	Total    int64                `json:"total"`
+	Page     int                  `json:"page"`
+	PageSize int                  `json:"pageSize"`
+}
+
+type auditLogPageResponse struct {
+	AuditLogs []models.AuditLog `json:"auditLogs"`
+	Total     int64             `json:"total"`
+	Page      int               `json:"page"`
+	PageSize  int               `json:"pageSize"`
+}
+
+type deliveryPageResponse struct {
+	Deliveries []models.WebhookDelivery `json:"deliveries"`
+	Total      int64                    `json:"total"`
+	Page       int                      `json:"page"`
+	PageSize   int                      `json:"pageSize"`
+}
+
+// pageQuery documents the parameters read by the handlers' pagination,
+// which defaults to 50 items and caps pageSize at 200.
+type pageQuery struct {
+	Page     int `form:"page" binding:"min=1"`
+	PageSize int `form:"pageSize" binding:"min=1,max=200"`
+}
+
+type userPageQuery struct {
+	Email string `form:"email"`
+	pageQuery
+}
+
+type dataRequestPageQuery struct {
+	UserID uint `form:"userId"`
+	pageQuery
+}
+
+type auditLogQuery struct {
+	models.AuditLogFilter
+	pageQuery
+}
+
+type tokenQuery struct {
+	Token string `form:"token" binding:"required"`
+}
+
+type ledgerQuery struct {
+	AccountNumber string `form:"accountNumber"`
+}
+
+type streamQuery struct {
+	LastEventID uint `form:"lastEventId"`
+}
+
+type authorizeDecision struct {
+	RequestID string `form:"request_id" json:"request_id" binding:"required"`
+	Decision  string `form:"decision" json:"decision" binding:"oneof=approve deny"`
+}
+
+var apiRoutes = []apiRoute{
+	{Method: "GET", Path: "/ping", Tag: "Health", Summary: "Check the server is up", Response: messageResponse{}},
+	{Method: "GET", Path: "/.well-known/jwks.json", Tag: "Health", Summary: "Public keys that verify access tokens", Response: struct {
+		Keys []map[string]string `json:"keys"`
+	}{}},
+	{Method: "GET", Path: "/openapi.json", Tag: "Docs", Summary: "This OpenAPI document", Response: map[string]interface{}{}},
+	{Method: "GET", Path: "/docs", Tag: "Docs", Summary: "Interactive API documentation", ContentType: "text/html"},
+
+	{Method: "POST", Path: "/user", Tag: "Users", Summary: "Sign up", Body: models.User{}, Status: http.StatusCreated, Response: createdUserResponse{}, Extra: map[int]interface{}{http.StatusConflict: errorResponse{}}},
+	{Method: "GET", Path: "/user/me", Tag: "Users", Summary: "Get your profile", Auth: authToken, Scope: models.SCOPE_USERS_READ, Response: models.UserResponse{}},
+	{Method: "PATCH", Path: "/user/me", Tag: "Users", Summary: "Update your profile", Auth: authSession, Body: models.UpdateProfile{}, Response: models.UserResponse{}, Extra: map[int]interface{}{http.StatusConflict: errorResponse{}}},
+	{Method: "PUT", Path: "/user/me/password", Tag: "Users", Summary: "Change your password", Auth: authSession, Body: models.ChangePassword{}, Response: messageResponse{}},
+	{Method: "GET", Path: "/user/:id", Tag: "Users", Summary: "Get a user, which must be you unless you can read users", Auth: authToken, Scope: models.SCOPE_USERS_READ, Response: models.UserResponse{}},
+	{Method: "GET", Path: "/user/verify-email", Tag: "Users", Summary: "Verify your email address with the emailed token", Query: tokenQuery{}, Response: messageResponse{}},
+	{Method: "POST", Path: "/user/verify-email/resend", Tag: "Users", Summary: "Send the verification email again", Auth: authSession, Status: http.StatusAccepted, Response: messageResponse{}},
+
+	{Method: "POST", Path: "/user/2fa/enroll", Tag: "Two-Factor Authentication", Summary: "Start enrolling an authenticator app", Auth: authSession, Response: models.TwoFactorEnrollment{}},
+	{Method: "POST", Path: "/user/2fa/confirm", Tag: "Two-Factor Authentication", Summary: "Confirm enrollment and get recovery codes", Auth: authSession, Body: models.TwoFactorCode{}, Response: recoveryCodesResponse{}},
+	{Method: "POST", Path: "/user/2fa/disable", Tag: "Two-Factor Authentication", Summary: "Turn off two-factor authentication", Auth: authSession, Body: models.DisableTwoFactor{}, Response: messageResponse{}},
+	{Method: "POST", Path: "/user/2fa/recovery-codes", Tag: "Two-Factor Authentication", Summary: "Replace your recovery codes", Auth: authSession, Body: models.TwoFactorCode{}, Response: recoveryCodesResponse{}},
+
+	{Method: "POST", Path: "/user/api-keys", Tag: "API Keys", Summary: "Create an API key, returned only once", Auth: authSession, Body: models.NewAPIKey{}, Status: http.StatusCreated, Response: models.APIKeyResponse{}},
+	{Method: "GET", Path: "/user/api-keys", Tag: "API Keys", Summary: "List your API keys", Auth: authSession, Response: listAPIKeysResponse{}},
+	{Method: "DELETE", Path: "/user/api-keys/:id", Tag: "API Keys", Summary: "Revoke an API key", Auth: authSession, Response: messageResponse{}},
+
+	{Method: "GET", Path: "/user/consents", Tag: "OAuth", Summary: "List apps you have authorized", Auth: authSession, Response: consentListResponse{}},
+	{Method: "DELETE", Path: "/user/consents/:id", Tag: "OAuth", Summary: "Revoke an app's access", Auth: authSession, Response: messageResponse{}},
+
+	{Method: "GET", Path: "/user/data-requests", Tag: "Your Data", Summary: "List your export and erasure requests", Auth: authSession, Response: dataRequestListResponse{}},
+	{Method: "POST", Path: "/user/data-requests/export", Tag: "Your Data", Summary: "Request an export of your data", Auth: authSession, Status: http.StatusAccepted, Response: models.DataRequest{}},
+	{Method: "GET", Path: "/user/data-requests/:id/download", Tag: "Your Data", Summary: "Download a completed export", Auth: authSession, ContentType: "application/zip"},
+	{Method: "POST", Path: "/user/data-requests/erasure", Tag: "Your Data", Summary: "Erase your personal data", Auth: authSession, Body: models.RequestErasure{}, Response: messageResponse{}},
+
+	{Method: "POST", Path: "/user/webhooks", Tag: "Webhooks", Summary: "Register a webhook endpoint; the signing secret is returned only once", Auth: authSession, Body: models.NewWebhookEndpoint{}, Status: http.StatusCreated, Response: models.WebhookEndpointResponse{}},
+	{Method: "GET", Path: "/user/webhooks", Tag: "Webhooks", Summary: "List your webhook endpoints", Auth: authSession, Response: webhookListResponse{}},
+	{Method: "DELETE", Path: "/user/webhooks/:id", Tag: "Webhooks", Summary: "Delete a webhook endpoint", Auth: authSession, Response: messageResponse{}},
+	{Method: "POST", Path: "/user/webhooks/:id/enable", Tag: "Webhooks", Summary: "Re-enable a disabled endpoint", Auth: authSession, Response: models.WebhookEndpointResponse{}},
+	{Method: "GET", Path: "/user/webhooks/:id/deliveries", Tag: "Webhooks", Summary: "List an endpoint's deliveries", Auth: authSession, Query: pageQuery{}, Response: deliveryPageResponse{}},
+	{Method: "POST", Path: "/user/webhooks/:id/deliveries/:deliveryId/redeliver", Tag: "Webhooks", Summary: "Send a delivery again", Auth: authSession, Status: http.StatusAccepted, Response: models.WebhookDelivery{}},
+
+	{Method: "GET", Path: "/oauth/authorize", Tag: "OAuth", Summary: "Show the consent screen for an authorization request", Auth: authSession, Query: models.OAuthAuthorize{}, ContentType: "text/html"},
+	{Method: "POST", Path: "/oauth/authorize", Tag: "OAuth", Summary: "Approve or deny an authorization request and redirect back to the app", Auth: authSession, Body: authorizeDecision{}, FormBody: true, Status: http.StatusFound},
+	{Method: "POST", Path: "/oauth/token", Tag: "OAuth", Summary: "Exchange an authorization code or refresh token", Body: models.OAuthTokenRequest{}, FormBody: true, Response: models.OAuthTokenResponse{}},
+
+	{Method: "POST", Path: "/login", Tag: "Login", Summary: "Log in and receive the token cookie", Body: models.Login{}, Response: messageResponse{}, Extra: map[int]interface{}{http.StatusAccepted: twoFactorChallengeResponse{}}},
+	{Method: "POST", Path: "/login/2fa", Tag: "Login", Summary: "Finish logging in with a two-factor code", Body: models.TwoFactorLogin{}, Response: messageResponse{}},
+	{Method: "GET", Path: "/login/unlock", Tag: "Login", Summary: "Show the page the emailed unlock link opens", Query: tokenQuery{}, ContentType: "text/html"},
+	{Method: "POST", Path: "/login/unlock", Tag: "Login", Summary: "Unlock a locked login with the emailed single-use token", Body: models.UnlockLogin{}, Response: messageResponse{}},
+
+	{Method: "POST", Path: "/password/forgot", Tag: "Login", Summary: "Email a password reset link", Body: models.ForgotPassword{}, Status: http.StatusAccepted, Response: messageResponse{}},
+	{Method: "GET", Path: "/password/reset", Tag: "Login", Summary: "Show the page the emailed reset link opens", Query: tokenQuery{}, ContentType: "text/html"},
+	{Method: "POST", Path: "/password/reset", Tag: "Login", Summary: "Set a new password with a reset token", Body: models.ResetPassword{}, Response: messageResponse{}},
+
+	{Method: "POST", Path: "/bank/new-account", Tag: "Bank", Summary: "Open an account", Auth: authToken, Scope: models.SCOPE_ACCOUNTS_WRITE, Status: http.StatusCreated, Response: accountNumberResponse{}},
+	{Method: "GET", Path: "/bank/accounts", Tag: "Bank", Summary: "List the accounts you hold", Auth: authToken, Scope: models.SCOPE_ACCOUNTS_READ, Response: []models.BankAccount{}},
+	{Method: "POST", Path: "/bank/deposit", Tag: "Bank", Summary: "Deposit into an account", Auth: authToken, Scope: models.SCOPE_TRANSACTIONS_WRITE, VerifiedEmail: true, Body: models.Transaction{}, Response: balanceResponse{}},
+	{Method: "POST", Path: "/bank/withdraw", Tag: "Bank", Summary: "Withdraw from an account", Auth: authToken, Scope: models.SCOPE_TRANSACTIONS_WRITE, VerifiedEmail: true, Body: models.Transaction{}, Response: balanceResponse{}},
+	{Method: "GET", Path: "/bank/activity-feed", Tag: "Bank", Summary: "Latest transactions on your accounts", Auth: authToken, Scope: models.SCOPE_TRANSACTIONS_READ, Response: activityFeedResponse{}},
+	{Method: "GET", Path: "/bank/stream", Tag: "Bank", Summary: "Server-Sent Events stream of your account events", Auth: authToken, Scope: models.SCOPE_TRANSACTIONS_READ, Query: streamQuery{}, ContentType: "text/event-stream"},
+	{Method: "GET", Path: "/bank/stream/ws", Tag: "Bank", Summary: "WebSocket stream of your account events", Auth: authToken, Scope: models.SCOPE_TRANSACTIONS_READ, Query: streamQuery{}, Status: http.StatusSwitchingProtocols},
+	{Method: "GET", Path: "/bank/invitations", Tag: "Joint Accounts", Summary: "List invitations to join accounts", Auth: authToken, Scope: models.SCOPE_ACCOUNTS_READ, Response: invitationListResponse{}},
+	{Method: "GET", Path: "/bank/accounts/:accountNumber/members", Tag: "Joint Accounts", Summary: "List an account's holders", Auth: authToken, Scope: models.SCOPE_ACCOUNTS_READ, Response: memberListResponse{}},
+	{Method: "POST", Path: "/bank/accounts/:accountNumber/members", Tag: "Joint Accounts", Summary: "Invite a holder", Auth: authToken, Scope: models.SCOPE_ACCOUNTS_WRITE, Body: models.InviteMember{}, Status: http.StatusCreated, Response: models.AccountMember{}},
+	{Method: "POST", Path: "/bank/accounts/:accountNumber/members/accept", Tag: "Joint Accounts", Summary: "Accept an invitation", Auth: authToken, Scope: models.SCOPE_ACCOUNTS_WRITE, Response: models.AccountMember{}},
+	{Method: "PUT", Path: "/bank/accounts/:accountNumber/members/:userId", Tag: "Joint Accounts", Summary: "Change a holder's role or limit", Auth: authToken, Scope: models.SCOPE_ACCOUNTS_WRITE, Body: models.UpdateMember{}, Response: models.AccountMember{}},
+	{Method: "DELETE", Path: "/bank/accounts/:accountNumber/members/:userId", Tag: "Joint Accounts", Summary: "Remove a holder", Auth: authToken, Scope: models.SCOPE_ACCOUNTS_WRITE, Response: messageResponse{}},
+	{Method: "POST", Path: "/bank/transfer/send", Tag: "Bank", Summary: "Send a transfer", Auth: authToken, Scope: models.SCOPE_TRANSFERS_WRITE, VerifiedEmail: true, Body: models.OutgoingTransfer{}, Response: balanceResponse{}},
+	{Method: "POST", Path: "/bank/transfer/accept", Tag: "Bank", Summary: "Accept a transfer into an account", Auth: authToken, Scope: models.SCOPE_TRANSFERS_WRITE, VerifiedEmail: true, Body: models.IncomingTransfer{}, Response: balanceResponse{}},
+
+	{Method: "POST", Path: "/graphql", Tag: "GraphQL", Summary: "Run a GraphQL query or mutation", Auth: authToken, Body: graphqlapi.Request{}, Response: graphqlResponse{}},
+
+	{Method: "GET", Path: "/admin/users", Tag: "Admin", Summary: "Search users", Auth: authToken, Scope: models.SCOPE_ADMIN, Permission: models.PERMISSION_READ_USERS, Query: userPageQuery{}, Response: userPageResponse{}},
+	{Method: "GET", Path: "/admin/users/:id", Tag: "Admin", Summary: "Get a user and their accounts", Auth: authToken, Scope: models.SCOPE_ADMIN, Permission: models.PERMISSION_READ_USERS, Response: adminUserResponse{}},
+	{Method: "PUT", Path: "/admin/users/:id/role", Tag: "Admin", Summary: "Change a user's role", Auth: authToken, Scope: models.SCOPE_ADMIN, Permission: models.PERMISSION_MANAGE_USERS, Body: models.UpdateRole{}, Response: models.UserResponse{}},
+	{Method: "POST", Path: "/admin/users/:id/unlock", Tag: "Admin", Summary: "Unlock a user's login", Auth: authToken, Scope: models.SCOPE_ADMIN, Permission: models.PERMISSION_MANAGE_USERS, Response: messageResponse{}},
+	{Method: "POST", Path: "/admin/users/:id/erase", Tag: "Admin", Summary: "Erase a user's personal data", Auth: authToken, Scope: models.SCOPE_ADMIN, Permission: models.PERMISSION_MANAGE_USERS, Response: models.DataRequest{}},
+	{Method: "GET", Path: "/admin/data-requests", Tag: "Admin", Summary: "List export and erasure requests", Auth: authToken, Scope: models.SCOPE_ADMIN, Permission: models.PERMISSION_READ_USERS, Query: dataRequestPageQuery{}, Response: dataRequestPageResponse{}},
+	{Method: "GET", Path: "/admin/accounts/:accountNumber/transactions", Tag: "Admin", Summary: "List an account's transactions", Auth: authToken, Scope: models.SCOPE_ADMIN, Permission: models.PERMISSION_READ_TRANSACTIONS, Query: pageQuery{}, Response: transactionPageResponse{}},
+	{Method: "POST", Path: "/admin/accounts/:accountNumber/freeze", Tag: "Admin", Summary: "Freeze an account", Auth: authToken, Scope: models.SCOPE_ADMIN, Permission: models.PERMISSION_FREEZE_ACCOUNTS, Response: models.BankAccount{}},
+	{Method: "POST", Path: "/admin/accounts/:accountNumber/unfreeze", Tag: "Admin", Summary: "Unfreeze an account", Auth: authToken, Scope: models.SCOPE_ADMIN, Permission: models.PERMISSION_FREEZE_ACCOUNTS, Response: models.BankAccount{}},
+	{Method: "GET", Path: "/admin/audit-logs", Tag: "Admin", Summary: "Search the audit log", Auth: authToken, Scope: models.SCOPE_ADMIN, Permission: models.PERMISSION_READ_AUDIT_LOG, Query: auditLogQuery{}, Response: auditLogPageResponse{}},
+	{Method: "GET", Path: "/admin/ledger/verify", Tag: "Admin", Summary: "Verify the transaction hash chain", Auth: authToken, Scope: models.SCOPE_ADMIN, Permission: models.PERMISSION_READ_AUDIT_LOG, Query: ledgerQuery{}, Response: models.LedgerVerification{}},
+	{Method: "POST", Path: "/admin/oauth/clients", Tag: "Admin", Summary: "Register an OAuth client; the secret is returned only once", Auth: authToken, Scope: models.SCOPE_ADMIN, Permission: models.PERMISSION_MANAGE_OAUTH, Body: models.NewOAuthClient{}, Status: http.StatusCreated, Response: models.OAuthClientResponse{}},
+}
+
+// BuildOpenAPI generates the OpenAPI document for apiRoutes.
+func BuildOpenAPI() *openapi.Document {
+	generator := openapi.NewGenerator()
+	errorSchema := generator.Schema(errorResponse{})
+	tokenErrorSchema := generator.Schema(tokenErrorResponse{})
+
+	doc := &openapi.Document{
+		OpenAPI: openapi.VERSION,
+		Info: openapi.Info{
+			Title:       "Go Banking",
+			Version:     "1.0.0",
+			Description: "REST banking service. Routes with x-required-scope need that scope when called with an API key or OAuth access token.",
+		},
+		Paths: map[string]openapi.PathItem{},
+		Components: openapi.Components{
+			SecuritySchemes: map[string]openapi.SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", Description: "A JWT from logging in, an API key or an OAuth access token."},
+				"cookieAuth": {Type: "apiKey", In: "cookie", Name: "token", Description: "The token cookie set by POST /login."},
+			},
+		},
+	}
+
+	tags := map[string]bool{}
+	for _, route := range apiRoutes {
+		path, parameters := openAPIPath(route.Path)
+		operation := &openapi.Operation{
+			OperationID:   operationID(route.Method, route.Path),
+			Summary:       route.Summary,
+			Description:   routeDescription(route),
+			Tags:          []string{route.Tag},
+			Parameters:    parameters,
+			Responses:     map[string]openapi.Response{},
+			Security:      []openapi.SecurityRequirement{},
+			RequiredScope: route.Scope,
+		}
+		tags[route.Tag] = true
+
+		if route.Auth != authNone {
+			operation.Security = []openapi.SecurityRequirement{{"bearerAuth": {}}, {"cookieAuth": {}}}
+			operation.Responses["401"] = errorResponseFor(http.StatusUnauthorized, errorSchema)
+			operation.Responses["403"] = errorResponseFor(http.StatusForbidden, errorSchema)
+		}
+
+		if route.Query != nil {
+			operation.Parameters = append(operation.Parameters, generator.Parameters(route.Query, "query")...)
+		}
+
+		if route.Body != nil {
+			contentType := "application/json"
+			if route.FormBody {
+				contentType = "application/x-www-form-urlencoded"
+			}
+			operation.RequestBody = &openapi.RequestBody{
+				Required: true,
+				Content:  map[string]openapi.MediaType{contentType: {Schema: generator.Schema(route.Body)}},
+			}
+		}
+
+		if route.Body != nil || route.Query != nil || len(parameters) > 0 {
+			schema := errorSchema
+			if strings.HasPrefix(route.Path, "/oauth/") {
+				schema = tokenErrorSchema
+			}
+			operation.Responses["400"] = errorResponseFor(http.StatusBadRequest, schema)
+		}
+
+		status := route.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		operation.Responses[fmt.Sprint(status)] = successResponse(generator, status, route)
+		for extraStatus, response := range route.Extra {
+			operation.Responses[fmt.Sprint(extraStatus)] = openapi.Response{
+				Description: http.StatusText(extraStatus),
+				Content:     openapi.JSONContent(generator.Schema(response)),
+			}
+		}
+
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = openapi.PathItem{}
+			doc.Paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = operation
+	}
+
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, openapi.Tag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+
+	doc.Components.Schemas = generator.Schemas
+	return doc
+}
+
+func successResponse(generator *openapi.Generator, status int, route apiRoute) openapi.Response {
+	response := openapi.Response{Description: http.StatusText(status)}
+	switch {
+	case route.ContentType == "application/zip":
+		response.Content = map[string]openapi.MediaType{route.ContentType: {Schema: &openapi.Schema{Type: "string", Format: "binary"}}}
+	case route.ContentType != "":
+		response.Content = map[string]openapi.MediaType{route.ContentType: {Schema: &openapi.Schema{Type: "string"}}}
+	case route.Response != nil:
+		response.Content = openapi.JSONContent(generator.Schema(route.Response))
+	}
+	return response
+}
+
+func errorResponseFor(status int, schema *openapi.Schema) openapi.Response {
+	return openapi.Response{Description: http.StatusText(status), Content: openapi.JSONContent(schema)}
+}
+
+func routeDescription(route apiRoute) string {
+	var notes []string
+	if route.Auth == authSession {
+		notes = append(notes, "Needs a logged-in user session; API keys and OAuth tokens are refused.")
+	}
+	if route.Permission != "" {
+		notes = append(notes, fmt.Sprintf("Needs a role with the %s permission.", route.Permission))
+	}
+	if route.VerifiedEmail {
+		notes = append(notes, "Needs a verified email address.")
+	}
+	return strings.Join(notes, " ")
+}
+
+// openAPIPath converts Gin's :param segments to OpenAPI's {param} and
+// returns the matching path parameters.
+func openAPIPath(path string) (string, []openapi.Parameter) {
+	segments := strings.Split(path, "/")
+	var parameters []openapi.Parameter
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			segments[i] = "{" + name + "}"
+			parameters = append(parameters, openapi.Parameter{
+				Name:     name,
+				In:       "path",
+				Required: true,
+				Schema:   &openapi.Schema{Type: "string"},
+			})
+		}
+	}
+	return strings.Join(segments, "/"), parameters
+}
+
+// operationID builds a name like getUserById from the method and path.
+func operationID(method string, path string) string {
+	id := strings.ToLower(method)
+	for _, segment := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '-' || r == '.' }) {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			segment = "by" + name
+		}
+		id += strings.ToUpper(segment[:1]) + segment[1:]
+	}
+	return id
+}
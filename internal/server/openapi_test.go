@@ -0,0 +1,80 @@
+package server
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestOpenAPIMatchesRoutes fails when a route is added to SetupRouter
+// without being documented in apiRoutes, or the other way round.
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	router := (&Server{}).SetupRouter()
+
+	registered := []string{}
+	for _, route := range router.Routes() {
+		path, _ := openAPIPath(route.Path)
+		registered = append(registered, route.Method+" "+path)
+	}
+
+	documented := []string{}
+	for path, item := range BuildOpenAPI().Paths {
+		for method := range item {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+
+	assert.ElementsMatch(t, registered, documented)
+}
+
+func TestOpenAPIReferencesResolve(t *testing.T) {
+	doc := BuildOpenAPI()
+
+	body, err := json.Marshal(doc)
+	assert.NoError(t, err)
+
+	var refs []string
+	collectRefs(t, body, &refs)
+	assert.NotEmpty(t, refs)
+
+	for _, ref := range refs {
+		name, ok := strings.CutPrefix(ref, "#/components/schemas/")
+		if assert.True(t, ok, ref) {
+			assert.NotNil(t, doc.Components.Schemas[name], ref)
+		}
+	}
+}
+
+func TestOpenAPIOperationIDsAreUnique(t *testing.T) {
+	seen := map[string]bool{}
+	for _, route := range apiRoutes {
+		id := operationID(route.Method, route.Path)
+		assert.False(t, seen[id], id)
+		seen[id] = true
+	}
+}
+
+func collectRefs(t *testing.T, body []byte, refs *[]string) {
+	var value interface{}
+	assert.NoError(t, json.Unmarshal(body, &value))
+
+	var walk func(value interface{})
+	walk = func(value interface{}) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for key, child := range v {
+				if ref, ok := child.(string); ok && key == "$ref" {
+					*refs = append(*refs, ref)
+				}
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(value)
+}
@@ -2,59 +2,235 @@ package server
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
+	"github.com/FaizanAC/Go-Banking/internal/graphqlapi"
+	"github.com/FaizanAC/Go-Banking/internal/mailer"
 	"github.com/FaizanAC/Go-Banking/internal/middleware"
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/repository"
 	"github.com/FaizanAC/Go-Banking/internal/server/handlers"
 	"github.com/FaizanAC/Go-Banking/internal/server/services"
+	"github.com/FaizanAC/Go-Banking/internal/util"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type Server struct {
-	db   *gorm.DB
-	Port string
+	db            *gorm.DB
+	streamService *services.StreamService
+	Port          string
+}
+
+// trustedProxies reads TRUSTED_PROXIES, the comma-separated addresses or
+// CIDRs of the reverse proxies whose X-Forwarded-For header is believed. By
+// default no proxy is trusted and the client IP is the connection's address.
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
 }
 
 func (s *Server) SetupRouter() *gin.Engine {
 	r := gin.Default()
+	if err := r.SetTrustedProxies(trustedProxies()); err != nil {
+		panic("Invalid TRUSTED_PROXIES")
+	}
+	r.Use(middleware.RequestID)
 
 	healthHandler := handlers.HealthHandler{}
 
-	userService := services.NewUserService(s.db)
+	keySet, err := util.DefaultKeySet()
+	if err != nil {
+		panic("Cannot load JWT signing keys")
+	}
+	jwksHandler := handlers.NewJWKSHandler(keySet)
+
+	mail, err := mailer.NewMailerFromEnv()
+	if err != nil {
+		panic("Cannot configure the mailer")
+	}
+
+	authenticate := middleware.Authenticate(s.db)
+	store := repository.NewGormStore(s.db)
+
+	userService := services.NewUserService(store, mail)
 	userHandler := handlers.NewUserHandler(userService)
 
-	loginService := services.NewLoginService(s.db)
+	loginService := services.NewLoginService(store, mail)
 	loginHandler := handlers.NewLoginHandler(loginService)
 
-	bankService := services.NewBankService(s.db)
+	twoFactorService := services.NewTwoFactorService(s.db)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService)
+
+	passwordService := services.NewPasswordService(s.db, mail)
+	passwordHandler := handlers.NewPasswordHandler(passwordService)
+
+	bankService := services.NewBankService(store)
 	bankHandler := handlers.NewBankHandler(bankService)
 
+	adminService := services.NewAdminService(store)
+	adminHandler := handlers.NewAdminHandler(adminService)
+
+	apiKeyService := services.NewAPIKeyService(s.db)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+
+	oauthService := services.NewOAuthService(s.db)
+	oauthHandler := handlers.NewOAuthHandler(oauthService)
+
+	privacyService := services.NewPrivacyService(s.db, mail)
+	privacyHandler := handlers.NewPrivacyHandler(privacyService)
+
+	auditService := services.NewAuditService(s.db)
+	auditHandler := handlers.NewAuditHandler(auditService)
+
+	streamHandler := handlers.NewStreamHandler(s.streamService)
+
+	webhookService := services.NewWebhookService(s.db)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+
+	ledgerService := services.NewLedgerService(s.db)
+	ledgerHandler := handlers.NewLedgerHandler(ledgerService)
+
+	docsHandler := handlers.NewDocsHandler(BuildOpenAPI())
+
+	graphqlSchema, err := graphqlapi.NewSchema(s.db, mail)
+	if err != nil {
+		panic("Cannot build the GraphQL schema")
+	}
+	graphqlHandler := handlers.NewGraphQLHandler(graphqlSchema)
+
 	// Health
 	r.GET("/ping", healthHandler.HandlePing)
 
+	// Keys
+	r.GET("/.well-known/jwks.json", jwksHandler.HandleJWKS)
+
+	// Docs
+	r.GET("/openapi.json", docsHandler.HandleOpenAPI)
+	r.GET("/docs", docsHandler.HandleDocs)
+
 	// User
-	r.GET("/user/:id", middleware.AuthorizeRequest, userHandler.HandleGetUser)
+	r.GET("/user/me", authenticate, middleware.RequireScope(models.SCOPE_USERS_READ), userHandler.HandleGetProfile)
+	r.PATCH("/user/me", authenticate, middleware.RequireUserSession, userHandler.HandleUpdateProfile)
+	r.PUT("/user/me/password", authenticate, middleware.RequireUserSession, userHandler.HandleChangePassword)
+	r.GET("/user/:id", authenticate, middleware.RequireScope(models.SCOPE_USERS_READ), userHandler.HandleGetUser)
 	r.POST("/user", userHandler.HandleUserCreation)
+	r.GET("/user/verify-email", userHandler.HandleVerifyEmail)
+	r.POST("/user/verify-email/resend", authenticate, middleware.RequireUserSession, userHandler.HandleResendVerification)
+
+	twoFactorGroup := r.Group("/user/2fa", authenticate, middleware.RequireUserSession)
+	{
+		twoFactorGroup.POST("/enroll", twoFactorHandler.HandleEnroll)
+		twoFactorGroup.POST("/confirm", twoFactorHandler.HandleConfirm)
+		twoFactorGroup.POST("/disable", twoFactorHandler.HandleDisable)
+		twoFactorGroup.POST("/recovery-codes", twoFactorHandler.HandleRegenerateRecoveryCodes)
+	}
+
+	apiKeyGroup := r.Group("/user/api-keys", authenticate, middleware.RequireUserSession)
+	{
+		apiKeyGroup.POST("", apiKeyHandler.HandleCreateAPIKey)
+		apiKeyGroup.GET("", apiKeyHandler.HandleListAPIKeys)
+		apiKeyGroup.DELETE("/:id", apiKeyHandler.HandleRevokeAPIKey)
+	}
+
+	consentGroup := r.Group("/user/consents", authenticate, middleware.RequireUserSession)
+	{
+		consentGroup.GET("", oauthHandler.HandleListConsents)
+		consentGroup.DELETE("/:id", oauthHandler.HandleRevokeConsent)
+	}
+
+	dataRequestGroup := r.Group("/user/data-requests", authenticate, middleware.RequireUserSession)
+	{
+		dataRequestGroup.GET("", privacyHandler.HandleListDataRequests)
+		dataRequestGroup.POST("/export", privacyHandler.HandleRequestExport)
+		dataRequestGroup.GET("/:id/download", privacyHandler.HandleDownloadExport)
+		dataRequestGroup.POST("/erasure", privacyHandler.HandleRequestErasure)
+	}
+
+	webhookGroup := r.Group("/user/webhooks", authenticate, middleware.RequireUserSession)
+	{
+		webhookGroup.POST("", webhookHandler.HandleCreateWebhook)
+		webhookGroup.GET("", webhookHandler.HandleListWebhooks)
+		webhookGroup.DELETE("/:id", webhookHandler.HandleDeleteWebhook)
+		webhookGroup.POST("/:id/enable", webhookHandler.HandleEnableWebhook)
+		webhookGroup.GET("/:id/deliveries", webhookHandler.HandleListDeliveries)
+		webhookGroup.POST("/:id/deliveries/:deliveryId/redeliver", webhookHandler.HandleRedeliver)
+	}
+
+	// OAuth
+	oauthGroup := r.Group("/oauth")
+	{
+		oauthGroup.GET("/authorize", authenticate, middleware.RequireUserSession, oauthHandler.HandleAuthorize)
+		oauthGroup.POST("/authorize", authenticate, middleware.RequireUserSession, oauthHandler.HandleAuthorizeDecision)
+		oauthGroup.POST("/token", oauthHandler.HandleToken)
+	}
 
 	// Login
 	r.POST("/login", loginHandler.HandleLogin)
+	r.POST("/login/2fa", loginHandler.HandleTwoFactorLogin)
+	r.GET("/login/unlock", loginHandler.HandleUnlockPage)
+	r.POST("/login/unlock", loginHandler.HandleUnlock)
+
+	// Password
+	r.POST("/password/forgot", passwordHandler.HandleForgotPassword)
+	r.GET("/password/reset", passwordHandler.HandleResetPasswordPage)
+	r.POST("/password/reset", passwordHandler.HandleResetPassword)
 
 	// Bank
-	bankGroup := r.Group("/bank")
+	bankGroup := r.Group("/bank", authenticate)
 	{
-		bankGroup.POST("/new-account", middleware.AuthorizeRequest, bankHandler.HandleNewAccount)
-		bankGroup.GET("/accounts", middleware.AuthorizeRequest, bankHandler.HandleGetAccounts)
-		bankGroup.POST("/deposit", middleware.AuthorizeRequest, bankHandler.HandleDeposit)
-		bankGroup.POST("/withdraw", middleware.AuthorizeRequest, bankHandler.HandleWithdraw)
-		bankGroup.GET("/activity-feed", middleware.AuthorizeRequest, bankHandler.HandleActivityFeed)
+		bankGroup.POST("/new-account", middleware.RequireScope(models.SCOPE_ACCOUNTS_WRITE), bankHandler.HandleNewAccount)
+		bankGroup.GET("/accounts", middleware.RequireScope(models.SCOPE_ACCOUNTS_READ), bankHandler.HandleGetAccounts)
+		bankGroup.POST("/deposit", middleware.RequireScope(models.SCOPE_TRANSACTIONS_WRITE), middleware.RequireVerifiedEmail, bankHandler.HandleDeposit)
+		bankGroup.POST("/withdraw", middleware.RequireScope(models.SCOPE_TRANSACTIONS_WRITE), middleware.RequireVerifiedEmail, bankHandler.HandleWithdraw)
+		bankGroup.GET("/activity-feed", middleware.RequireScope(models.SCOPE_TRANSACTIONS_READ), bankHandler.HandleActivityFeed)
+		bankGroup.GET("/stream", middleware.RequireScope(models.SCOPE_TRANSACTIONS_READ), streamHandler.HandleEventStream)
+		bankGroup.GET("/stream/ws", middleware.RequireScope(models.SCOPE_TRANSACTIONS_READ), streamHandler.HandleWebSocket)
+
+		bankGroup.GET("/invitations", middleware.RequireScope(models.SCOPE_ACCOUNTS_READ), bankHandler.HandleListInvitations)
+
+		memberGroup := bankGroup.Group("/accounts/:accountNumber/members")
+		{
+			memberGroup.GET("", middleware.RequireScope(models.SCOPE_ACCOUNTS_READ), bankHandler.HandleListMembers)
+			memberGroup.POST("", middleware.RequireScope(models.SCOPE_ACCOUNTS_WRITE), bankHandler.HandleInviteMember)
+			memberGroup.POST("/accept", middleware.RequireScope(models.SCOPE_ACCOUNTS_WRITE), bankHandler.HandleAcceptInvitation)
+			memberGroup.PUT("/:userId", middleware.RequireScope(models.SCOPE_ACCOUNTS_WRITE), bankHandler.HandleUpdateMember)
+			memberGroup.DELETE("/:userId", middleware.RequireScope(models.SCOPE_ACCOUNTS_WRITE), bankHandler.HandleRemoveMember)
+		}
 
-		transferGroup := bankGroup.Group("/transfer")
+		transferGroup := bankGroup.Group("/transfer", middleware.RequireScope(models.SCOPE_TRANSFERS_WRITE), middleware.RequireVerifiedEmail)
 		{
-			transferGroup.POST("/send", middleware.AuthorizeRequest, bankHandler.HandleSendTransfer)
-			transferGroup.POST("/accept", middleware.AuthorizeRequest, bankHandler.HandleAcceptTransfer)
+			transferGroup.POST("/send", bankHandler.HandleSendTransfer)
+			transferGroup.POST("/accept", bankHandler.HandleAcceptTransfer)
 		}
 	}
 
+	// GraphQL
+	r.POST("/graphql", authenticate, graphqlHandler.HandleGraphQL)
+
+	// Admin
+	adminGroup := r.Group("/admin", authenticate, middleware.RequireScope(models.SCOPE_ADMIN))
+	{
+		adminGroup.GET("/users", middleware.RequirePermission(models.PERMISSION_READ_USERS), adminHandler.HandleListUsers)
+		adminGroup.GET("/users/:id", middleware.RequirePermission(models.PERMISSION_READ_USERS), adminHandler.HandleGetUser)
+		adminGroup.PUT("/users/:id/role", middleware.RequirePermission(models.PERMISSION_MANAGE_USERS), adminHandler.HandleUpdateRole)
+		adminGroup.POST("/users/:id/unlock", middleware.RequirePermission(models.PERMISSION_MANAGE_USERS), adminHandler.HandleUnlockLogin)
+		adminGroup.POST("/users/:id/erase", middleware.RequirePermission(models.PERMISSION_MANAGE_USERS), privacyHandler.HandleEraseUser)
+		adminGroup.GET("/data-requests", middleware.RequirePermission(models.PERMISSION_READ_USERS), privacyHandler.HandleAdminListDataRequests)
+		adminGroup.GET("/accounts/:accountNumber/transactions", middleware.RequirePermission(models.PERMISSION_READ_TRANSACTIONS), adminHandler.HandleAccountTransactions)
+		adminGroup.POST("/accounts/:accountNumber/freeze", middleware.RequirePermission(models.PERMISSION_FREEZE_ACCOUNTS), adminHandler.HandleFreezeAccount)
+		adminGroup.POST("/accounts/:accountNumber/unfreeze", middleware.RequirePermission(models.PERMISSION_FREEZE_ACCOUNTS), adminHandler.HandleUnfreezeAccount)
+		adminGroup.GET("/audit-logs", middleware.RequirePermission(models.PERMISSION_READ_AUDIT_LOG), auditHandler.HandleListAuditLogs)
+		adminGroup.GET("/ledger/verify", middleware.RequirePermission(models.PERMISSION_READ_AUDIT_LOG), ledgerHandler.HandleVerifyLedger)
+		adminGroup.POST("/oauth/clients", middleware.RequirePermission(models.PERMISSION_MANAGE_OAUTH), oauthHandler.HandleRegisterClient)
+	}
+
 	return r
 }
 
@@ -67,7 +243,14 @@ func (s *Server) Start() {
 
 func NewServer(db *gorm.DB, port string) *Server {
 	return &Server{
-		db:   db,
-		Port: port,
+		db:            db,
+		streamService: services.NewStreamService(db),
+		Port:          port,
 	}
 }
+
+// StreamService is the server's real-time stream, which must also be given
+// to the outbox relay as a sink.
+func (s *Server) StreamService() *services.StreamService {
+	return s.streamService
+}
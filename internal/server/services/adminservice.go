@@ -0,0 +1,453 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAccountNotEmpty  = errors.New("account balance must be zero to close it")
+	ErrPendingTransfers = errors.New("account has pending transfers")
+	ErrReasonRequired   = errors.New("a reason is required")
+	ErrZeroAdjustment   = errors.New("adjustment amount must not be zero")
+	ErrOwnRole          = errors.New("you cannot change your own role")
+	ErrLastAdmin        = errors.New("at least one admin must remain")
+	errAccountNotFound  = errors.New("account not found")
+)
+
+type AdminService struct {
+	store repository.Store
+}
+
+func NewAdminService(store repository.Store) *AdminService {
+	return &AdminService{store: store}
+}
+
+// ListUsers returns a page of users, optionally filtered by an email
+// substring, along with the total number of matches.
+func (s *AdminService) ListUsers(email string, page int, pageSize int) ([]models.User, int64, error) {
+	users, total, err := s.store.Users().List(email, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list users")
+	}
+
+	for i := range users {
+		users[i].Password = ""
+	}
+
+	return users, total, nil
+}
+
+func (s *AdminService) GetUser(userID uint) (models.User, error) {
+	user, err := s.store.Users().Get(userID)
+	if err != nil {
+		return user, fmt.Errorf("user not found")
+	}
+
+	user.Password = ""
+	return user, nil
+}
+
+// GetUserByEmail looks a user up by their exact address, ignoring case.
+func (s *AdminService) GetUserByEmail(email string) (models.User, error) {
+	user, err := s.store.Users().GetByEmail(email)
+	if err != nil {
+		return user, fmt.Errorf("user not found")
+	}
+
+	user.Password = ""
+	return user, nil
+}
+
+func (s *AdminService) GetUserAccounts(userID uint) ([]models.BankAccount, error) {
+	accounts, err := s.store.Accounts().ListForMember(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts")
+	}
+
+	return accounts, nil
+}
+
+func (s *AdminService) GetAccount(accountNumber string) (models.BankAccount, error) {
+	account, err := s.store.Accounts().Get(accountNumber)
+	if err != nil {
+		return account, errAccountNotFound
+	}
+
+	return account, nil
+}
+
+func (s *AdminService) GetAccountTransactions(accountNumber string, page int, pageSize int) ([]models.Transaction, error) {
+	if _, err := s.store.Accounts().Get(accountNumber); err != nil {
+		return nil, fmt.Errorf("account not found")
+	}
+
+	transactions, err := s.store.Transactions().ListForAccount(accountNumber, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions")
+	}
+
+	return transactions, nil
+}
+
+// SetAccountFrozen freezes or unfreezes an account. Frozen accounts reject
+// deposits, withdrawals and transfers.
+func (s *AdminService) SetAccountFrozen(actor models.Actor, accountNumber string, frozen bool) (models.BankAccount, error) {
+	account, err := s.GetAccount(accountNumber)
+	if err != nil {
+		return account, err
+	}
+
+	action := models.AUDIT_ACCOUNT_UNFREEZE
+	var event models.DomainEvent = models.AccountUnfrozen{AccountNumber: account.AccountNumber}
+	var frozenAt *time.Time
+	if frozen {
+		now := time.Now()
+		frozenAt = &now
+		action = models.AUDIT_ACCOUNT_FREEZE
+		event = models.AccountFrozen{AccountNumber: account.AccountNumber}
+	}
+
+	before := account
+	if err := s.store.Transaction(func(tx repository.Store) error {
+		locked, err := tx.Accounts().GetForUpdate(accountNumber)
+		if err != nil {
+			return err
+		}
+
+		before, account = locked, locked
+		account.FrozenAt = frozenAt
+		if err := tx.Accounts().Save(&account); err != nil {
+			return err
+		}
+
+		if err := recordAudit(tx, actor, action, models.ENTITY_ACCOUNT, account.AccountNumber, before, account); err != nil {
+			return err
+		}
+
+		return recordEvent(tx, event)
+	}); err != nil {
+		return before, fmt.Errorf("failed to update account")
+	}
+
+	return account, nil
+}
+
+// CloseAccount closes an empty account with no outgoing transfers awaiting
+// acceptance. Closed accounts reject every transaction.
+func (s *AdminService) CloseAccount(actor models.Actor, accountNumber string) (models.BankAccount, error) {
+	account, err := s.GetAccount(accountNumber)
+	if err != nil {
+		return account, err
+	}
+
+	if account.IsClosed() {
+		return account, ErrAccountClosed
+	}
+
+	before := account
+	now := time.Now()
+	if err := s.store.Transaction(func(tx repository.Store) error {
+		// The lock keeps money from arriving between the checks and the close.
+		locked, err := tx.Accounts().GetForUpdate(accountNumber)
+		if err != nil {
+			return err
+		}
+		if locked.Balance != 0 {
+			return ErrAccountNotEmpty
+		}
+
+		pending, err := tx.Transfers().CountSentFrom(accountNumber, PENDING)
+		if err != nil {
+			return err
+		}
+		if pending > 0 {
+			return ErrPendingTransfers
+		}
+
+		before, account = locked, locked
+		account.ClosedAt = &now
+		if err := tx.Accounts().Save(&account); err != nil {
+			return err
+		}
+
+		if err := recordAudit(tx, actor, models.AUDIT_ACCOUNT_CLOSE, models.ENTITY_ACCOUNT, account.AccountNumber, before, account); err != nil {
+			return err
+		}
+
+		return recordEvent(tx, models.AccountClosed{AccountNumber: account.AccountNumber})
+	}); err != nil {
+		if errors.Is(err, ErrAccountNotEmpty) || errors.Is(err, ErrPendingTransfers) {
+			return before, err
+		}
+		return before, fmt.Errorf("failed to close account")
+	}
+
+	return account, nil
+}
+
+// AdjustBalance posts a manual correction to an account, a credit when
+// amount is positive and a debit when it is negative. The reason is kept in
+// the audit log and on the published event.
+func (s *AdminService) AdjustBalance(actor models.Actor, accountNumber string, amount float64, reason string) (models.BankAccount, models.Transaction, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return models.BankAccount{}, models.Transaction{}, ErrReasonRequired
+	}
+
+	if amount == 0 || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return models.BankAccount{}, models.Transaction{}, ErrZeroAdjustment
+	}
+
+	account, err := s.GetAccount(accountNumber)
+	if err != nil {
+		return account, models.Transaction{}, err
+	}
+
+	if account.IsClosed() {
+		return account, models.Transaction{}, ErrAccountClosed
+	}
+
+	adjustment := models.Transaction{
+		Amount:        math.Abs(amount),
+		AccountNumber: accountNumber,
+		TransactionID: uuid.New().String(),
+		Type:          ADJUSTMENT_CREDIT,
+	}
+	if amount < 0 {
+		adjustment.Type = ADJUSTMENT_DEBIT
+	}
+
+	before := account
+	if err := s.store.Transaction(func(tx repository.Store) error {
+		locked, err := chainTransaction(tx, &adjustment)
+		if err != nil {
+			return err
+		}
+
+		before, account = locked, locked
+		account.Balance += amount
+		if account.Balance < 0 {
+			return ErrInsufficientBalance
+		}
+
+		if err := tx.Accounts().Save(&account); err != nil {
+			return err
+		}
+
+		if err := tx.Transactions().Create(&adjustment); err != nil {
+			return err
+		}
+
+		reasoned := struct {
+			models.Transaction
+			Reason string `json:"reason"`
+		}{adjustment, reason}
+		if err := recordAudit(tx, actor, models.AUDIT_ACCOUNT_ADJUST, models.ENTITY_TRANSACTION, adjustment.TransactionID, nil, reasoned); err != nil {
+			return err
+		}
+
+		if err := recordAudit(tx, actor, models.AUDIT_ACCOUNT_ADJUST, models.ENTITY_ACCOUNT, accountNumber, before, account); err != nil {
+			return err
+		}
+
+		return recordEvent(tx, models.BalanceAdjusted{
+			AccountNumber: accountNumber,
+			TransactionID: adjustment.TransactionID,
+			Amount:        amount,
+			Balance:       account.Balance,
+			Reason:        reason,
+		})
+	}); err != nil {
+		if errors.Is(err, ErrInsufficientBalance) {
+			return before, models.Transaction{}, err
+		}
+		return before, models.Transaction{}, domainError(err, fmt.Errorf("failed to adjust balance"))
+	}
+
+	return account, adjustment, nil
+}
+
+// ExpireTransfers refunds the pending transfers that expired before now to
+// the accounts they were sent from and marks them expired. Transfers
+// accepted in the meantime are skipped.
+func (s *AdminService) ExpireTransfers(actor models.Actor, now time.Time) ([]models.Transfer, error) {
+	due, err := s.store.Transfers().ListDue(PENDING, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transfers")
+	}
+
+	expired := []models.Transfer{}
+	for _, transfer := range due {
+		if err := s.expireTransfer(actor, &transfer); err != nil {
+			if errors.Is(err, ErrTransferNotPending) {
+				continue
+			}
+			return expired, fmt.Errorf("failed to expire transfer %s", transfer.TransactionID)
+		}
+		expired = append(expired, transfer)
+	}
+
+	return expired, nil
+}
+
+func (s *AdminService) expireTransfer(actor models.Actor, transfer *models.Transfer) error {
+	return s.store.Transaction(func(tx repository.Store) error {
+		locked, err := tx.Transfers().GetForUpdate(transfer.TransactionID)
+		if err != nil {
+			return err
+		}
+		if locked.Status != PENDING {
+			return ErrTransferNotPending
+		}
+
+		// The sender's transaction shares the transfer's id and names the
+		// account the money left.
+		sent, err := tx.Transactions().Get(locked.TransactionID)
+		if err != nil {
+			return err
+		}
+
+		refund := models.Transaction{
+			Amount:        locked.Amount,
+			AccountNumber: sent.AccountNumber,
+			TransactionID: uuid.New().String(),
+			Type:          REFUND,
+		}
+		account, err := chainTransaction(tx, &refund)
+		if err != nil {
+			return err
+		}
+
+		beforeAccount, beforeTransfer := account, locked
+		account.Balance += refund.Amount
+		locked.Status = EXPIRED
+
+		if err := tx.Accounts().Save(&account); err != nil {
+			return err
+		}
+
+		if err := tx.Transactions().Create(&refund); err != nil {
+			return err
+		}
+
+		if err := tx.Transfers().Save(&locked); err != nil {
+			return err
+		}
+
+		if err := recordAudit(tx, actor, models.AUDIT_TRANSFER_EXPIRE, models.ENTITY_TRANSFER, locked.TransactionID, beforeTransfer, locked); err != nil {
+			return err
+		}
+
+		if err := recordAudit(tx, actor, models.AUDIT_TRANSFER_EXPIRE, models.ENTITY_ACCOUNT, account.AccountNumber, beforeAccount, account); err != nil {
+			return err
+		}
+
+		if err := recordEvent(tx, models.TransferExpired{
+			AccountNumber: account.AccountNumber,
+			TransactionID: locked.TransactionID,
+			SenderID:      locked.SenderID,
+			ReceiverID:    locked.ReceiverID,
+			Amount:        locked.Amount,
+			Balance:       account.Balance,
+		}); err != nil {
+			return err
+		}
+
+		*transfer = locked
+		return nil
+	})
+}
+
+// UpdateRole changes a user's role and revokes their existing tokens, which
+// still carry the old role. Staff cannot change their own role, and the last
+// admin cannot be demoted.
+func (s *AdminService) UpdateRole(actor models.Actor, userID uint, role string) (models.User, error) {
+	if !models.IsValidRole(role) {
+		return models.User{}, fmt.Errorf("invalid role")
+	}
+	if actor.UserID != 0 && actor.UserID == userID {
+		return models.User{}, ErrOwnRole
+	}
+
+	user, err := s.GetUser(userID)
+	if err != nil {
+		return user, err
+	}
+
+	before := user.Response()
+	if err := s.store.Transaction(func(tx repository.Store) error {
+		if user.Role == models.ROLE_ADMIN && role != models.ROLE_ADMIN {
+			// Locking the admins serialises concurrent demotions.
+			admins, err := tx.Users().ListByRoleForUpdate(models.ROLE_ADMIN)
+			if err != nil {
+				return err
+			}
+			if !slices.ContainsFunc(admins, func(admin models.User) bool { return admin.ID != user.ID }) {
+				return ErrLastAdmin
+			}
+		}
+
+		user.Role = role
+		user.SessionsValidFrom = time.Now()
+		if err := tx.Users().Update(&user, "Role", "SessionsValidFrom"); err != nil {
+			return err
+		}
+
+		return recordAudit(tx, actor, models.AUDIT_USER_ROLE_UPDATE, models.ENTITY_USER, user.ID, before, user.Response())
+	}); err != nil {
+		if errors.Is(err, ErrLastAdmin) {
+			return user, err
+		}
+		return user, fmt.Errorf("failed to update role")
+	}
+
+	return user, nil
+}
+
+// VerifyEmail marks a user's email address as verified, for users staff
+// have checked some other way.
+func (s *AdminService) VerifyEmail(actor models.Actor, userID uint) (models.User, error) {
+	user, err := s.GetUser(userID)
+	if err != nil {
+		return user, err
+	}
+
+	if user.IsEmailVerified() {
+		return user, nil
+	}
+
+	before := user.Response()
+	now := time.Now()
+	if err := s.store.Transaction(func(tx repository.Store) error {
+		user.EmailVerifiedAt = &now
+		if err := tx.Users().Update(&user, "EmailVerifiedAt"); err != nil {
+			return err
+		}
+
+		return recordAudit(tx, actor, models.AUDIT_USER_EMAIL_VERIFY, models.ENTITY_USER, user.ID, before, user.Response())
+	}); err != nil {
+		return user, fmt.Errorf("failed to verify email")
+	}
+
+	return user, nil
+}
+
+func (s *AdminService) UnlockLogin(actor models.Actor, userID uint) error {
+	user, err := s.GetUser(userID)
+	if err != nil {
+		return err
+	}
+
+	return s.store.Transaction(func(tx repository.Store) error {
+		return unlockLogin(tx, normalizeEmail(user.Email), &user.ID, actor)
+	})
+}
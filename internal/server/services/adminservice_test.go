@@ -0,0 +1,205 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateRole(t *testing.T) {
+	store := repository.NewMemoryStore()
+	service := NewAdminService(store)
+	admin := createTestUser(t, store, "admin@example.com")
+	customer := createTestUser(t, store, "customer@example.com")
+
+	// Bootstrapping the first admin has no operator to check against.
+	admin, err := service.UpdateRole(models.Actor{}, admin.ID, models.ROLE_ADMIN)
+	assert.NoError(t, err)
+
+	_, err = service.UpdateRole(actorFor(admin), admin.ID, models.ROLE_SUPPORT)
+	assert.ErrorIs(t, err, ErrOwnRole)
+
+	_, err = service.UpdateRole(actorFor(admin), customer.ID, "superuser")
+	assert.EqualError(t, err, "invalid role")
+
+	customer, err = service.UpdateRole(actorFor(admin), customer.ID, models.ROLE_SUPPORT)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ROLE_SUPPORT, customer.Role)
+	assert.False(t, customer.SessionsValidFrom.IsZero())
+
+	stored, err := store.Users().Get(customer.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ROLE_SUPPORT, stored.Role)
+	assert.NotEmpty(t, stored.Password)
+}
+
+func TestUpdateRoleKeepsAnAdmin(t *testing.T) {
+	store := repository.NewMemoryStore()
+	service := NewAdminService(store)
+	first := createTestUser(t, store, "first@example.com")
+	second := createTestUser(t, store, "second@example.com")
+
+	first, err := service.UpdateRole(models.Actor{}, first.ID, models.ROLE_ADMIN)
+	assert.NoError(t, err)
+
+	// Without an operator the self-check cannot apply, so the count must.
+	_, err = service.UpdateRole(models.Actor{}, first.ID, models.ROLE_CUSTOMER)
+	assert.ErrorIs(t, err, ErrLastAdmin)
+
+	second, err = service.UpdateRole(actorFor(first), second.ID, models.ROLE_ADMIN)
+	assert.NoError(t, err)
+
+	first, err = service.UpdateRole(actorFor(second), first.ID, models.ROLE_CUSTOMER)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ROLE_CUSTOMER, first.Role)
+}
+
+func TestListUsers(t *testing.T) {
+	store := repository.NewMemoryStore()
+	service := NewAdminService(store)
+	createTestUser(t, store, "ann@example.com")
+	createTestUser(t, store, "bob@example.com")
+	createTestUser(t, store, "ann@example.org")
+
+	users, total, err := service.ListUsers("ann@", 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, users, 1)
+	assert.Equal(t, "ann@example.com", users[0].Email)
+	assert.Empty(t, users[0].Password)
+
+	users, _, err = service.ListUsers("ann@", 2, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "ann@example.org", users[0].Email)
+
+	user, err := service.GetUserByEmail(" BOB@example.com")
+	assert.NoError(t, err)
+	assert.Empty(t, user.Password)
+
+	_, err = service.GetUser(999)
+	assert.EqualError(t, err, "user not found")
+}
+
+func TestSetAccountFrozen(t *testing.T) {
+	_, store, user, account := newTestBank(t, 10)
+	service := NewAdminService(store)
+
+	account, err := service.SetAccountFrozen(models.Actor{}, account.AccountNumber, true)
+	assert.NoError(t, err)
+	assert.True(t, account.IsFrozen())
+	assert.Equal(t, 10.0, account.Balance)
+
+	accounts, err := service.GetUserAccounts(user.ID)
+	assert.NoError(t, err)
+	assert.Len(t, accounts, 1)
+	assert.True(t, accounts[0].IsFrozen())
+
+	account, err = service.SetAccountFrozen(models.Actor{}, account.AccountNumber, false)
+	assert.NoError(t, err)
+	assert.False(t, account.IsFrozen())
+
+	assert.Contains(t, auditActions(store), models.AUDIT_ACCOUNT_FREEZE)
+	assert.Contains(t, eventTypes(store), models.EVENT_ACCOUNT_FROZEN)
+	assert.Contains(t, eventTypes(store), models.EVENT_ACCOUNT_UNFROZEN)
+
+	_, err = service.SetAccountFrozen(models.Actor{}, "missing", true)
+	assert.EqualError(t, err, "account not found")
+}
+
+func TestCloseAccount(t *testing.T) {
+	bank, store, user, account := newTestBank(t, 10)
+	service := NewAdminService(store)
+	receiver := createTestUser(t, store, "receiver@example.com")
+
+	_, err := service.CloseAccount(models.Actor{}, account.AccountNumber)
+	assert.ErrorIs(t, err, ErrAccountNotEmpty)
+
+	_, err = bank.SendTransfer(models.OutgoingTransfer{AccountNumber: account.AccountNumber, ReceiverID: receiver.ID, Amount: 10}, actorFor(user))
+	assert.NoError(t, err)
+
+	_, err = service.CloseAccount(models.Actor{}, account.AccountNumber)
+	assert.ErrorIs(t, err, ErrPendingTransfers)
+
+	expired, err := service.ExpireTransfers(models.Actor{}, time.Now().Add(31*24*time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, expired, 1)
+	assert.Equal(t, EXPIRED, expired[0].Status)
+
+	account, _, err = service.AdjustBalance(models.Actor{}, account.AccountNumber, -10, "closing the account")
+	assert.NoError(t, err)
+
+	account, err = service.CloseAccount(models.Actor{}, account.AccountNumber)
+	assert.NoError(t, err)
+	assert.True(t, account.IsClosed())
+	assert.Contains(t, eventTypes(store), models.EVENT_ACCOUNT_CLOSED)
+
+	_, err = service.CloseAccount(models.Actor{}, account.AccountNumber)
+	assert.ErrorIs(t, err, ErrAccountClosed)
+}
+
+func TestExpireTransfersRefundsTheSender(t *testing.T) {
+	bank, store, user, account := newTestBank(t, 50)
+	service := NewAdminService(store)
+	receiver := createTestUser(t, store, "receiver@example.com")
+
+	_, err := bank.SendTransfer(models.OutgoingTransfer{AccountNumber: account.AccountNumber, ReceiverID: receiver.ID, Amount: 20}, actorFor(user))
+	assert.NoError(t, err)
+
+	expired, err := service.ExpireTransfers(models.Actor{}, time.Now())
+	assert.NoError(t, err)
+	assert.Empty(t, expired)
+
+	expired, err = service.ExpireTransfers(models.Actor{}, time.Now().Add(31*24*time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, expired, 1)
+
+	account, err = service.GetAccount(account.AccountNumber)
+	assert.NoError(t, err)
+	assert.Equal(t, 50.0, account.Balance)
+
+	transactions, err := service.GetAccountTransactions(account.AccountNumber, 1, 10)
+	assert.NoError(t, err)
+	assert.Len(t, transactions, 3)
+	assert.Equal(t, REFUND, transactions[0].Type)
+	assert.Contains(t, eventTypes(store), models.EVENT_TRANSFER_EXPIRED)
+}
+
+func TestAdjustBalance(t *testing.T) {
+	_, store, _, account := newTestBank(t, 10)
+	service := NewAdminService(store)
+
+	_, _, err := service.AdjustBalance(models.Actor{}, account.AccountNumber, 5, " ")
+	assert.ErrorIs(t, err, ErrReasonRequired)
+
+	_, _, err = service.AdjustBalance(models.Actor{}, account.AccountNumber, 0, "nothing")
+	assert.ErrorIs(t, err, ErrZeroAdjustment)
+
+	_, _, err = service.AdjustBalance(models.Actor{}, account.AccountNumber, -11, "too much")
+	assert.ErrorIs(t, err, ErrInsufficientBalance)
+
+	account, adjustment, err := service.AdjustBalance(models.Actor{}, account.AccountNumber, -4, "fee refund reversal")
+	assert.NoError(t, err)
+	assert.Equal(t, 6.0, account.Balance)
+	assert.Equal(t, ADJUSTMENT_DEBIT, adjustment.Type)
+	assert.Equal(t, 4.0, adjustment.Amount)
+	assert.Contains(t, eventTypes(store), models.EVENT_BALANCE_ADJUSTED)
+}
+
+func TestAdminVerifyEmail(t *testing.T) {
+	store := repository.NewMemoryStore()
+	service := NewAdminService(store)
+	user := models.User{Email: "unverified@example.com", Role: models.ROLE_CUSTOMER}
+	assert.NoError(t, store.Users().Create(&user))
+
+	user, err := service.VerifyEmail(models.Actor{}, user.ID)
+	assert.NoError(t, err)
+	assert.True(t, user.IsEmailVerified())
+
+	stored, err := store.Users().Get(user.ID)
+	assert.NoError(t, err)
+	assert.True(t, stored.IsEmailVerified())
+	assert.Equal(t, []string{models.AUDIT_USER_EMAIL_VERIFY}, auditActions(store))
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/repository"
+	"github.com/FaizanAC/Go-Banking/internal/util"
+	"gorm.io/gorm"
+)
+
+type APIKeyService struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyService(db *gorm.DB) *APIKeyService {
+	return &APIKeyService{db: db}
+}
+
+// CreateAPIKey issues a key for the user. The plain key is only part of the
+// returned response and cannot be retrieved again.
+func (s *APIKeyService) CreateAPIKey(actor models.Actor, request models.NewAPIKey) (models.APIKeyResponse, error) {
+	var user models.User
+	if err := s.db.First(&user, actor.UserID).Error; err != nil {
+		return models.APIKeyResponse{}, fmt.Errorf("user not found")
+	}
+
+	for _, scope := range request.Scopes {
+		if !models.IsValidScope(scope) {
+			return models.APIKeyResponse{}, fmt.Errorf("unknown scope %q", scope)
+		}
+
+		if scope == models.SCOPE_ADMIN && !models.HasPermission(user.Role, models.PERMISSION_READ_USERS) {
+			return models.APIKeyResponse{}, fmt.Errorf("only staff can create keys with the admin scope")
+		}
+	}
+
+	for _, entry := range request.AllowedIPs {
+		if _, _, err := net.ParseCIDR(entry); err != nil && net.ParseIP(entry) == nil {
+			return models.APIKeyResponse{}, fmt.Errorf("invalid IP address or range %q", entry)
+		}
+	}
+
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return models.APIKeyResponse{}, fmt.Errorf("expiry must be in the future")
+	}
+
+	rawKey, prefix, err := util.GenerateAPIKey()
+	if err != nil {
+		return models.APIKeyResponse{}, fmt.Errorf("failed to generate API key")
+	}
+
+	apiKey := models.APIKey{
+		UserID:     actor.UserID,
+		Name:       request.Name,
+		Prefix:     prefix,
+		KeyHash:    util.HashToken(rawKey),
+		Scopes:     strings.Join(request.Scopes, ","),
+		AllowedIPs: strings.Join(request.AllowedIPs, ","),
+		ExpiresAt:  request.ExpiresAt,
+	}
+
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&apiKey).Error; err != nil {
+			return err
+		}
+
+		return recordAudit(repository.NewGormStore(tx), actor, models.AUDIT_API_KEY_CREATE, models.ENTITY_API_KEY, apiKey.ID, nil, apiKey.Response())
+	}); err != nil {
+		return models.APIKeyResponse{}, fmt.Errorf("failed to create API key")
+	}
+
+	response := apiKey.Response()
+	response.Key = rawKey
+	return response, nil
+}
+
+func (s *APIKeyService) ListAPIKeys(userID uint) ([]models.APIKeyResponse, error) {
+	var apiKeys []models.APIKey
+	if err := s.db.Where("user_id = ?", userID).Order("created_at desc").Find(&apiKeys).Error; err != nil {
+		return nil, fmt.Errorf("failed to list API keys")
+	}
+
+	responses := make([]models.APIKeyResponse, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		responses = append(responses, apiKey.Response())
+	}
+
+	return responses, nil
+}
+
+func (s *APIKeyService) RevokeAPIKey(actor models.Actor, keyID uint) error {
+	var apiKey models.APIKey
+	if err := s.db.Where("id = ? AND user_id = ? AND revoked_at IS NULL", keyID, actor.UserID).First(&apiKey).Error; err != nil {
+		return fmt.Errorf("API key not found")
+	}
+
+	before := apiKey.Response()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		res := tx.Model(&apiKey).Where("revoked_at IS NULL").Update("revoked_at", &now)
+		if res.Error != nil {
+			return fmt.Errorf("failed to revoke API key")
+		}
+
+		if res.RowsAffected == 0 {
+			return fmt.Errorf("API key not found")
+		}
+
+		apiKey.RevokedAt = &now
+		if err := recordAudit(repository.NewGormStore(tx), actor, models.AUDIT_API_KEY_REVOKE, models.ENTITY_API_KEY, apiKey.ID, before, apiKey.Response()); err != nil {
+			return fmt.Errorf("failed to revoke API key")
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/repository"
+	"gorm.io/gorm"
+)
+
+type AuditService struct {
+	db *gorm.DB
+}
+
+func NewAuditService(db *gorm.DB) *AuditService {
+	return &AuditService{db: db}
+}
+
+// ListAuditLogs returns a page of audit entries matching the filter, newest
+// first, along with the total number of matches.
+func (s *AuditService) ListAuditLogs(filter models.AuditLogFilter, page int, pageSize int) ([]models.AuditLog, int64, error) {
+	query := s.db.Model(&models.AuditLog{})
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit logs")
+	}
+
+	var logs []models.AuditLog
+	if err := query.Order("id desc").Offset((page - 1) * pageSize).Limit(pageSize).Find(&logs).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit logs")
+	}
+
+	return logs, total, nil
+}
+
+// recordAudit appends an audit entry using tx, so that it commits or rolls
+// back together with the change it describes.
+func recordAudit(tx repository.Store, actor models.Actor, action string, entityType string, entityID interface{}, before interface{}, after interface{}) error {
+	entry := models.AuditLog{
+		AuthMethod: actor.AuthMethod,
+		IP:         actor.IP,
+		UserAgent:  actor.UserAgent,
+		RequestID:  actor.RequestID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   fmt.Sprint(entityID),
+	}
+
+	if actor.UserID != 0 {
+		actorID := actor.UserID
+		entry.ActorID = &actorID
+	}
+
+	var err error
+	if entry.Before, entry.After, err = auditSnapshots(before, after); err != nil {
+		return err
+	}
+
+	return tx.Journal().CreateAuditLog(&entry)
+}
+
+// personalAuditFields are left out of audit snapshots. The log is
+// append-only, so anything written to it would survive erasure; entries keep
+// the entity's ID and, in "changed", the names of the fields that changed.
+var personalAuditFields = []string{"email", "firstName", "lastName", "ip", "userAgent"}
+
+func auditSnapshots(before interface{}, after interface{}) (json.RawMessage, json.RawMessage, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	changed := []string{}
+	for _, field := range personalAuditFields {
+		oldValue, inBefore := beforeFields[field]
+		newValue, inAfter := afterFields[field]
+		if beforeFields != nil && afterFields != nil && (inBefore || inAfter) && string(oldValue) != string(newValue) {
+			changed = append(changed, field)
+		}
+		delete(beforeFields, field)
+		delete(afterFields, field)
+	}
+
+	if len(changed) > 0 {
+		afterFields["changed"], _ = json.Marshal(changed)
+	}
+
+	beforeSnapshot, err := auditSnapshot(before, beforeFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterSnapshot, err := auditSnapshot(after, afterFields)
+	return beforeSnapshot, afterSnapshot, err
+}
+
+// auditFields splits a snapshot into its top-level JSON fields, or returns
+// nil when it is not a JSON object.
+func auditFields(value interface{}) (map[string]json.RawMessage, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) != nil {
+		return nil, nil
+	}
+	return fields, nil
+}
+
+func auditSnapshot(value interface{}, fields map[string]json.RawMessage) (json.RawMessage, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if fields == nil {
+		return json.Marshal(value)
+	}
+	return json.Marshal(fields)
+}
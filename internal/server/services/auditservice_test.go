@@ -0,0 +1,40 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditSnapshots(t *testing.T) {
+	before, after, err := auditSnapshots(nil, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, before)
+	assert.Nil(t, after)
+
+	user := models.User{Email: "test@example.com", FirstName: "Test", Password: "hash", Role: models.ROLE_CUSTOMER}
+	_, after, err = auditSnapshots(nil, user.Response())
+	assert.NoError(t, err)
+	assert.Contains(t, string(after), `"role":"customer"`)
+	assert.NotContains(t, string(after), "test@example.com")
+	assert.NotContains(t, string(after), "Test")
+	assert.NotContains(t, string(after), "hash")
+	assert.NotContains(t, string(after), "changed")
+}
+
+func TestAuditSnapshotsNameChangedPersonalFields(t *testing.T) {
+	old := models.User{Email: "old@example.com", FirstName: "Test", Role: models.ROLE_CUSTOMER}
+	updated := models.User{Email: "new@example.com", FirstName: "Test", Role: models.ROLE_SUPPORT}
+
+	before, after, err := auditSnapshots(old.Response(), updated.Response())
+	assert.NoError(t, err)
+	assert.Contains(t, string(before), `"role":"customer"`)
+	assert.Contains(t, string(after), `"role":"support"`)
+	assert.Contains(t, string(after), `"changed":["email"]`)
+	assert.NotContains(t, string(before)+string(after), "example.com")
+
+	_, after, err = auditSnapshots(nil, map[string]interface{}{"sessionsRevoked": true})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"sessionsRevoked":true}`, string(after))
+}
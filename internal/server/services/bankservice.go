@@ -1,20 +1,24 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/repository"
 	"github.com/FaizanAC/Go-Banking/internal/util"
 	"github.com/google/uuid"
 	"golang.org/x/sync/errgroup"
-	"gorm.io/gorm"
 )
 
 const (
-	DEPOSIT  = "DEPOSIT"
-	WITHDRAW = "WITHDRAW"
-	TRANSFER = "TRANSFER"
+	DEPOSIT           = "DEPOSIT"
+	WITHDRAW          = "WITHDRAW"
+	TRANSFER          = "TRANSFER"
+	ADJUSTMENT_CREDIT = "ADJUSTMENT_CREDIT"
+	ADJUSTMENT_DEBIT  = "ADJUSTMENT_DEBIT"
+	REFUND            = "REFUND"
 )
 
 const (
@@ -23,22 +27,73 @@ const (
 	EXPIRED  = "EXPIRED"
 )
 
+var (
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrInvalidAmount       = errors.New("amount must be positive")
+	ErrReceiverNotFound    = errors.New("receiver not found")
+	ErrAccountClosed       = errors.New("account is closed")
+	ErrTransferNotPending  = errors.New("transfer is no longer pending")
+	ErrAccountFrozen       = errors.New("account is frozen")
+)
+
+// lockedErrors are the domain errors found by checks made on the locked
+// account row inside a transaction.
+var lockedErrors = []error{ErrAccountClosed, ErrAccountFrozen, ErrInsufficientBalance, ErrTransferNotPending}
+
+// checkErrors are the domain errors behind the check constraints the
+// services can run into.
+var checkErrors = map[string]error{
+	repository.CHECK_ACCOUNT_BALANCE:    ErrInsufficientBalance,
+	repository.CHECK_TRANSACTION_AMOUNT: ErrInvalidAmount,
+	repository.CHECK_TRANSFER_AMOUNT:    ErrInvalidAmount,
+}
+
 type BankService struct {
-	db *gorm.DB
+	store repository.Store
+}
+
+func NewBankService(store repository.Store) *BankService {
+	return &BankService{store}
 }
 
-func NewBankService(db *gorm.DB) *BankService {
-	return &BankService{db}
+func (s *BankService) CreateAccount(actor models.Actor) (models.BankAccount, error) {
+	return s.CreateAccountFor(actor.UserID, actor)
 }
 
-func (s *BankService) CreateAccount(userID uint) (models.BankAccount, error) {
+// CreateAccountFor opens an account owned by ownerID on behalf of actor,
+// which is how staff open accounts for customers.
+func (s *BankService) CreateAccountFor(ownerID uint, actor models.Actor) (models.BankAccount, error) {
 	newAccount := models.BankAccount{
 		AccountNumber: util.GenerateAccountNumber(),
-		UserID:        userID,
+		UserID:        ownerID,
 		Balance:       0,
 	}
 
-	if res := s.db.Create(&newAccount); res.Error != nil {
+	now := time.Now()
+	if err := s.store.Transaction(func(tx repository.Store) error {
+		if err := tx.Accounts().Create(&newAccount); err != nil {
+			return err
+		}
+
+		if err := tx.Accounts().CreateMember(&models.AccountMember{
+			AccountNumber: newAccount.AccountNumber,
+			UserID:        ownerID,
+			Role:          models.MEMBER_OWNER,
+			InvitedBy:     ownerID,
+			AcceptedAt:    &now,
+		}); err != nil {
+			return err
+		}
+
+		if err := recordAudit(tx, actor, models.AUDIT_ACCOUNT_CREATE, models.ENTITY_ACCOUNT, newAccount.AccountNumber, nil, newAccount); err != nil {
+			return err
+		}
+
+		return recordEvent(tx, models.AccountOpened{AccountNumber: newAccount.AccountNumber, OwnerID: ownerID})
+	}); err != nil {
+		if errors.Is(err, repository.ErrMissingReference) {
+			return newAccount, fmt.Errorf("user not found")
+		}
 		return newAccount, fmt.Errorf("failed to create account")
 	}
 
@@ -46,88 +101,134 @@ func (s *BankService) CreateAccount(userID uint) (models.BankAccount, error) {
 }
 
 func (s *BankService) GetAccountsByUserID(userID uint) ([]models.BankAccount, error) {
-	var allAccounts []models.BankAccount
-
-	if err := s.db.Where("user_id = ?", userID).Find(&allAccounts).Error; err != nil {
+	allAccounts, err := s.store.Accounts().ListForMember(userID)
+	if err != nil {
 		return allAccounts, fmt.Errorf("user has no accounts")
 	}
 
 	return allAccounts, nil
 }
 
-func (s *BankService) DepositToAccount(deposit models.Transaction, userID uint) (models.BankAccount, error) {
-	var account models.BankAccount
-	if err := s.db.Where("account_number = ?", deposit.AccountNumber).First(&account).Error; err != nil {
-		return account, fmt.Errorf("account not found")
+func (s *BankService) DepositToAccount(deposit models.Transaction, actor models.Actor) (models.BankAccount, error) {
+	account, member, err := s.accountForMember(deposit.AccountNumber, actor.UserID)
+	if err != nil {
+		return account, err
 	}
 
-	if account.UserID != userID {
-		return account, fmt.Errorf("you are not the owner of this account")
+	if !member.CanTransact() {
+		return account, fmt.Errorf("you are not allowed to transact on this account")
 	}
 
-	account.Balance += deposit.Amount
-
 	deposit.Type = DEPOSIT
 	deposit.TransactionID = uuid.New().String()
 
 	eg := errgroup.Group{}
-	if err := s.db.Transaction(func(tx *gorm.DB) error {
+	if err := s.store.Transaction(func(tx repository.Store) error {
+		locked, err := chainTransaction(tx, &deposit)
+		if err != nil {
+			return err
+		}
+		if err := checkTransactable(locked); err != nil {
+			return err
+		}
+
+		before := locked
+		account = locked
+		account.Balance += deposit.Amount
+
 		eg.Go(func() error {
-			return tx.Save(&account).Error
+			return tx.Accounts().Save(&account)
 		})
 
 		eg.Go(func() error {
-			return tx.Save(&deposit).Error
+			return tx.Transactions().Create(&deposit)
 		})
 
-		return eg.Wait()
+		if err := eg.Wait(); err != nil {
+			return err
+		}
+
+		if err := recordTransactionAudit(tx, actor, models.AUDIT_TRANSACTION_DEPOSIT, before, account, deposit); err != nil {
+			return err
+		}
+
+		return recordEvent(tx, models.FundsDeposited{
+			AccountNumber: account.AccountNumber,
+			TransactionID: deposit.TransactionID,
+			UserID:        actor.UserID,
+			Amount:        deposit.Amount,
+			Balance:       account.Balance,
+		})
 	}); err != nil {
-		return account, fmt.Errorf("failed to save deposit")
+		return account, domainError(err, fmt.Errorf("failed to save deposit"))
 	}
 
 	return account, nil
 }
 
-func (s *BankService) WithdrawFromAccount(withdraw models.Transaction, userID uint) (models.BankAccount, error) {
-	var account models.BankAccount
-	if err := s.db.Where("account_number = ?", withdraw.AccountNumber).First(&account).Error; err != nil {
-		return account, fmt.Errorf("account not found")
+func (s *BankService) WithdrawFromAccount(withdraw models.Transaction, actor models.Actor) (models.BankAccount, error) {
+	account, member, err := s.accountForMember(withdraw.AccountNumber, actor.UserID)
+	if err != nil {
+		return account, err
 	}
 
-	if account.UserID != userID {
-		return account, fmt.Errorf("you are not the owner of this account")
+	if !member.CanTransactAmount(withdraw.Amount) {
+		return account, fmt.Errorf("you are not allowed to withdraw this amount from this account")
 	}
 
-	if account.Balance < withdraw.Amount {
-		return account, fmt.Errorf("insufficient balance")
-	}
-
-	account.Balance -= withdraw.Amount
-
 	withdraw.Type = WITHDRAW
 	withdraw.TransactionID = uuid.New().String()
 
 	eg := errgroup.Group{}
-	if err := s.db.Transaction(func(tx *gorm.DB) error {
+	if err := s.store.Transaction(func(tx repository.Store) error {
+		locked, err := chainTransaction(tx, &withdraw)
+		if err != nil {
+			return err
+		}
+		if err := checkTransactable(locked); err != nil {
+			return err
+		}
+		if locked.Balance < withdraw.Amount {
+			return ErrInsufficientBalance
+		}
+
+		before := locked
+		account = locked
+		account.Balance -= withdraw.Amount
+
 		eg.Go(func() error {
-			return tx.Save(&account).Error
+			return tx.Accounts().Save(&account)
 		})
 
 		eg.Go(func() error {
-			return tx.Save(&withdraw).Error
+			return tx.Transactions().Create(&withdraw)
 		})
 
-		return eg.Wait()
+		if err := eg.Wait(); err != nil {
+			return err
+		}
+
+		if err := recordTransactionAudit(tx, actor, models.AUDIT_TRANSACTION_WITHDRAW, before, account, withdraw); err != nil {
+			return err
+		}
+
+		return recordEvent(tx, models.FundsWithdrawn{
+			AccountNumber: account.AccountNumber,
+			TransactionID: withdraw.TransactionID,
+			UserID:        actor.UserID,
+			Amount:        withdraw.Amount,
+			Balance:       account.Balance,
+		})
 	}); err != nil {
-		return account, fmt.Errorf("failed to save withdraw")
+		return account, domainError(err, fmt.Errorf("failed to save withdraw"))
 	}
 
 	return account, nil
 }
 
 func (s *BankService) GetActivityFeed(userID uint) ([]models.Transaction, error) {
-	var allAccounts []models.BankAccount
-	if err := s.db.Where("user_id = ?", userID).Find(&allAccounts).Error; err != nil {
+	allAccounts, err := s.store.Accounts().ListForMember(userID)
+	if err != nil {
 		return nil, fmt.Errorf("no accounts found")
 	}
 
@@ -139,22 +240,54 @@ func (s *BankService) GetActivityFeed(userID uint) ([]models.Transaction, error)
 		}
 	}
 
-	var latestTransactions []models.Transaction
-	if err := s.db.Where("account_number IN ?", accountNumbers).Order("created_at desc").Limit(10).Find(&latestTransactions).Error; err != nil {
+	latestTransactions, err := s.store.Transactions().ListRecent(accountNumbers, 10)
+	if err != nil {
 		return nil, fmt.Errorf("failed to get activity feed")
 	}
 
 	return latestTransactions, nil
 }
 
-func (s *BankService) SendTransfer(transfer models.OutgoingTransfer, userID uint) (models.BankAccount, error) {
-	var senderAccount models.BankAccount
-	if err := s.db.Where("account_number = ?", transfer.AccountNumber).First(&senderAccount).Error; err != nil {
-		return senderAccount, fmt.Errorf("sender account not found")
+// GetAccountsByNumbers loads the listed accounts that the user holds, in a
+// single query. Accounts the user does not hold are left out.
+func (s *BankService) GetAccountsByNumbers(userID uint, accountNumbers []string) ([]models.BankAccount, error) {
+	accounts, err := s.store.Accounts().ListForMemberByNumbers(userID, accountNumbers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts")
 	}
 
-	if senderAccount.UserID != userID {
-		return senderAccount, fmt.Errorf("you are not the owner of this account")
+	return accounts, nil
+}
+
+// GetRecentTransactions loads up to limit of the newest transactions of each
+// listed account in a single query, newest first.
+func (s *BankService) GetRecentTransactions(accountNumbers []string, limit int) ([]models.Transaction, error) {
+	transactions, err := s.store.Transactions().ListRecentPerAccount(accountNumbers, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions")
+	}
+
+	return transactions, nil
+}
+
+// GetTransfers returns the newest transfers the user sent or received.
+func (s *BankService) GetTransfers(userID uint, limit int) ([]models.Transfer, error) {
+	transfers, err := s.store.Transfers().ListForUser(userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transfers")
+	}
+
+	return transfers, nil
+}
+
+func (s *BankService) SendTransfer(transfer models.OutgoingTransfer, actor models.Actor) (models.BankAccount, error) {
+	senderAccount, member, err := s.accountForMember(transfer.AccountNumber, actor.UserID)
+	if err != nil {
+		return senderAccount, err
+	}
+
+	if !member.CanTransactAmount(transfer.Amount) {
+		return senderAccount, fmt.Errorf("you are not allowed to send this amount from this account")
 	}
 
 	transactionDetails := models.Transaction{
@@ -165,7 +298,7 @@ func (s *BankService) SendTransfer(transfer models.OutgoingTransfer, userID uint
 	}
 
 	transferRow := models.Transfer{
-		SenderID:      userID,
+		SenderID:      actor.UserID,
 		ReceiverID:    transfer.ReceiverID,
 		Amount:        transfer.Amount,
 		Status:        PENDING,
@@ -173,47 +306,90 @@ func (s *BankService) SendTransfer(transfer models.OutgoingTransfer, userID uint
 		TransactionID: transactionDetails.TransactionID,
 	}
 
-	senderAccount.Balance -= transfer.Amount
-
 	eg := errgroup.Group{}
-	if err := s.db.Transaction(func(tx *gorm.DB) error {
+	if err := s.store.Transaction(func(tx repository.Store) error {
+		locked, err := chainTransaction(tx, &transactionDetails)
+		if err != nil {
+			return err
+		}
+		if err := checkTransactable(locked); err != nil {
+			return err
+		}
+		if locked.Balance < transfer.Amount {
+			return ErrInsufficientBalance
+		}
+
+		before := locked
+		senderAccount = locked
+		senderAccount.Balance -= transfer.Amount
+
 		eg.Go(func() error {
-			return tx.Save(&senderAccount).Error
+			return tx.Accounts().Save(&senderAccount)
 		})
 
 		eg.Go(func() error {
-			return tx.Save(&transferRow).Error
+			return tx.Transfers().Create(&transferRow)
 		})
 
 		eg.Go(func() error {
-			return tx.Save(&transactionDetails).Error
+			return tx.Transactions().Create(&transactionDetails)
 		})
 
-		return eg.Wait()
+		if err := eg.Wait(); err != nil {
+			return err
+		}
+
+		if err := recordAudit(tx, actor, models.AUDIT_TRANSFER_SEND, models.ENTITY_TRANSFER, transferRow.TransactionID, nil, transferRow); err != nil {
+			return err
+		}
+
+		if err := recordAudit(tx, actor, models.AUDIT_TRANSFER_SEND, models.ENTITY_ACCOUNT, senderAccount.AccountNumber, before, senderAccount); err != nil {
+			return err
+		}
+
+		return recordEvent(tx, models.TransferSent{
+			AccountNumber: senderAccount.AccountNumber,
+			TransactionID: transferRow.TransactionID,
+			SenderID:      transferRow.SenderID,
+			ReceiverID:    transferRow.ReceiverID,
+			Amount:        transferRow.Amount,
+			Balance:       senderAccount.Balance,
+			ExpiresOn:     transferRow.ExpiresOn,
+		})
 	}); err != nil {
-		return senderAccount, fmt.Errorf("failed to send transfer")
+		// The sender and their account exist, so a missing reference can
+		// only be the receiver.
+		if errors.Is(err, repository.ErrMissingReference) {
+			return senderAccount, ErrReceiverNotFound
+		}
+		return senderAccount, domainError(err, fmt.Errorf("failed to send transfer"))
 	}
 
 	return senderAccount, nil
 }
 
-func (s *BankService) AcceptTransfer(acceptTransfer models.IncomingTransfer, userID uint) (models.BankAccount, error) {
-	var tranferDetails models.Transfer
-	if err := s.db.Where("transaction_id = ?", acceptTransfer.TransactionID).First(&tranferDetails).Error; err != nil {
+func (s *BankService) AcceptTransfer(acceptTransfer models.IncomingTransfer, actor models.Actor) (models.BankAccount, error) {
+	tranferDetails, err := s.store.Transfers().Get(acceptTransfer.TransactionID)
+	if err != nil {
 		return models.BankAccount{}, fmt.Errorf("no transfer found")
 	}
 
-	if tranferDetails.ReceiverID != userID {
+	if tranferDetails.ReceiverID != actor.UserID {
 		return models.BankAccount{}, fmt.Errorf("you are not the receiver of this transfer")
 	}
 
-	var userAccount models.BankAccount
-	if err := s.db.Where("account_number = ?", acceptTransfer.AccountNumber).Find(&userAccount).Error; err != nil {
-		return userAccount, fmt.Errorf("account not found")
+	if tranferDetails.Status != PENDING || time.Now().After(tranferDetails.ExpiresOn) {
+		return models.BankAccount{}, ErrTransferNotPending
 	}
 
-	userAccount.Balance += tranferDetails.Amount
-	tranferDetails.Status = ACCEPTED
+	userAccount, member, err := s.accountForMember(acceptTransfer.AccountNumber, actor.UserID)
+	if err != nil {
+		return userAccount, err
+	}
+
+	if !member.CanTransact() {
+		return userAccount, fmt.Errorf("you are not allowed to transact on this account")
+	}
 
 	transactionDetails := models.Transaction{
 		Amount:        tranferDetails.Amount,
@@ -223,23 +399,121 @@ func (s *BankService) AcceptTransfer(acceptTransfer models.IncomingTransfer, use
 	}
 
 	eg := errgroup.Group{}
-	if err := s.db.Transaction(func(tx *gorm.DB) error {
+	if err := s.store.Transaction(func(tx repository.Store) error {
+		// Expiry may have refunded the transfer since it was read.
+		lockedTransfer, err := tx.Transfers().GetForUpdate(tranferDetails.TransactionID)
+		if err != nil {
+			return err
+		}
+		if lockedTransfer.Status != PENDING {
+			return ErrTransferNotPending
+		}
+
+		lockedAccount, err := chainTransaction(tx, &transactionDetails)
+		if err != nil {
+			return err
+		}
+		if err := checkTransactable(lockedAccount); err != nil {
+			return err
+		}
+
+		beforeAccount, beforeTransfer := lockedAccount, lockedTransfer
+		userAccount, tranferDetails = lockedAccount, lockedTransfer
+		userAccount.Balance += tranferDetails.Amount
+		tranferDetails.Status = ACCEPTED
+
 		eg.Go(func() error {
-			return tx.Save(&userAccount).Error
+			return tx.Accounts().Save(&userAccount)
 		})
 
 		eg.Go(func() error {
-			return tx.Save(&tranferDetails).Error
+			return tx.Transfers().Save(&tranferDetails)
 		})
 
 		eg.Go(func() error {
-			return tx.Save(&transactionDetails).Error
+			return tx.Transactions().Create(&transactionDetails)
 		})
 
-		return eg.Wait()
+		if err := eg.Wait(); err != nil {
+			return err
+		}
+
+		if err := recordAudit(tx, actor, models.AUDIT_TRANSFER_ACCEPT, models.ENTITY_TRANSFER, tranferDetails.TransactionID, beforeTransfer, tranferDetails); err != nil {
+			return err
+		}
+
+		if err := recordAudit(tx, actor, models.AUDIT_TRANSFER_ACCEPT, models.ENTITY_ACCOUNT, userAccount.AccountNumber, beforeAccount, userAccount); err != nil {
+			return err
+		}
+
+		return recordEvent(tx, models.TransferAccepted{
+			AccountNumber: userAccount.AccountNumber,
+			TransactionID: tranferDetails.TransactionID,
+			SenderID:      tranferDetails.SenderID,
+			ReceiverID:    tranferDetails.ReceiverID,
+			Amount:        tranferDetails.Amount,
+			Balance:       userAccount.Balance,
+		})
 	}); err != nil {
-		return userAccount, fmt.Errorf("failed to accept transfer")
+		return userAccount, domainError(err, fmt.Errorf("failed to accept transfer"))
 	}
 
 	return userAccount, nil
 }
+
+// recordTransactionAudit logs a deposit or withdrawal against both the new
+// transaction and the account balance it changed.
+func recordTransactionAudit(tx repository.Store, actor models.Actor, action string, before models.BankAccount, after models.BankAccount, transaction models.Transaction) error {
+	if err := recordAudit(tx, actor, action, models.ENTITY_TRANSACTION, transaction.TransactionID, nil, transaction); err != nil {
+		return err
+	}
+
+	return recordAudit(tx, actor, action, models.ENTITY_ACCOUNT, after.AccountNumber, before, after)
+}
+
+// accountForMember loads an account together with the caller's active
+// membership of it. Every ownership check goes through here.
+func (s *BankService) accountForMember(accountNumber string, userID uint) (models.BankAccount, models.AccountMember, error) {
+	account, err := s.store.Accounts().Get(accountNumber)
+	if err != nil {
+		return account, models.AccountMember{}, fmt.Errorf("account not found")
+	}
+
+	member, err := s.store.Accounts().GetMember(accountNumber, userID)
+	if err != nil || !member.IsActive() {
+		return account, member, fmt.Errorf("you are not a holder of this account")
+	}
+
+	return account, member, nil
+}
+
+// checkTransactable fails for accounts that cannot be transacted on. It is
+// given the locked row, since an account can be closed or frozen at any time.
+func checkTransactable(account models.BankAccount) error {
+	if account.IsClosed() {
+		return ErrAccountClosed
+	}
+	if account.IsFrozen() {
+		return ErrAccountFrozen
+	}
+	return nil
+}
+
+// domainError turns a write rejected by a check constraint into the domain
+// error it stands for, passes lockedErrors through, and turns any other
+// failure into fallback.
+func domainError(err error, fallback error) error {
+	for _, lockedErr := range lockedErrors {
+		if errors.Is(err, lockedErr) {
+			return lockedErr
+		}
+	}
+
+	var violation *repository.ConstraintError
+	if errors.As(err, &violation) {
+		if domainErr, ok := checkErrors[violation.Constraint]; ok {
+			return domainErr
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,311 @@
+package services
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestBank returns a bank with one user holding one account with balance.
+func newTestBank(t *testing.T, balance float64) (*BankService, *repository.MemoryStore, models.User, models.BankAccount) {
+	store := repository.NewMemoryStore()
+	bank := NewBankService(store)
+	user := createTestUser(t, store, "holder@example.com")
+
+	account, err := bank.CreateAccount(actorFor(user))
+	assert.NoError(t, err)
+
+	if balance > 0 {
+		account, err = bank.DepositToAccount(models.Transaction{AccountNumber: account.AccountNumber, Amount: balance}, actorFor(user))
+		assert.NoError(t, err)
+	}
+	return bank, store, user, account
+}
+
+func TestCreateAccount(t *testing.T) {
+	bank, store, user, account := newTestBank(t, 0)
+
+	assert.NotEmpty(t, account.AccountNumber)
+	assert.Equal(t, user.ID, account.UserID)
+
+	member, err := store.Accounts().GetMember(account.AccountNumber, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.MEMBER_OWNER, member.Role)
+	assert.True(t, member.IsActive())
+
+	assert.Equal(t, []string{models.AUDIT_ACCOUNT_CREATE}, auditActions(store))
+	assert.Equal(t, []string{models.EVENT_ACCOUNT_OPENED}, eventTypes(store))
+
+	accounts, err := bank.GetAccountsByUserID(user.ID)
+	assert.NoError(t, err)
+	assert.Len(t, accounts, 1)
+}
+
+func TestGetAccountsByUserIDOnlyIncludesActiveMemberships(t *testing.T) {
+	bank, store, _, account := newTestBank(t, 0)
+	invitee := createTestUser(t, store, "invitee@example.com")
+
+	assert.NoError(t, store.Accounts().CreateMember(&models.AccountMember{AccountNumber: account.AccountNumber, UserID: invitee.ID, Role: models.MEMBER_VIEWER}))
+
+	accounts, err := bank.GetAccountsByUserID(invitee.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, accounts)
+}
+
+func TestDepositToAccount(t *testing.T) {
+	bank, store, user, account := newTestBank(t, 0)
+
+	account, err := bank.DepositToAccount(models.Transaction{AccountNumber: account.AccountNumber, Amount: 25}, actorFor(user))
+	assert.NoError(t, err)
+	assert.Equal(t, 25.0, account.Balance)
+
+	stored, _ := store.Accounts().Get(account.AccountNumber)
+	assert.Equal(t, 25.0, stored.Balance)
+
+	last, err := store.Transactions().Last(account.AccountNumber)
+	assert.NoError(t, err)
+	assert.Equal(t, DEPOSIT, last.Type)
+	assert.Equal(t, uint64(1), last.Sequence)
+	assert.Equal(t, models.LEDGER_GENESIS_HASH, last.PrevHash)
+	assert.Equal(t, last.ComputeHash(), last.Hash)
+
+	assert.Contains(t, eventTypes(store), models.EVENT_FUNDS_DEPOSITED)
+}
+
+func TestDepositRejectsViewersAndFrozenAccounts(t *testing.T) {
+	bank, store, user, account := newTestBank(t, 0)
+	viewer := createTestUser(t, store, "viewer@example.com")
+	now := time.Now()
+	assert.NoError(t, store.Accounts().CreateMember(&models.AccountMember{AccountNumber: account.AccountNumber, UserID: viewer.ID, Role: models.MEMBER_VIEWER, AcceptedAt: &now}))
+
+	_, err := bank.DepositToAccount(models.Transaction{AccountNumber: account.AccountNumber, Amount: 5}, actorFor(viewer))
+	assert.EqualError(t, err, "you are not allowed to transact on this account")
+
+	account.FrozenAt = &now
+	assert.NoError(t, store.Accounts().Save(&account))
+
+	_, err = bank.DepositToAccount(models.Transaction{AccountNumber: account.AccountNumber, Amount: 5}, actorFor(user))
+	assert.EqualError(t, err, "account is frozen")
+
+	_, err = bank.DepositToAccount(models.Transaction{AccountNumber: "missing", Amount: 5}, actorFor(user))
+	assert.EqualError(t, err, "account not found")
+}
+
+func TestWithdrawFromAccount(t *testing.T) {
+	bank, store, user, account := newTestBank(t, 100)
+
+	account, err := bank.WithdrawFromAccount(models.Transaction{AccountNumber: account.AccountNumber, Amount: 40}, actorFor(user))
+	assert.NoError(t, err)
+	assert.Equal(t, 60.0, account.Balance)
+
+	last, _ := store.Transactions().Last(account.AccountNumber)
+	assert.Equal(t, WITHDRAW, last.Type)
+	assert.Equal(t, uint64(2), last.Sequence)
+
+	_, err = bank.WithdrawFromAccount(models.Transaction{AccountNumber: account.AccountNumber, Amount: 61}, actorFor(user))
+	assert.EqualError(t, err, "insufficient balance")
+}
+
+func TestWithdrawRespectsTransactorLimit(t *testing.T) {
+	bank, store, _, account := newTestBank(t, 100)
+	transactor := createTestUser(t, store, "transactor@example.com")
+	now := time.Now()
+	assert.NoError(t, store.Accounts().CreateMember(&models.AccountMember{
+		AccountNumber: account.AccountNumber, UserID: transactor.ID, Role: models.MEMBER_TRANSACTOR, Limit: 10, AcceptedAt: &now,
+	}))
+
+	_, err := bank.WithdrawFromAccount(models.Transaction{AccountNumber: account.AccountNumber, Amount: 11}, actorFor(transactor))
+	assert.EqualError(t, err, "you are not allowed to withdraw this amount from this account")
+
+	account, err = bank.WithdrawFromAccount(models.Transaction{AccountNumber: account.AccountNumber, Amount: 10}, actorFor(transactor))
+	assert.NoError(t, err)
+	assert.Equal(t, 90.0, account.Balance)
+}
+
+func TestGetActivityFeed(t *testing.T) {
+	bank, _, user, account := newTestBank(t, 0)
+	for i := 0; i < 12; i++ {
+		_, err := bank.DepositToAccount(models.Transaction{AccountNumber: account.AccountNumber, Amount: float64(i + 1)}, actorFor(user))
+		assert.NoError(t, err)
+	}
+
+	feed, err := bank.GetActivityFeed(user.ID)
+	assert.NoError(t, err)
+	assert.Len(t, feed, 10)
+	assert.Equal(t, 12.0, feed[0].Amount)
+}
+
+func TestGetAccountsByNumbers(t *testing.T) {
+	bank, store, user, account := newTestBank(t, 0)
+	other := createTestUser(t, store, "other@example.com")
+	otherAccount, err := bank.CreateAccount(actorFor(other))
+	assert.NoError(t, err)
+
+	accounts, err := bank.GetAccountsByNumbers(user.ID, []string{account.AccountNumber, otherAccount.AccountNumber})
+	assert.NoError(t, err)
+	assert.Len(t, accounts, 1)
+	assert.Equal(t, account.AccountNumber, accounts[0].AccountNumber)
+}
+
+func TestGetRecentTransactions(t *testing.T) {
+	bank, _, user, first := newTestBank(t, 0)
+	second, err := bank.CreateAccount(actorFor(user))
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		for _, account := range []models.BankAccount{first, second} {
+			_, err := bank.DepositToAccount(models.Transaction{AccountNumber: account.AccountNumber, Amount: float64(i + 1)}, actorFor(user))
+			assert.NoError(t, err)
+		}
+	}
+
+	transactions, err := bank.GetRecentTransactions([]string{first.AccountNumber, second.AccountNumber}, 2)
+	assert.NoError(t, err)
+	assert.Len(t, transactions, 4)
+
+	perAccount := map[string][]float64{}
+	for _, transaction := range transactions {
+		perAccount[transaction.AccountNumber] = append(perAccount[transaction.AccountNumber], transaction.Amount)
+	}
+	assert.Equal(t, []float64{3, 2}, perAccount[first.AccountNumber])
+	assert.Equal(t, []float64{3, 2}, perAccount[second.AccountNumber])
+}
+
+func TestSendAndAcceptTransfer(t *testing.T) {
+	bank, store, sender, senderAccount := newTestBank(t, 100)
+	receiver := createTestUser(t, store, "receiver@example.com")
+	receiverAccount, err := bank.CreateAccount(actorFor(receiver))
+	assert.NoError(t, err)
+
+	senderAccount, err = bank.SendTransfer(models.OutgoingTransfer{
+		AccountNumber: senderAccount.AccountNumber, ReceiverID: receiver.ID, Amount: 30,
+	}, actorFor(sender))
+	assert.NoError(t, err)
+	assert.Equal(t, 70.0, senderAccount.Balance)
+
+	transfers, err := bank.GetTransfers(receiver.ID, 10)
+	assert.NoError(t, err)
+	assert.Len(t, transfers, 1)
+	assert.Equal(t, PENDING, transfers[0].Status)
+
+	_, err = bank.AcceptTransfer(models.IncomingTransfer{TransactionID: transfers[0].TransactionID, AccountNumber: senderAccount.AccountNumber}, actorFor(sender))
+	assert.EqualError(t, err, "you are not the receiver of this transfer")
+
+	receiverAccount, err = bank.AcceptTransfer(models.IncomingTransfer{
+		TransactionID: transfers[0].TransactionID, AccountNumber: receiverAccount.AccountNumber,
+	}, actorFor(receiver))
+	assert.NoError(t, err)
+	assert.Equal(t, 30.0, receiverAccount.Balance)
+
+	accepted, _ := store.Transfers().Get(transfers[0].TransactionID)
+	assert.Equal(t, ACCEPTED, accepted.Status)
+	assert.Contains(t, eventTypes(store), models.EVENT_TRANSFER_SENT)
+	assert.Contains(t, eventTypes(store), models.EVENT_TRANSFER_ACCEPTED)
+
+	_, err = bank.AcceptTransfer(models.IncomingTransfer{TransactionID: "missing", AccountNumber: receiverAccount.AccountNumber}, actorFor(receiver))
+	assert.EqualError(t, err, "no transfer found")
+}
+
+func TestClosedAccountsAndSettledTransfersRejectChanges(t *testing.T) {
+	bank, store, sender, senderAccount := newTestBank(t, 100)
+	receiver := createTestUser(t, store, "receiver@example.com")
+	receiverAccount, err := bank.CreateAccount(actorFor(receiver))
+	assert.NoError(t, err)
+
+	_, err = bank.SendTransfer(models.OutgoingTransfer{
+		AccountNumber: senderAccount.AccountNumber, ReceiverID: receiver.ID, Amount: 30,
+	}, actorFor(sender))
+	assert.NoError(t, err)
+
+	transfers, _ := bank.GetTransfers(receiver.ID, 10)
+	transfer := transfers[0]
+	transfer.Status = EXPIRED
+	assert.NoError(t, store.Transfers().Save(&transfer))
+
+	accept := models.IncomingTransfer{TransactionID: transfer.TransactionID, AccountNumber: receiverAccount.AccountNumber}
+	_, err = bank.AcceptTransfer(accept, actorFor(receiver))
+	assert.ErrorIs(t, err, ErrTransferNotPending)
+
+	transfer.Status, transfer.ExpiresOn = PENDING, time.Now().Add(-time.Minute)
+	assert.NoError(t, store.Transfers().Save(&transfer))
+	_, err = bank.AcceptTransfer(accept, actorFor(receiver))
+	assert.ErrorIs(t, err, ErrTransferNotPending)
+
+	now := time.Now()
+	senderAccount, _ = store.Accounts().Get(senderAccount.AccountNumber)
+	senderAccount.ClosedAt = &now
+	assert.NoError(t, store.Accounts().Save(&senderAccount))
+
+	_, err = bank.DepositToAccount(models.Transaction{AccountNumber: senderAccount.AccountNumber, Amount: 5}, actorFor(sender))
+	assert.ErrorIs(t, err, ErrAccountClosed)
+
+	_, err = bank.WithdrawFromAccount(models.Transaction{AccountNumber: senderAccount.AccountNumber, Amount: 5}, actorFor(sender))
+	assert.ErrorIs(t, err, ErrAccountClosed)
+}
+
+func TestSendTransferRequiresHolder(t *testing.T) {
+	bank, store, _, account := newTestBank(t, 100)
+	stranger := createTestUser(t, store, "stranger@example.com")
+
+	_, err := bank.SendTransfer(models.OutgoingTransfer{AccountNumber: account.AccountNumber, ReceiverID: stranger.ID, Amount: 10}, actorFor(stranger))
+	assert.EqualError(t, err, "you are not a holder of this account")
+
+	transfers, _ := bank.GetTransfers(stranger.ID, 10)
+	assert.Empty(t, transfers)
+}
+
+func TestConstraintViolationsBecomeDomainErrors(t *testing.T) {
+	bank, store, user, account := newTestBank(t, 100)
+
+	_, err := bank.DepositToAccount(models.Transaction{AccountNumber: account.AccountNumber, Amount: -5}, actorFor(user))
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+
+	_, err = bank.SendTransfer(models.OutgoingTransfer{AccountNumber: account.AccountNumber, ReceiverID: 999, Amount: 10}, actorFor(user))
+	assert.ErrorIs(t, err, ErrReceiverNotFound)
+
+	receiver := createTestUser(t, store, "receiver@example.com")
+	_, err = bank.SendTransfer(models.OutgoingTransfer{AccountNumber: account.AccountNumber, ReceiverID: receiver.ID, Amount: 101}, actorFor(user))
+	assert.ErrorIs(t, err, ErrInsufficientBalance)
+
+	stored, _ := store.Accounts().Get(account.AccountNumber)
+	assert.Equal(t, 100.0, stored.Balance)
+}
+
+func TestConcurrentWritesKeepTheBalance(t *testing.T) {
+	db, store := newTestDB(t)
+	bank := NewBankService(store)
+	admin := NewAdminService(store)
+	user := createTestUser(t, store, "busy@example.com")
+	account, err := bank.CreateAccount(actorFor(user))
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := bank.DepositToAccount(models.Transaction{AccountNumber: account.AccountNumber, Amount: 10}, actorFor(user))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, _, err := admin.AdjustBalance(models.Actor{}, account.AccountNumber, 5, "goodwill credit")
+		assert.NoError(t, err)
+	}()
+	wg.Wait()
+
+	account, err = store.Accounts().Get(account.AccountNumber)
+	assert.NoError(t, err)
+	assert.Equal(t, 205.0, account.Balance)
+
+	result, err := NewLedgerService(db).Verify(account.AccountNumber)
+	assert.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, 21, result.Transactions)
+}
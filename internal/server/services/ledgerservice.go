@@ -0,0 +1,193 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/repository"
+	"github.com/FaizanAC/Go-Banking/internal/util"
+	"gorm.io/gorm"
+)
+
+type LedgerService struct {
+	db *gorm.DB
+}
+
+func NewLedgerService(db *gorm.DB) *LedgerService {
+	return &LedgerService{db: db}
+}
+
+// Verify walks the hash chain of one account, or of every account when
+// accountNumber is empty, and reports the first broken link.
+func (s *LedgerService) Verify(accountNumber string) (models.LedgerVerification, error) {
+	query := s.db.Unscoped().Model(&models.Transaction{}).Order("account_number, sequence")
+	if accountNumber != "" {
+		query = query.Where("account_number = ?", accountNumber)
+	}
+
+	rows, err := query.Rows()
+	if err != nil {
+		return models.LedgerVerification{}, fmt.Errorf("failed to read ledger")
+	}
+	defer rows.Close()
+
+	result := models.LedgerVerification{Valid: true}
+	var head models.LedgerHead
+	for rows.Next() {
+		var transaction models.Transaction
+		if err := s.db.ScanRows(rows, &transaction); err != nil {
+			return result, fmt.Errorf("failed to read ledger")
+		}
+
+		if transaction.AccountNumber != head.AccountNumber {
+			head = models.LedgerHead{AccountNumber: transaction.AccountNumber, Hash: models.LEDGER_GENESIS_HASH}
+			result.Accounts++
+		}
+		result.Transactions++
+
+		if reason := checkLink(head, transaction); reason != "" {
+			result.Valid = false
+			result.BrokenLink = &models.LedgerBreak{
+				AccountNumber: transaction.AccountNumber,
+				Sequence:      transaction.Sequence,
+				TransactionID: transaction.TransactionID,
+				Reason:        reason,
+			}
+			return result, nil
+		}
+
+		head.Sequence, head.Hash = transaction.Sequence, transaction.Hash
+	}
+
+	return result, rows.Err()
+}
+
+// checkLink validates a transaction against the head of its chain so far.
+func checkLink(head models.LedgerHead, transaction models.Transaction) string {
+	switch {
+	case transaction.Sequence != head.Sequence+1:
+		return fmt.Sprintf("expected sequence %d, found %d", head.Sequence+1, transaction.Sequence)
+	case transaction.PrevHash != head.Hash:
+		return "previous hash does not match the preceding transaction"
+	case transaction.Hash != transaction.ComputeHash():
+		return "hash does not match the transaction's contents"
+	}
+	return ""
+}
+
+// Checkpoint captures and signs the current head of every account's chain.
+func (s *LedgerService) Checkpoint() (models.LedgerCheckpoint, error) {
+	checkpoint := models.LedgerCheckpoint{CreatedAt: time.Now().UTC()}
+
+	if err := s.db.Unscoped().Model(&models.Transaction{}).
+		Select("account_number, sequence, hash").
+		Where("(account_number, sequence) IN (?)",
+			s.db.Unscoped().Model(&models.Transaction{}).Select("account_number, MAX(sequence)").Group("account_number")).
+		Order("account_number").
+		Scan(&checkpoint.Heads).Error; err != nil {
+		return checkpoint, fmt.Errorf("failed to read ledger")
+	}
+
+	kid, signature, err := util.SignDocument(checkpoint.SigningPayload())
+	if err != nil {
+		return checkpoint, fmt.Errorf("failed to sign checkpoint")
+	}
+
+	checkpoint.KeyID, checkpoint.Signature = kid, signature
+	return checkpoint, nil
+}
+
+// WriteCheckpoint stores a new signed checkpoint as a JSON file in dir and
+// returns its path.
+func (s *LedgerService) WriteCheckpoint(dir string) (string, error) {
+	checkpoint, err := s.Checkpoint()
+	if err != nil {
+		return "", err
+	}
+
+	document, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("checkpoint-%s.json", checkpoint.CreatedAt.Format("20060102T150405Z")))
+	return path, os.WriteFile(path, document, 0o644)
+}
+
+// VerifyCheckpoint checks the checkpoint's signature and that every head it
+// recorded is still present, unchanged, in the ledger.
+func (s *LedgerService) VerifyCheckpoint(checkpoint models.LedgerCheckpoint) error {
+	if err := util.VerifyDocument(checkpoint.SigningPayload(), checkpoint.KeyID, checkpoint.Signature); err != nil {
+		return fmt.Errorf("invalid checkpoint signature: %w", err)
+	}
+
+	for _, head := range checkpoint.Heads {
+		var transaction models.Transaction
+		if err := s.db.Unscoped().Where("account_number = ? AND sequence = ?", head.AccountNumber, head.Sequence).
+			First(&transaction).Error; err != nil {
+			return fmt.Errorf("account %s: transaction %d is missing", head.AccountNumber, head.Sequence)
+		}
+
+		if transaction.Hash != head.Hash {
+			return fmt.Errorf("account %s: transaction %d no longer matches the checkpoint", head.AccountNumber, head.Sequence)
+		}
+	}
+
+	return nil
+}
+
+// StartCheckpoints writes a checkpoint to dir every interval until stop is
+// closed.
+func (s *LedgerService) StartCheckpoints(dir string, interval time.Duration, stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if path, err := s.WriteCheckpoint(dir); err != nil {
+					log.Println("failed to write ledger checkpoint:", err)
+				} else {
+					log.Println("wrote ledger checkpoint", path)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// chainTransaction links a new transaction to the end of its account's
+// chain. It locks the account row so concurrent writers cannot fork the chain
+// and returns it; callers change the balance of that row, not an earlier
+// read.
+func chainTransaction(tx repository.Store, transaction *models.Transaction) (models.BankAccount, error) {
+	account, err := tx.Accounts().GetForUpdate(transaction.AccountNumber)
+	if err != nil {
+		return account, err
+	}
+
+	transaction.Sequence, transaction.PrevHash = 1, models.LEDGER_GENESIS_HASH
+
+	last, err := tx.Transactions().Last(transaction.AccountNumber)
+	if err == nil {
+		transaction.Sequence, transaction.PrevHash = last.Sequence+1, last.Hash
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return account, err
+	}
+
+	transaction.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	transaction.Hash = transaction.ComputeHash()
+	return account, nil
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckLink(t *testing.T) {
+	head := models.LedgerHead{AccountNumber: "123", Hash: models.LEDGER_GENESIS_HASH}
+
+	first := models.Transaction{AccountNumber: "123", TransactionID: "a", Type: DEPOSIT, Amount: 10, Sequence: 1, PrevHash: head.Hash}
+	first.CreatedAt = time.Now().Truncate(time.Microsecond)
+	first.Hash = first.ComputeHash()
+	assert.Empty(t, checkLink(head, first))
+
+	head.Sequence, head.Hash = first.Sequence, first.Hash
+
+	second := models.Transaction{AccountNumber: "123", TransactionID: "b", Type: WITHDRAW, Amount: 5, Sequence: 2, PrevHash: first.Hash}
+	second.CreatedAt = first.CreatedAt.Add(time.Second)
+	second.Hash = second.ComputeHash()
+	assert.Empty(t, checkLink(head, second))
+
+	tampered := second
+	tampered.Amount = 500
+	assert.Contains(t, checkLink(head, tampered), "contents")
+
+	skipped := second
+	skipped.Sequence = 3
+	assert.Contains(t, checkLink(head, skipped), "sequence")
+
+	forked := second
+	forked.PrevHash = models.LEDGER_GENESIS_HASH
+	assert.Contains(t, checkLink(head, forked), "previous hash")
+}
@@ -1,36 +1,372 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"log"
+	"math"
+	"net/url"
+	"strings"
+	"time"
 
+	"github.com/FaizanAC/Go-Banking/internal/mailer"
 	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/repository"
 	"github.com/FaizanAC/Go-Banking/internal/util"
 	"golang.org/x/crypto/bcrypt"
-	"gorm.io/gorm"
 )
 
+const (
+	loginWindow          = time.Minute * 15
+	loginDelayThreshold  = 3
+	loginMaxDelay        = time.Second * 30
+	accountLockThreshold = 10
+	accountLockDuration  = time.Minute * 15
+	accountUnlockTTL     = time.Hour
+	ipFailureLimit       = 50
+)
+
+const (
+	LOGIN_SUCCESS             = "SUCCESS"
+	LOGIN_INVALID_CREDENTIALS = "INVALID_CREDENTIALS"
+	LOGIN_THROTTLED           = "THROTTLED"
+	LOGIN_LOCKED              = "LOCKED"
+	LOGIN_IP_BLOCKED          = "IP_BLOCKED"
+	LOGIN_UNLOCKED            = "UNLOCKED"
+)
+
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
+// ThrottledError is returned while an email address or IP has to wait
+// before trying again.
+type ThrottledError struct {
+	RetryAfter time.Duration
+	reason     string
+}
+
+func (e *ThrottledError) Error() string {
+	return "too many failed login attempts, please try again later"
+}
+
+// dummyHash is compared against when the email is unknown so that response
+// times do not reveal whether an account exists.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), 10)
+
 type LoginService struct {
-	db *gorm.DB
+	store  repository.Store
+	mailer mailer.Mailer
 }
 
-func NewLoginService(db *gorm.DB) *LoginService {
-	return &LoginService{db: db}
+func NewLoginService(store repository.Store, mailer mailer.Mailer) *LoginService {
+	return &LoginService{store: store, mailer: mailer}
 }
 
-func (s *LoginService) Login(login models.Login) (string, error) {
-	var user models.User
-	if err := s.db.Where("email = ?", login.Email).First(&user).Error; err != nil {
-		return "", fmt.Errorf("user with email %s not found", login.Email)
+// Login checks the user's password. Users with two-factor authentication
+// enabled get a pending challenge instead of a token.
+func (s *LoginService) Login(login models.Login, client models.ClientInfo) (models.LoginResult, error) {
+	email := normalizeEmail(login.Email)
+
+	if err := s.checkIPThrottle(client.IP); err != nil {
+		s.recordAttempt(email, nil, client, false, err.reason)
+		return models.LoginResult{}, err
+	}
+
+	if err := s.checkAccountThrottle(email); err != nil {
+		s.recordAttempt(email, nil, client, false, err.reason)
+		return models.LoginResult{}, err
+	}
+
+	user, err := s.store.Users().GetByEmail(email)
+	if err != nil {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(login.Password))
+		s.recordFailure(email, nil, client)
+		return models.LoginResult{}, ErrInvalidCredentials
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(login.Password)); err != nil {
-		return "", fmt.Errorf("invalid Password")
+		s.recordFailure(email, &user, client)
+		return models.LoginResult{}, ErrInvalidCredentials
 	}
 
-	jwtToken, err := util.GenerateJWT(user.ID)
+	s.recordAttempt(email, &user.ID, client, true, LOGIN_SUCCESS)
+
+	if user.TOTPEnabled {
+		challengeID, err := s.createChallenge(user.ID)
+		if err != nil {
+			return models.LoginResult{}, fmt.Errorf("failed to start two-factor login")
+		}
+
+		return models.LoginResult{ChallengeID: challengeID}, nil
+	}
+
+	jwtToken, err := util.GenerateJWT(user.ID, user.Role)
+	if err != nil {
+		return models.LoginResult{}, fmt.Errorf("failed to Generate JWT")
+	}
+
+	return models.LoginResult{Token: jwtToken}, nil
+}
+
+// CompleteTwoFactorLogin exchanges a pending challenge and a valid TOTP or
+// recovery code for a token.
+func (s *LoginService) CompleteTwoFactorLogin(request models.TwoFactorLogin, client models.ClientInfo) (string, error) {
+	var user models.User
+
+	if err := s.store.Transaction(func(tx repository.Store) error {
+		challenge, err := tx.Logins().GetChallenge(util.HashToken(request.ChallengeID))
+		if err != nil {
+			return fmt.Errorf("invalid or expired challenge")
+		}
+
+		if challenge.CompletedAt != nil || time.Now().After(challenge.ExpiresOn) || challenge.Attempts >= maxChallengeAttempts {
+			return fmt.Errorf("invalid or expired challenge")
+		}
+
+		if user, err = tx.Users().Get(challenge.UserID); err != nil {
+			return fmt.Errorf("invalid or expired challenge")
+		}
+
+		if err := verifySecondFactor(tx.Logins(), &user, request.Code); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		challenge.CompletedAt = &now
+		if err := tx.Logins().UpdateChallenge(&challenge, "CompletedAt"); err != nil {
+			return err
+		}
+
+		return recordAudit(tx, models.Actor{ClientInfo: client}, models.AUDIT_LOGIN_SUCCESS, models.ENTITY_USER, user.ID,
+			nil, map[string]interface{}{"secondFactor": true})
+	}); err != nil {
+		s.recordFailedChallenge(request.ChallengeID)
+		return "", err
+	}
+
+	jwtToken, err := util.GenerateJWT(user.ID, user.Role)
 	if err != nil {
 		return "", fmt.Errorf("failed to Generate JWT")
 	}
 
 	return jwtToken, nil
 }
+
+// Unlock clears the failed-attempt counter of an email address using the
+// single-use token from the link emailed on lockout.
+func (s *LoginService) Unlock(token string, client models.ClientInfo) error {
+	return s.store.Transaction(func(tx repository.Store) error {
+		unlockToken, err := tx.Logins().UseUnlockToken(util.HashToken(token), time.Now())
+		if err != nil {
+			return fmt.Errorf("invalid or expired unlock link")
+		}
+
+		user, err := tx.Users().Get(unlockToken.UserID)
+		if err != nil {
+			return fmt.Errorf("invalid or expired unlock link")
+		}
+
+		return unlockLogin(tx, normalizeEmail(user.Email), &user.ID, models.Actor{ClientInfo: client})
+	})
+}
+
+// checkIPThrottle blocks an IP address with too many recent failures,
+// whichever accounts they were against.
+func (s *LoginService) checkIPThrottle(ip string) *ThrottledError {
+	ipFailures, err := s.store.Logins().CountFailedAttemptsFromIP(ip, time.Now().Add(-loginWindow))
+	if err != nil {
+		log.Println("failed to count login attempts:", err)
+		return nil
+	}
+
+	if ipFailures >= ipFailureLimit {
+		return &ThrottledError{RetryAfter: loginWindow, reason: LOGIN_IP_BLOCKED}
+	}
+
+	return nil
+}
+
+// checkAccountThrottle applies the progressive delay between failed attempts
+// for an email address and locks it after accountLockThreshold failures.
+// Failures only count since the last successful login or unlock.
+func (s *LoginService) checkAccountThrottle(email string) *ThrottledError {
+	now := time.Now()
+
+	attempts, err := s.store.Logins().ListAttempts(email, now.Add(-loginWindow))
+	if err != nil {
+		log.Println("failed to load login attempts:", err)
+		return nil
+	}
+
+	failures := []models.LoginAttempt{}
+	for _, attempt := range attempts {
+		if attempt.Success || attempt.Reason == LOGIN_UNLOCKED {
+			break
+		}
+		if attempt.Reason == LOGIN_INVALID_CREDENTIALS {
+			failures = append(failures, attempt)
+		}
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+
+	lastFailure := failures[0].CreatedAt
+	if len(failures) >= accountLockThreshold {
+		if wait := lastFailure.Add(accountLockDuration).Sub(now); wait > 0 {
+			return &ThrottledError{RetryAfter: wait, reason: LOGIN_LOCKED}
+		}
+		return nil
+	}
+
+	if wait := lastFailure.Add(loginDelay(len(failures))).Sub(now); wait > 0 {
+		return &ThrottledError{RetryAfter: wait, reason: LOGIN_THROTTLED}
+	}
+
+	return nil
+}
+
+// recordFailure stores a failed password check and, when it is the one that
+// locks the account, emails the owner an unlock link.
+func (s *LoginService) recordFailure(email string, user *models.User, client models.ClientInfo) {
+	var userID *uint
+	if user != nil {
+		userID = &user.ID
+	}
+	s.recordAttempt(email, userID, client, false, LOGIN_INVALID_CREDENTIALS)
+
+	if user == nil {
+		return
+	}
+
+	if err := s.checkAccountThrottle(email); err != nil && err.reason == LOGIN_LOCKED {
+		s.sendUnlockEmail(user)
+	}
+}
+
+func (s *LoginService) recordAttempt(email string, userID *uint, client models.ClientInfo, success bool, reason string) {
+	attempt := models.LoginAttempt{
+		Email:     email,
+		UserID:    userID,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		Success:   success,
+		Reason:    reason,
+	}
+
+	action := models.AUDIT_LOGIN_FAILURE
+	if success {
+		action = models.AUDIT_LOGIN_SUCCESS
+	}
+
+	// Attempts against unknown addresses have no user to point at. The
+	// address stays in login_attempts, which erasure can scrub.
+	var entityID interface{} = ""
+	if userID != nil {
+		entityID = *userID
+	}
+
+	if err := s.store.Transaction(func(tx repository.Store) error {
+		if err := tx.Logins().CreateAttempt(&attempt); err != nil {
+			return err
+		}
+		return recordAudit(tx, models.Actor{ClientInfo: client}, action, models.ENTITY_USER, entityID, nil, attempt)
+	}); err != nil {
+		log.Println("failed to record login attempt:", err)
+	}
+}
+
+func (s *LoginService) sendUnlockEmail(user *models.User) {
+	token, err := util.GenerateToken(32)
+	if err != nil {
+		log.Println("failed to create unlock token:", err)
+		return
+	}
+
+	if err := s.store.Logins().CreateUnlockToken(&models.LoginUnlockToken{
+		UserID:    user.ID,
+		TokenHash: util.HashToken(token),
+		ExpiresOn: time.Now().Add(accountUnlockTTL),
+	}); err != nil {
+		log.Println("failed to create unlock token:", err)
+		return
+	}
+
+	link := fmt.Sprintf("%s/login/unlock?token=%s", mailer.BaseURL(), url.QueryEscape(token))
+	if err := s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Your account has been locked",
+		Body: fmt.Sprintf("Hi %s,\n\nWe locked sign-in to your account for %s after %d failed login attempts.\n\n"+
+			"If this was you, you can unlock it now:\n\n%s\n\nIf it was not, consider resetting your password.\n",
+			user.FirstName, accountLockDuration, accountLockThreshold, link),
+	}); err != nil {
+		log.Println("failed to send unlock email:", err)
+	}
+}
+
+func (s *LoginService) createChallenge(userID uint) (string, error) {
+	challengeID, err := util.GenerateToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	challenge := models.LoginChallenge{
+		ChallengeHash: util.HashToken(challengeID),
+		UserID:        userID,
+		ExpiresOn:     time.Now().Add(loginChallengeTTL),
+	}
+
+	if err := s.store.Logins().CreateChallenge(&challenge); err != nil {
+		return "", err
+	}
+
+	return challengeID, nil
+}
+
+func (s *LoginService) recordFailedChallenge(challengeID string) {
+	if err := s.store.Logins().CountChallengeFailure(util.HashToken(challengeID)); err != nil {
+		log.Println("failed to record challenge attempt:", err)
+	}
+}
+
+func unlockLogin(tx repository.Store, email string, userID *uint, actor models.Actor) error {
+	attempt := models.LoginAttempt{
+		Email:     email,
+		UserID:    userID,
+		IP:        actor.IP,
+		UserAgent: actor.UserAgent,
+		Reason:    LOGIN_UNLOCKED,
+	}
+
+	if err := tx.Logins().CreateAttempt(&attempt); err != nil {
+		return fmt.Errorf("failed to unlock account")
+	}
+
+	var entityID interface{} = ""
+	if userID != nil {
+		entityID = *userID
+	}
+
+	if err := recordAudit(tx, actor, models.AUDIT_LOGIN_UNLOCK, models.ENTITY_USER, entityID, nil, attempt); err != nil {
+		return fmt.Errorf("failed to unlock account")
+	}
+
+	return nil
+}
+
+// loginDelay doubles the wait after every failure past loginDelayThreshold.
+func loginDelay(failures int) time.Duration {
+	if failures < loginDelayThreshold {
+		return 0
+	}
+
+	delay := time.Second * time.Duration(math.Pow(2, float64(failures-loginDelayThreshold)))
+	if delay > loginMaxDelay {
+		return loginMaxDelay
+	}
+	return delay
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/repository"
+	"github.com/FaizanAC/Go-Banking/internal/util"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginDelayIsProgressive(t *testing.T) {
+	assert.Equal(t, time.Duration(0), loginDelay(1))
+	assert.Equal(t, time.Duration(0), loginDelay(2))
+	assert.Equal(t, time.Second, loginDelay(3))
+	assert.Equal(t, time.Second*2, loginDelay(4))
+	assert.Equal(t, time.Second*16, loginDelay(7))
+	assert.Equal(t, loginMaxDelay, loginDelay(9))
+}
+
+func newTestLoginService() (*LoginService, *repository.MemoryStore, *recordingMailer) {
+	store := repository.NewMemoryStore()
+	mail := &recordingMailer{}
+	return NewLoginService(store, mail), store, mail
+}
+
+func TestLogin(t *testing.T) {
+	service, store, _ := newTestLoginService()
+	user := createTestUser(t, store, "user@example.com")
+	client := models.ClientInfo{IP: "192.0.2.1"}
+
+	result, err := service.Login(models.Login{Email: user.Email, Password: testPassword}, client)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, result.Token)
+
+	_, err = service.Login(models.Login{Email: user.Email, Password: "wrong"}, client)
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = service.Login(models.Login{Email: "nobody@example.com", Password: testPassword}, client)
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	attempts, _ := store.Logins().ListAttempts(user.Email, time.Now().Add(-time.Minute))
+	assert.Len(t, attempts, 2)
+	assert.Equal(t, LOGIN_INVALID_CREDENTIALS, attempts[0].Reason)
+	assert.Equal(t, LOGIN_SUCCESS, attempts[1].Reason)
+}
+
+func TestLoginThrottlesRepeatedFailures(t *testing.T) {
+	service, store, _ := newTestLoginService()
+	user := createTestUser(t, store, "user@example.com")
+	client := models.ClientInfo{IP: "192.0.2.1"}
+
+	for i := 0; i < loginDelayThreshold; i++ {
+		_, err := service.Login(models.Login{Email: user.Email, Password: "wrong"}, client)
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+
+	_, err := service.Login(models.Login{Email: user.Email, Password: testPassword}, client)
+	var throttled *ThrottledError
+	assert.ErrorAs(t, err, &throttled)
+	assert.Equal(t, LOGIN_THROTTLED, throttled.reason)
+}
+
+func TestLoginLocksAndUnlocks(t *testing.T) {
+	service, store, mail := newTestLoginService()
+	user := createTestUser(t, store, "user@example.com")
+	client := models.ClientInfo{IP: "192.0.2.1"}
+
+	// Backdate failures so the progressive delay has passed for each.
+	for i := 0; i < accountLockThreshold; i++ {
+		assert.NoError(t, store.Logins().CreateAttempt(&models.LoginAttempt{
+			GormModel: models.GormModel{CreatedAt: time.Now().Add(-time.Minute)},
+			Email:     user.Email, UserID: &user.ID, IP: "198.51.100.1", Reason: LOGIN_INVALID_CREDENTIALS,
+		}))
+	}
+
+	_, err := service.Login(models.Login{Email: user.Email, Password: testPassword}, client)
+	var throttled *ThrottledError
+	assert.ErrorAs(t, err, &throttled)
+	assert.Equal(t, LOGIN_LOCKED, throttled.reason)
+
+	service.sendUnlockEmail(&user)
+	token := mailedToken(t, mail, user.Email)
+	assert.NoError(t, service.Unlock(token, client))
+	assert.EqualError(t, service.Unlock(token, client), "invalid or expired unlock link")
+	assert.EqualError(t, service.Unlock("not-a-token", client), "invalid or expired unlock link")
+
+	result, err := service.Login(models.Login{Email: user.Email, Password: testPassword}, client)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, result.Token)
+	assert.Contains(t, auditActions(store), models.AUDIT_LOGIN_UNLOCK)
+}
+
+func TestUnlockTokensExpire(t *testing.T) {
+	service, store, _ := newTestLoginService()
+	user := createTestUser(t, store, "user@example.com")
+
+	assert.NoError(t, store.Logins().CreateUnlockToken(&models.LoginUnlockToken{
+		UserID: user.ID, TokenHash: util.HashToken("stale"), ExpiresOn: time.Now().Add(-time.Minute),
+	}))
+	assert.EqualError(t, service.Unlock("stale", models.ClientInfo{}), "invalid or expired unlock link")
+}
+
+func TestLoginNormalizesEmail(t *testing.T) {
+	service, store, _ := newTestLoginService()
+	createTestUser(t, store, "user@example.com")
+
+	result, err := service.Login(models.Login{Email: " User@Example.COM ", Password: testPassword}, models.ClientInfo{IP: "192.0.2.1"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, result.Token)
+}
+
+func TestLoginBlocksBusyIPs(t *testing.T) {
+	service, store, _ := newTestLoginService()
+	user := createTestUser(t, store, "user@example.com")
+
+	for i := 0; i < ipFailureLimit; i++ {
+		assert.NoError(t, store.Logins().CreateAttempt(&models.LoginAttempt{Email: "other@example.com", IP: "192.0.2.1", Reason: LOGIN_INVALID_CREDENTIALS}))
+	}
+
+	_, err := service.Login(models.Login{Email: user.Email, Password: testPassword}, models.ClientInfo{IP: "192.0.2.1"})
+	var throttled *ThrottledError
+	assert.ErrorAs(t, err, &throttled)
+	assert.Equal(t, LOGIN_IP_BLOCKED, throttled.reason)
+
+	_, err = service.Login(models.Login{Email: user.Email, Password: testPassword}, models.ClientInfo{IP: "192.0.2.2"})
+	assert.NoError(t, err)
+}
+
+func TestCompleteTwoFactorLogin(t *testing.T) {
+	service, store, _ := newTestLoginService()
+	user := createTestUser(t, store, "user@example.com")
+	client := models.ClientInfo{IP: "192.0.2.1"}
+
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: "test", AccountName: user.Email})
+	assert.NoError(t, err)
+	user.TOTPSecret, user.TOTPEnabled = key.Secret(), true
+	assert.NoError(t, store.Users().Update(&user, "TOTPSecret", "TOTPEnabled"))
+	store.AddRecoveryCode(user.ID, util.HashToken("abcd-efgh"))
+
+	result, err := service.Login(models.Login{Email: user.Email, Password: testPassword}, client)
+	assert.NoError(t, err)
+	assert.Empty(t, result.Token)
+	assert.NotEmpty(t, result.ChallengeID)
+
+	_, err = service.CompleteTwoFactorLogin(models.TwoFactorLogin{ChallengeID: result.ChallengeID, Code: "000000"}, client)
+	assert.EqualError(t, err, "invalid code")
+
+	challenge, _ := store.Logins().GetChallenge(util.HashToken(result.ChallengeID))
+	assert.Equal(t, 1, challenge.Attempts)
+
+	code, err := totp.GenerateCode(key.Secret(), time.Now())
+	assert.NoError(t, err)
+	token, err := service.CompleteTwoFactorLogin(models.TwoFactorLogin{ChallengeID: result.ChallengeID, Code: code}, client)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+
+	// A completed challenge cannot be used again.
+	_, err = service.CompleteTwoFactorLogin(models.TwoFactorLogin{ChallengeID: result.ChallengeID, Code: code}, client)
+	assert.EqualError(t, err, "invalid or expired challenge")
+
+	result, err = service.Login(models.Login{Email: user.Email, Password: testPassword}, client)
+	assert.NoError(t, err)
+	_, err = service.CompleteTwoFactorLogin(models.TwoFactorLogin{ChallengeID: result.ChallengeID, Code: "ABCD-EFGH"}, client)
+	assert.NoError(t, err)
+
+	used, _ := store.Logins().UseRecoveryCode(user.ID, util.HashToken("abcd-efgh"))
+	assert.False(t, used)
+}
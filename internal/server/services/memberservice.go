@@ -0,0 +1,174 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/repository"
+)
+
+func (s *BankService) ListMembers(accountNumber string, userID uint) ([]models.AccountMember, error) {
+	if _, _, err := s.accountForMember(accountNumber, userID); err != nil {
+		return nil, err
+	}
+
+	members, err := s.store.Accounts().ListMembers(accountNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list account holders")
+	}
+
+	return members, nil
+}
+
+// InviteMember adds a pending holder to the account. The invitee gets access
+// once they accept.
+func (s *BankService) InviteMember(accountNumber string, actor models.Actor, invite models.InviteMember) (models.AccountMember, error) {
+	_, member, err := s.accountForMember(accountNumber, actor.UserID)
+	if err != nil {
+		return models.AccountMember{}, err
+	}
+
+	if !member.CanManageMembers() {
+		return models.AccountMember{}, fmt.Errorf("you are not allowed to manage holders of this account")
+	}
+
+	if invite.Role == models.MEMBER_TRANSACTOR && invite.Limit <= 0 {
+		return models.AccountMember{}, fmt.Errorf("transactors need a positive limit")
+	}
+
+	invitee, err := s.store.Users().GetByEmail(invite.Email)
+	if err != nil {
+		return models.AccountMember{}, fmt.Errorf("no user with that email")
+	}
+
+	if _, err := s.store.Accounts().GetMember(accountNumber, invitee.ID); err == nil {
+		return models.AccountMember{}, fmt.Errorf("user is already a holder of this account")
+	}
+
+	newMember := models.AccountMember{
+		AccountNumber: accountNumber,
+		UserID:        invitee.ID,
+		Role:          invite.Role,
+		Limit:         invite.Limit,
+		InvitedBy:     actor.UserID,
+	}
+
+	if err := s.store.Transaction(func(tx repository.Store) error {
+		if err := tx.Accounts().CreateMember(&newMember); err != nil {
+			return err
+		}
+
+		return recordAudit(tx, actor, models.AUDIT_ACCOUNT_MEMBER_INVITE, models.ENTITY_MEMBER, newMember.ID, nil, newMember)
+	}); err != nil {
+		return newMember, fmt.Errorf("failed to invite holder")
+	}
+
+	return newMember, nil
+}
+
+func (s *BankService) ListInvitations(userID uint) ([]models.AccountMember, error) {
+	invitations, err := s.store.Accounts().ListInvitations(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations")
+	}
+
+	return invitations, nil
+}
+
+func (s *BankService) AcceptInvitation(accountNumber string, actor models.Actor) (models.AccountMember, error) {
+	member, err := s.store.Accounts().GetMember(accountNumber, actor.UserID)
+	if err != nil || member.IsActive() {
+		return models.AccountMember{}, fmt.Errorf("invitation not found")
+	}
+
+	before := member
+	now := time.Now()
+	if err := s.store.Transaction(func(tx repository.Store) error {
+		member.AcceptedAt = &now
+		if err := tx.Accounts().UpdateMember(&member, "AcceptedAt"); err != nil {
+			return err
+		}
+
+		return recordAudit(tx, actor, models.AUDIT_ACCOUNT_MEMBER_ACCEPT, models.ENTITY_MEMBER, member.ID, before, member)
+	}); err != nil {
+		return before, fmt.Errorf("failed to accept invitation")
+	}
+
+	return member, nil
+}
+
+func (s *BankService) UpdateMember(accountNumber string, actor models.Actor, memberUserID uint, update models.UpdateMember) (models.AccountMember, error) {
+	_, member, err := s.accountForMember(accountNumber, actor.UserID)
+	if err != nil {
+		return models.AccountMember{}, err
+	}
+
+	if !member.CanManageMembers() {
+		return models.AccountMember{}, fmt.Errorf("you are not allowed to manage holders of this account")
+	}
+
+	if update.Role == models.MEMBER_TRANSACTOR && update.Limit <= 0 {
+		return models.AccountMember{}, fmt.Errorf("transactors need a positive limit")
+	}
+
+	target, err := s.store.Accounts().GetMember(accountNumber, memberUserID)
+	if err != nil {
+		return target, fmt.Errorf("holder not found")
+	}
+
+	if target.Role == models.MEMBER_OWNER {
+		return target, fmt.Errorf("the owner's access cannot be changed")
+	}
+
+	before := target
+	if err := s.store.Transaction(func(tx repository.Store) error {
+		target.Role, target.Limit = update.Role, update.Limit
+		if err := tx.Accounts().UpdateMember(&target, "Role", "Limit"); err != nil {
+			return err
+		}
+
+		return recordAudit(tx, actor, models.AUDIT_ACCOUNT_MEMBER_UPDATE, models.ENTITY_MEMBER, target.ID, before, target)
+	}); err != nil {
+		return before, fmt.Errorf("failed to update holder")
+	}
+
+	return target, nil
+}
+
+// RemoveMember removes a holder or pending invitation. Holders may always
+// remove themselves, which is also how an invitation is declined; the owner
+// can never be removed.
+func (s *BankService) RemoveMember(accountNumber string, actor models.Actor, memberUserID uint) error {
+	target, err := s.store.Accounts().GetMember(accountNumber, memberUserID)
+	if err != nil {
+		return fmt.Errorf("holder not found")
+	}
+
+	if target.Role == models.MEMBER_OWNER {
+		return fmt.Errorf("the owner cannot be removed")
+	}
+
+	if memberUserID != actor.UserID {
+		_, member, err := s.accountForMember(accountNumber, actor.UserID)
+		if err != nil {
+			return err
+		}
+
+		if !member.CanManageMembers() {
+			return fmt.Errorf("you are not allowed to manage holders of this account")
+		}
+	}
+
+	if err := s.store.Transaction(func(tx repository.Store) error {
+		if err := tx.Accounts().DeleteMember(&target); err != nil {
+			return err
+		}
+
+		return recordAudit(tx, actor, models.AUDIT_ACCOUNT_MEMBER_REMOVE, models.ENTITY_MEMBER, target.ID, target, nil)
+	}); err != nil {
+		return fmt.Errorf("failed to remove holder")
+	}
+
+	return nil
+}
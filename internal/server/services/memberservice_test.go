@@ -0,0 +1,95 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInviteAndAcceptMember(t *testing.T) {
+	bank, store, owner, account := newTestBank(t, 0)
+	invitee := createTestUser(t, store, "invitee@example.com")
+
+	member, err := bank.InviteMember(account.AccountNumber, actorFor(owner), models.InviteMember{Email: invitee.Email, Role: models.MEMBER_VIEWER})
+	assert.NoError(t, err)
+	assert.False(t, member.IsActive())
+
+	_, err = bank.InviteMember(account.AccountNumber, actorFor(owner), models.InviteMember{Email: invitee.Email, Role: models.MEMBER_VIEWER})
+	assert.EqualError(t, err, "user is already a holder of this account")
+
+	_, err = bank.InviteMember(account.AccountNumber, actorFor(owner), models.InviteMember{Email: "nobody@example.com", Role: models.MEMBER_VIEWER})
+	assert.EqualError(t, err, "no user with that email")
+
+	invitations, err := bank.ListInvitations(invitee.ID)
+	assert.NoError(t, err)
+	assert.Len(t, invitations, 1)
+
+	_, err = bank.ListMembers(account.AccountNumber, invitee.ID)
+	assert.EqualError(t, err, "you are not a holder of this account")
+
+	member, err = bank.AcceptInvitation(account.AccountNumber, actorFor(invitee))
+	assert.NoError(t, err)
+	assert.True(t, member.IsActive())
+
+	_, err = bank.AcceptInvitation(account.AccountNumber, actorFor(invitee))
+	assert.EqualError(t, err, "invitation not found")
+
+	members, err := bank.ListMembers(account.AccountNumber, invitee.ID)
+	assert.NoError(t, err)
+	assert.Len(t, members, 2)
+	assert.Equal(t, models.MEMBER_OWNER, members[0].Role)
+}
+
+func TestInviteMemberRequiresManager(t *testing.T) {
+	bank, store, owner, account := newTestBank(t, 0)
+	viewer := createTestUser(t, store, "viewer@example.com")
+
+	_, err := bank.InviteMember(account.AccountNumber, actorFor(owner), models.InviteMember{Email: viewer.Email, Role: models.MEMBER_VIEWER})
+	assert.NoError(t, err)
+	_, err = bank.AcceptInvitation(account.AccountNumber, actorFor(viewer))
+	assert.NoError(t, err)
+
+	_, err = bank.InviteMember(account.AccountNumber, actorFor(viewer), models.InviteMember{Email: owner.Email, Role: models.MEMBER_VIEWER})
+	assert.EqualError(t, err, "you are not allowed to manage holders of this account")
+
+	_, err = bank.InviteMember(account.AccountNumber, actorFor(owner), models.InviteMember{Email: viewer.Email, Role: models.MEMBER_TRANSACTOR})
+	assert.EqualError(t, err, "transactors need a positive limit")
+}
+
+func TestUpdateMember(t *testing.T) {
+	bank, store, owner, account := newTestBank(t, 0)
+	holder := createTestUser(t, store, "holder2@example.com")
+
+	_, err := bank.InviteMember(account.AccountNumber, actorFor(owner), models.InviteMember{Email: holder.Email, Role: models.MEMBER_VIEWER})
+	assert.NoError(t, err)
+
+	member, err := bank.UpdateMember(account.AccountNumber, actorFor(owner), holder.ID, models.UpdateMember{Role: models.MEMBER_TRANSACTOR, Limit: 50})
+	assert.NoError(t, err)
+	assert.Equal(t, models.MEMBER_TRANSACTOR, member.Role)
+
+	stored, _ := store.Accounts().GetMember(account.AccountNumber, holder.ID)
+	assert.Equal(t, 50.0, stored.Limit)
+
+	_, err = bank.UpdateMember(account.AccountNumber, actorFor(owner), owner.ID, models.UpdateMember{Role: models.MEMBER_VIEWER})
+	assert.EqualError(t, err, "the owner's access cannot be changed")
+
+	assert.Contains(t, auditActions(store), models.AUDIT_ACCOUNT_MEMBER_UPDATE)
+}
+
+func TestRemoveMember(t *testing.T) {
+	bank, store, owner, account := newTestBank(t, 0)
+	holder := createTestUser(t, store, "holder2@example.com")
+
+	_, err := bank.InviteMember(account.AccountNumber, actorFor(owner), models.InviteMember{Email: holder.Email, Role: models.MEMBER_VIEWER})
+	assert.NoError(t, err)
+
+	assert.EqualError(t, bank.RemoveMember(account.AccountNumber, actorFor(holder), owner.ID), "the owner cannot be removed")
+
+	// Declining an invitation is removing yourself.
+	assert.NoError(t, bank.RemoveMember(account.AccountNumber, actorFor(holder), holder.ID))
+	assert.EqualError(t, bank.RemoveMember(account.AccountNumber, actorFor(owner), holder.ID), "holder not found")
+
+	invitations, _ := bank.ListInvitations(holder.ID)
+	assert.Empty(t, invitations)
+}
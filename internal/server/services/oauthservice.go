@@ -0,0 +1,506 @@
+package services
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/repository"
+	"github.com/FaizanAC/Go-Banking/internal/util"
+	"gorm.io/gorm"
+)
+
+const (
+	oauthAuthorizationRequestTTL = time.Minute * 10
+	oauthAuthorizationCodeTTL    = time.Minute * 10
+	oauthRefreshTokenTTL         = time.Hour * 24 * 30
+)
+
+// OAuthError is an RFC 6749 error. When RedirectURI is set the error is
+// reported to the client by redirecting the user agent; otherwise it is
+// shown to the user or returned from the token endpoint.
+type OAuthError struct {
+	Code        string
+	Description string
+	RedirectURI string
+	State       string
+}
+
+func (e *OAuthError) Error() string {
+	return e.Description
+}
+
+// Location returns the client redirect carrying the error.
+func (e *OAuthError) Location() string {
+	params := url.Values{"error": {e.Code}, "error_description": {e.Description}}
+	if e.State != "" {
+		params.Set("state", e.State)
+	}
+	return appendQuery(e.RedirectURI, params)
+}
+
+type OAuthService struct {
+	db *gorm.DB
+}
+
+func NewOAuthService(db *gorm.DB) *OAuthService {
+	return &OAuthService{db: db}
+}
+
+// RegisterClient creates a third-party application. Confidential clients get
+// a secret, which is only returned here.
+func (s *OAuthService) RegisterClient(actor models.Actor, request models.NewOAuthClient) (models.OAuthClientResponse, error) {
+	for _, scope := range request.Scopes {
+		if !models.IsValidOAuthScope(scope) {
+			return models.OAuthClientResponse{}, fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+
+	clientID, err := util.GenerateToken(12)
+	if err != nil {
+		return models.OAuthClientResponse{}, fmt.Errorf("failed to register client")
+	}
+
+	client := models.OAuthClient{
+		ClientID:     clientID,
+		Name:         request.Name,
+		RedirectURIs: strings.Join(request.RedirectURIs, " "),
+		Scopes:       strings.Join(request.Scopes, " "),
+		OwnerID:      actor.UserID,
+	}
+
+	var clientSecret string
+	if request.Confidential {
+		clientSecret, err = util.GenerateToken(32)
+		if err != nil {
+			return models.OAuthClientResponse{}, fmt.Errorf("failed to register client")
+		}
+		client.ClientSecretHash = util.HashToken(clientSecret)
+	}
+
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&client).Error; err != nil {
+			return err
+		}
+
+		return recordAudit(repository.NewGormStore(tx), actor, models.AUDIT_OAUTH_CLIENT_REGISTER, models.ENTITY_OAUTH_CLIENT, client.ClientID, nil, client)
+	}); err != nil {
+		return models.OAuthClientResponse{}, fmt.Errorf("failed to register client")
+	}
+
+	return models.OAuthClientResponse{
+		ClientID:     client.ClientID,
+		ClientSecret: clientSecret,
+		Name:         client.Name,
+		RedirectURIs: request.RedirectURIs,
+		Scopes:       request.Scopes,
+	}, nil
+}
+
+// StartAuthorization validates an authorization request and stores it until
+// the user approves or denies it on the consent screen.
+func (s *OAuthService) StartAuthorization(userID uint, request models.OAuthAuthorize) (string, models.OAuthClient, []string, error) {
+	var client models.OAuthClient
+	if err := s.db.Where("client_id = ?", request.ClientID).First(&client).Error; err != nil {
+		return "", client, nil, &OAuthError{Code: "invalid_client", Description: "unknown client"}
+	}
+
+	if !client.AllowsRedirectURI(request.RedirectURI) {
+		return "", client, nil, &OAuthError{Code: "invalid_request", Description: "redirect_uri is not registered for this client"}
+	}
+
+	redirectError := func(code string, description string) error {
+		return &OAuthError{Code: code, Description: description, RedirectURI: request.RedirectURI, State: request.State}
+	}
+
+	if request.ResponseType != "code" {
+		return "", client, nil, redirectError("unsupported_response_type", "only the code response type is supported")
+	}
+
+	if request.CodeChallenge == "" || request.CodeChallengeMethod != "S256" {
+		return "", client, nil, redirectError("invalid_request", "PKCE with the S256 method is required")
+	}
+
+	scopes := strings.Fields(request.Scope)
+	if len(scopes) == 0 {
+		return "", client, nil, redirectError("invalid_scope", "no scope requested")
+	}
+
+	for _, scope := range scopes {
+		if !models.IsValidOAuthScope(scope) || !client.AllowsScope(scope) {
+			return "", client, nil, redirectError("invalid_scope", fmt.Sprintf("scope %q is not allowed", scope))
+		}
+	}
+
+	requestID, err := util.GenerateToken(32)
+	if err != nil {
+		return "", client, nil, redirectError("server_error", "failed to start authorization")
+	}
+
+	if err := s.db.Create(&models.OAuthAuthorizationRequest{
+		RequestHash:   util.HashToken(requestID),
+		UserID:        userID,
+		ClientID:      client.ClientID,
+		RedirectURI:   request.RedirectURI,
+		Scopes:        strings.Join(scopes, " "),
+		State:         request.State,
+		CodeChallenge: request.CodeChallenge,
+		ExpiresOn:     time.Now().Add(oauthAuthorizationRequestTTL),
+	}).Error; err != nil {
+		return "", client, nil, redirectError("server_error", "failed to start authorization")
+	}
+
+	return requestID, client, scopes, nil
+}
+
+// CompleteAuthorization records the user's decision and returns the URL the
+// user agent is sent back to, carrying either a code or an access_denied
+// error.
+func (s *OAuthService) CompleteAuthorization(actor models.Actor, requestID string, approved bool) (string, error) {
+	userID := actor.UserID
+	var location string
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var request models.OAuthAuthorizationRequest
+		if err := tx.Where("request_hash = ? AND user_id = ?", util.HashToken(requestID), userID).First(&request).Error; err != nil {
+			return fmt.Errorf("authorization request not found")
+		}
+
+		if err := tx.Delete(&request).Error; err != nil {
+			return fmt.Errorf("failed to complete authorization")
+		}
+
+		if time.Now().After(request.ExpiresOn) {
+			return fmt.Errorf("authorization request has expired")
+		}
+
+		if !approved {
+			location = (&OAuthError{
+				Code:        "access_denied",
+				Description: "the user denied the request",
+				RedirectURI: request.RedirectURI,
+				State:       request.State,
+			}).Location()
+			return nil
+		}
+
+		consent, err := grantConsent(tx, actor, request.ClientID, strings.Fields(request.Scopes))
+		if err != nil {
+			return fmt.Errorf("failed to complete authorization")
+		}
+
+		code, err := util.GenerateToken(32)
+		if err != nil {
+			return fmt.Errorf("failed to complete authorization")
+		}
+
+		if err := tx.Create(&models.OAuthAuthorizationCode{
+			CodeHash:      util.HashToken(code),
+			ConsentID:     consent.ID,
+			UserID:        userID,
+			ClientID:      request.ClientID,
+			RedirectURI:   request.RedirectURI,
+			Scopes:        request.Scopes,
+			CodeChallenge: request.CodeChallenge,
+			ExpiresOn:     time.Now().Add(oauthAuthorizationCodeTTL),
+		}).Error; err != nil {
+			return fmt.Errorf("failed to complete authorization")
+		}
+
+		params := url.Values{"code": {code}}
+		if request.State != "" {
+			params.Set("state", request.State)
+		}
+		location = appendQuery(request.RedirectURI, params)
+		return nil
+	})
+
+	return location, err
+}
+
+// ExchangeToken implements the authorization_code and refresh_token grants.
+func (s *OAuthService) ExchangeToken(request models.OAuthTokenRequest) (models.OAuthTokenResponse, error) {
+	client, err := s.authenticateClient(request.ClientID, request.ClientSecret)
+	if err != nil {
+		return models.OAuthTokenResponse{}, err
+	}
+
+	switch request.GrantType {
+	case "authorization_code":
+		return s.exchangeAuthorizationCode(client, request)
+	case "refresh_token":
+		return s.exchangeRefreshToken(client, request)
+	default:
+		return models.OAuthTokenResponse{}, &OAuthError{Code: "unsupported_grant_type", Description: "unsupported grant_type"}
+	}
+}
+
+func (s *OAuthService) ListConsents(userID uint) ([]models.OAuthConsentResponse, error) {
+	var consents []models.OAuthConsent
+	if err := s.db.Where("user_id = ? AND revoked_at IS NULL", userID).Order("created_at desc").Find(&consents).Error; err != nil {
+		return nil, fmt.Errorf("failed to list consents")
+	}
+
+	responses := make([]models.OAuthConsentResponse, 0, len(consents))
+	for _, consent := range consents {
+		var client models.OAuthClient
+		s.db.Where("client_id = ?", consent.ClientID).First(&client)
+
+		responses = append(responses, models.OAuthConsentResponse{
+			ID:         consent.ID,
+			ClientID:   consent.ClientID,
+			ClientName: client.Name,
+			Scopes:     strings.Fields(consent.Scopes),
+			GrantedAt:  consent.CreatedAt,
+		})
+	}
+
+	return responses, nil
+}
+
+// RevokeConsent withdraws a client's access. Outstanding access tokens are
+// rejected by the auth middleware and refresh tokens stop working.
+func (s *OAuthService) RevokeConsent(actor models.Actor, consentID uint) error {
+	var consent models.OAuthConsent
+	if err := s.db.Where("id = ? AND user_id = ? AND revoked_at IS NULL", consentID, actor.UserID).First(&consent).Error; err != nil {
+		return fmt.Errorf("consent not found")
+	}
+
+	return revokeConsent(s.db, actor, consent)
+}
+
+func (s *OAuthService) authenticateClient(clientID string, clientSecret string) (models.OAuthClient, error) {
+	var client models.OAuthClient
+	if err := s.db.Where("client_id = ?", clientID).First(&client).Error; err != nil {
+		return client, &OAuthError{Code: "invalid_client", Description: "client authentication failed"}
+	}
+
+	if client.IsConfidential() &&
+		subtle.ConstantTimeCompare([]byte(util.HashToken(clientSecret)), []byte(client.ClientSecretHash)) != 1 {
+		return client, &OAuthError{Code: "invalid_client", Description: "client authentication failed"}
+	}
+
+	return client, nil
+}
+
+func (s *OAuthService) exchangeAuthorizationCode(client models.OAuthClient, request models.OAuthTokenRequest) (models.OAuthTokenResponse, error) {
+	var response models.OAuthTokenResponse
+	var replayedConsentID uint
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var code models.OAuthAuthorizationCode
+		if err := tx.Where("code_hash = ?", util.HashToken(request.Code)).First(&code).Error; err != nil {
+			return &OAuthError{Code: "invalid_grant", Description: "invalid authorization code"}
+		}
+
+		if code.UsedAt != nil {
+			// A replayed code suggests it leaked, so revoke what it granted.
+			replayedConsentID = code.ConsentID
+			return &OAuthError{Code: "invalid_grant", Description: "authorization code has already been used"}
+		}
+
+		if code.ClientID != client.ClientID || code.RedirectURI != request.RedirectURI || time.Now().After(code.ExpiresOn) {
+			return &OAuthError{Code: "invalid_grant", Description: "invalid authorization code"}
+		}
+
+		if !verifyCodeChallenge(request.CodeVerifier, code.CodeChallenge) {
+			return &OAuthError{Code: "invalid_grant", Description: "code_verifier does not match"}
+		}
+
+		// Only one of two concurrent exchanges can mark the code used.
+		now := time.Now()
+		res := tx.Model(&code).Where("used_at IS NULL").Update("used_at", &now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			replayedConsentID = code.ConsentID
+			return &OAuthError{Code: "invalid_grant", Description: "authorization code has already been used"}
+		}
+
+		var err error
+		response, err = issueOAuthTokens(tx, code.UserID, client.ClientID, code.ConsentID, code.Scopes)
+		return err
+	})
+
+	s.revokeReplayedConsent(replayedConsentID)
+	return response, s.oauthError(err)
+}
+
+func (s *OAuthService) exchangeRefreshToken(client models.OAuthClient, request models.OAuthTokenRequest) (models.OAuthTokenResponse, error) {
+	var response models.OAuthTokenResponse
+	var replayedConsentID uint
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var refreshToken models.OAuthRefreshToken
+		if err := tx.Where("token_hash = ?", util.HashToken(request.RefreshToken)).First(&refreshToken).Error; err != nil {
+			return &OAuthError{Code: "invalid_grant", Description: "invalid refresh token"}
+		}
+
+		var consent models.OAuthConsent
+		if err := tx.First(&consent, refreshToken.ConsentID).Error; err != nil || consent.ClientID != client.ClientID {
+			return &OAuthError{Code: "invalid_grant", Description: "invalid refresh token"}
+		}
+
+		if refreshToken.UsedAt != nil {
+			// Refresh tokens rotate on every use; a second use means one leaked.
+			replayedConsentID = consent.ID
+			return &OAuthError{Code: "invalid_grant", Description: "refresh token has already been used"}
+		}
+
+		if consent.RevokedAt != nil || time.Now().After(refreshToken.ExpiresOn) {
+			return &OAuthError{Code: "invalid_grant", Description: "invalid refresh token"}
+		}
+
+		now := time.Now()
+		res := tx.Model(&refreshToken).Where("used_at IS NULL").Update("used_at", &now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			replayedConsentID = consent.ID
+			return &OAuthError{Code: "invalid_grant", Description: "refresh token has already been used"}
+		}
+
+		// Scopes the user granted the client since keep to their own tokens.
+		var err error
+		response, err = issueOAuthTokens(tx, consent.UserID, client.ClientID, consent.ID, refreshToken.Scopes)
+		return err
+	})
+
+	s.revokeReplayedConsent(replayedConsentID)
+	return response, s.oauthError(err)
+}
+
+func (s *OAuthService) revokeReplayedConsent(consentID uint) {
+	if consentID == 0 {
+		return
+	}
+
+	var consent models.OAuthConsent
+	if err := s.db.Where("id = ? AND revoked_at IS NULL", consentID).First(&consent).Error; err != nil {
+		return
+	}
+
+	if err := revokeConsent(s.db, models.Actor{}, consent); err != nil {
+		log.Println("failed to revoke replayed consent:", err)
+	}
+}
+
+func revokeConsent(db *gorm.DB, actor models.Actor, consent models.OAuthConsent) error {
+	before := consent
+	return db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		res := tx.Model(&consent).Where("revoked_at IS NULL").Update("revoked_at", &now)
+		if res.Error != nil {
+			return fmt.Errorf("failed to revoke consent")
+		}
+
+		if res.RowsAffected == 0 {
+			return fmt.Errorf("consent not found")
+		}
+
+		consent.RevokedAt = &now
+		if err := recordAudit(repository.NewGormStore(tx), actor, models.AUDIT_OAUTH_CONSENT_REVOKE, models.ENTITY_OAUTH_CONSENT, consent.ID, before, consent); err != nil {
+			return fmt.Errorf("failed to revoke consent")
+		}
+
+		return nil
+	})
+}
+
+// oauthError passes RFC 6749 errors through and reports anything else as a
+// server_error.
+func (s *OAuthService) oauthError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if oauthErr, ok := err.(*OAuthError); ok {
+		return oauthErr
+	}
+
+	return &OAuthError{Code: "server_error", Description: "failed to issue tokens"}
+}
+
+func grantConsent(tx *gorm.DB, actor models.Actor, clientID string, scopes []string) (models.OAuthConsent, error) {
+	var consent models.OAuthConsent
+	err := tx.Where("user_id = ? AND client_id = ? AND revoked_at IS NULL", actor.UserID, clientID).First(&consent).Error
+	if err != nil {
+		consent = models.OAuthConsent{UserID: actor.UserID, ClientID: clientID, Scopes: strings.Join(scopes, " ")}
+		if err := tx.Create(&consent).Error; err != nil {
+			return consent, err
+		}
+		return consent, recordAudit(repository.NewGormStore(tx), actor, models.AUDIT_OAUTH_CONSENT_GRANT, models.ENTITY_OAUTH_CONSENT, consent.ID, nil, consent)
+	}
+
+	before := consent
+
+	granted := strings.Fields(consent.Scopes)
+	for _, scope := range scopes {
+		if !slices.Contains(granted, scope) {
+			granted = append(granted, scope)
+		}
+	}
+
+	consent.Scopes = strings.Join(granted, " ")
+	if err := tx.Model(&consent).Update("scopes", consent.Scopes).Error; err != nil {
+		return consent, err
+	}
+	return consent, recordAudit(repository.NewGormStore(tx), actor, models.AUDIT_OAUTH_CONSENT_GRANT, models.ENTITY_OAUTH_CONSENT, consent.ID, before, consent)
+}
+
+func issueOAuthTokens(tx *gorm.DB, userID uint, clientID string, consentID uint, scope string) (models.OAuthTokenResponse, error) {
+	accessToken, err := util.GenerateOAuthAccessToken(userID, clientID, consentID, scope)
+	if err != nil {
+		return models.OAuthTokenResponse{}, err
+	}
+
+	refreshToken, err := util.GenerateToken(32)
+	if err != nil {
+		return models.OAuthTokenResponse{}, err
+	}
+
+	if err := tx.Create(&models.OAuthRefreshToken{
+		TokenHash: util.HashToken(refreshToken),
+		ConsentID: consentID,
+		Scopes:    scope,
+		ExpiresOn: time.Now().Add(oauthRefreshTokenTTL),
+	}).Error; err != nil {
+		return models.OAuthTokenResponse{}, err
+	}
+
+	return models.OAuthTokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(util.OAuthAccessTokenLifetime.Seconds()),
+		RefreshToken: refreshToken,
+		Scope:        scope,
+	}, nil
+}
+
+// verifyCodeChallenge checks a PKCE code_verifier against its S256 challenge.
+func verifyCodeChallenge(verifier string, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+func appendQuery(rawURL string, params url.Values) string {
+	separator := "?"
+	if strings.Contains(rawURL, "?") {
+		separator = "&"
+	}
+	return rawURL + separator + params.Encode()
+}
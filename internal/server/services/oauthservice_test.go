@@ -0,0 +1,249 @@
+package services
+
+import (
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/FaizanAC/Go-Banking/internal/middleware"
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testRedirectURI   = "https://app.example.com/callback"
+	testCodeVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	testCodeChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+// authorizeClient registers a confidential client and runs the consent flow
+// for user, returning the client and the code from the redirect.
+func authorizeClient(t *testing.T, service *OAuthService, user models.User) (models.OAuthClientResponse, string) {
+	client, err := service.RegisterClient(actorFor(user), models.NewOAuthClient{
+		Name:         "Budget App",
+		RedirectURIs: []string{testRedirectURI},
+		Scopes:       []string{models.OAUTH_SCOPE_ACCOUNTS_READ},
+		Confidential: true,
+	})
+	assert.NoError(t, err)
+
+	requestID, _, scopes, err := service.StartAuthorization(user.ID, models.OAuthAuthorize{
+		ResponseType:        "code",
+		ClientID:            client.ClientID,
+		RedirectURI:         testRedirectURI,
+		Scope:               models.OAUTH_SCOPE_ACCOUNTS_READ,
+		State:               "xyz",
+		CodeChallenge:       testCodeChallenge,
+		CodeChallengeMethod: "S256",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{models.OAUTH_SCOPE_ACCOUNTS_READ}, scopes)
+
+	location, err := service.CompleteAuthorization(actorFor(user), requestID, true)
+	assert.NoError(t, err)
+
+	redirect, err := url.Parse(location)
+	assert.NoError(t, err)
+	assert.Equal(t, "xyz", redirect.Query().Get("state"))
+	return client, redirect.Query().Get("code")
+}
+
+func codeGrant(client models.OAuthClientResponse, code string, verifier string) models.OAuthTokenRequest {
+	return models.OAuthTokenRequest{
+		GrantType:    "authorization_code",
+		Code:         code,
+		RedirectURI:  testRedirectURI,
+		CodeVerifier: verifier,
+		ClientID:     client.ClientID,
+		ClientSecret: client.ClientSecret,
+	}
+}
+
+func refreshGrant(client models.OAuthClientResponse, refreshToken string) models.OAuthTokenRequest {
+	return models.OAuthTokenRequest{
+		GrantType:    "refresh_token",
+		RefreshToken: refreshToken,
+		ClientID:     client.ClientID,
+		ClientSecret: client.ClientSecret,
+	}
+}
+
+func assertOAuthError(t *testing.T, err error, code string, description string) {
+	oauthErr, ok := err.(*OAuthError)
+	if assert.True(t, ok, "%v", err) {
+		assert.Equal(t, code, oauthErr.Code)
+		assert.Equal(t, description, oauthErr.Description)
+	}
+}
+
+func TestAuthorizationCodeExchange(t *testing.T) {
+	db, store := newTestDB(t)
+	service := NewOAuthService(db)
+	user := createTestUser(t, store, "oauth@example.com")
+	client, code := authorizeClient(t, service, user)
+
+	_, err := service.ExchangeToken(codeGrant(models.OAuthClientResponse{ClientID: client.ClientID, ClientSecret: "wrong"}, code, testCodeVerifier))
+	assertOAuthError(t, err, "invalid_client", "client authentication failed")
+
+	_, err = service.ExchangeToken(codeGrant(client, code, testCodeVerifier[1:]+"x"))
+	assertOAuthError(t, err, "invalid_grant", "code_verifier does not match")
+
+	tokens, err := service.ExchangeToken(codeGrant(client, code, testCodeVerifier))
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer", tokens.TokenType)
+	assert.Equal(t, models.OAUTH_SCOPE_ACCOUNTS_READ, tokens.Scope)
+	assert.NotEmpty(t, tokens.RefreshToken)
+
+	claims, err := util.ParseJWT(tokens.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, client.ClientID, claims.ClientID)
+	_, err = middleware.VerifySession(db, claims)
+	assert.NoError(t, err)
+}
+
+func TestAuthorizationCodeReuseRevokesGrant(t *testing.T) {
+	db, store := newTestDB(t)
+	service := NewOAuthService(db)
+	user := createTestUser(t, store, "reuse@example.com")
+	client, code := authorizeClient(t, service, user)
+
+	tokens, err := service.ExchangeToken(codeGrant(client, code, testCodeVerifier))
+	assert.NoError(t, err)
+
+	_, err = service.ExchangeToken(codeGrant(client, code, testCodeVerifier))
+	assertOAuthError(t, err, "invalid_grant", "authorization code has already been used")
+
+	// The replay revokes everything the code granted.
+	_, err = service.ExchangeToken(refreshGrant(client, tokens.RefreshToken))
+	assertOAuthError(t, err, "invalid_grant", "invalid refresh token")
+
+	consents, err := service.ListConsents(user.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, consents)
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	db, store := newTestDB(t)
+	service := NewOAuthService(db)
+	user := createTestUser(t, store, "refresh@example.com")
+	client, code := authorizeClient(t, service, user)
+
+	first, err := service.ExchangeToken(codeGrant(client, code, testCodeVerifier))
+	assert.NoError(t, err)
+
+	second, err := service.ExchangeToken(refreshGrant(client, first.RefreshToken))
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+	// Using a rotated-out token again revokes the grant, including the
+	// token issued in its place.
+	_, err = service.ExchangeToken(refreshGrant(client, first.RefreshToken))
+	assertOAuthError(t, err, "invalid_grant", "refresh token has already been used")
+
+	_, err = service.ExchangeToken(refreshGrant(client, second.RefreshToken))
+	assertOAuthError(t, err, "invalid_grant", "invalid refresh token")
+}
+
+func TestRefreshKeepsOriginalScopes(t *testing.T) {
+	db, store := newTestDB(t)
+	service := NewOAuthService(db)
+	user := createTestUser(t, store, "scopes@example.com")
+	client, code := authorizeClient(t, service, user)
+
+	tokens, err := service.ExchangeToken(codeGrant(client, code, testCodeVerifier))
+	assert.NoError(t, err)
+
+	// A later authorization adds to the consent, but not to this chain.
+	assert.NoError(t, db.Model(&models.OAuthConsent{}).Where("user_id = ?", user.ID).
+		Update("scopes", models.OAUTH_SCOPE_ACCOUNTS_READ+" "+models.OAUTH_SCOPE_PAYMENTS_WRITE).Error)
+
+	refreshed, err := service.ExchangeToken(refreshGrant(client, tokens.RefreshToken))
+	assert.NoError(t, err)
+	assert.Equal(t, models.OAUTH_SCOPE_ACCOUNTS_READ, refreshed.Scope)
+
+	claims, err := util.ParseJWT(refreshed.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, models.OAUTH_SCOPE_ACCOUNTS_READ, claims.Scope)
+}
+
+func TestConcurrentCodeExchangesIssueOnce(t *testing.T) {
+	db, store := newTestDB(t)
+	service := NewOAuthService(db)
+	user := createTestUser(t, store, "race@example.com")
+	client, code := authorizeClient(t, service, user)
+
+	var wg sync.WaitGroup
+	var issued atomic.Int32
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := service.ExchangeToken(codeGrant(client, code, testCodeVerifier)); err == nil {
+				issued.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), issued.Load())
+}
+
+func TestRevokeConsentEndsAccess(t *testing.T) {
+	db, store := newTestDB(t)
+	service := NewOAuthService(db)
+	user := createTestUser(t, store, "revoke@example.com")
+	other := createTestUser(t, store, "other@example.com")
+	client, code := authorizeClient(t, service, user)
+
+	tokens, err := service.ExchangeToken(codeGrant(client, code, testCodeVerifier))
+	assert.NoError(t, err)
+
+	consents, err := service.ListConsents(user.ID)
+	assert.NoError(t, err)
+	if !assert.Len(t, consents, 1) {
+		return
+	}
+	assert.Equal(t, "Budget App", consents[0].ClientName)
+
+	assert.EqualError(t, service.RevokeConsent(actorFor(other), consents[0].ID), "consent not found")
+	assert.NoError(t, service.RevokeConsent(actorFor(user), consents[0].ID))
+
+	claims, err := util.ParseJWT(tokens.AccessToken)
+	assert.NoError(t, err)
+	_, err = middleware.VerifySession(db, claims)
+	assert.EqualError(t, err, "the access grant has been revoked")
+
+	_, err = service.ExchangeToken(refreshGrant(client, tokens.RefreshToken))
+	assertOAuthError(t, err, "invalid_grant", "invalid refresh token")
+
+	var actions []string
+	db.Model(&models.AuditLog{}).Where("entity_type = ?", models.ENTITY_OAUTH_CONSENT).Order("id").Pluck("action", &actions)
+	assert.Equal(t, []string{models.AUDIT_OAUTH_CONSENT_GRANT, models.AUDIT_OAUTH_CONSENT_REVOKE}, actions)
+}
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	// Example from RFC 7636, appendix B.
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	assert.True(t, verifyCodeChallenge(verifier, challenge))
+	assert.False(t, verifyCodeChallenge(verifier+"x", challenge))
+	assert.False(t, verifyCodeChallenge("too-short", challenge))
+}
+
+func TestOAuthErrorLocation(t *testing.T) {
+	err := &OAuthError{
+		Code:        "access_denied",
+		Description: "the user denied the request",
+		RedirectURI: "https://app.example.com/callback?source=bank",
+		State:       "xyz",
+	}
+
+	location, parseErr := url.Parse(err.Location())
+	assert.Nil(t, parseErr)
+	assert.Equal(t, "bank", location.Query().Get("source"))
+	assert.Equal(t, "access_denied", location.Query().Get("error"))
+	assert.Equal(t, "xyz", location.Query().Get("state"))
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/events"
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const OUTBOX_BATCH_SIZE = 100
+
+// OUTBOX_CLAIM_TIMEOUT is how long a relay may take to publish the batch it
+// claimed before another relay takes the events over.
+const OUTBOX_CLAIM_TIMEOUT = 5 * time.Minute
+
+// OutboxRelay publishes outbox events to its sinks in the order they were
+// written. An event is only marked published once every sink has accepted
+// it, so delivery is at-least-once.
+type OutboxRelay struct {
+	db    *gorm.DB
+	sinks []events.Sink
+}
+
+func NewOutboxRelay(db *gorm.DB, sinks ...events.Sink) *OutboxRelay {
+	return &OutboxRelay{db: db, sinks: sinks}
+}
+
+// RelayOnce publishes up to batchSize pending events and returns how many
+// were published. It stops at the first event a sink rejects so that later
+// events are never delivered ahead of it.
+//
+// The batch is claimed in a short transaction and published after it
+// commits, so slow sinks hold no locks and sinks can write to the database
+// themselves.
+func (r *OutboxRelay) RelayOnce(batchSize int) (int, error) {
+	pending, err := r.claim(batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, event := range pending {
+		if publishErr := r.publish(event); publishErr != nil {
+			if err := r.db.Model(&event).Updates(map[string]interface{}{
+				"attempts":   event.Attempts + 1,
+				"last_error": publishErr.Error(),
+			}).Error; err != nil {
+				return i, err
+			}
+			return i, r.release(pending[i:])
+		}
+
+		now := time.Now()
+		if err := r.db.Model(&event).Updates(map[string]interface{}{
+			"published_at":  &now,
+			"claimed_until": nil,
+		}).Error; err != nil {
+			return i, err
+		}
+	}
+
+	return len(pending), nil
+}
+
+// claim takes the oldest pending events for OUTBOX_CLAIM_TIMEOUT. It claims
+// nothing while another relay holds the head of the queue, so two relays
+// never publish events out of order.
+func (r *OutboxRelay) claim(batchSize int) ([]models.OutboxEvent, error) {
+	var pending []models.OutboxEvent
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("published_at IS NULL").Order("id").Limit(batchSize).Find(&pending).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if len(pending) == 0 || (pending[0].ClaimedUntil != nil && pending[0].ClaimedUntil.After(now)) {
+			pending = nil
+			return nil
+		}
+
+		until := now.Add(OUTBOX_CLAIM_TIMEOUT)
+		return tx.Model(&models.OutboxEvent{}).Where("id IN ?", outboxIDs(pending)).Update("claimed_until", &until).Error
+	})
+	return pending, err
+}
+
+// release gives up the claim on events that were not published, so the next
+// relay retries them straight away.
+func (r *OutboxRelay) release(unpublished []models.OutboxEvent) error {
+	return r.db.Model(&models.OutboxEvent{}).Where("id IN ?", outboxIDs(unpublished)).Update("claimed_until", nil).Error
+}
+
+func outboxIDs(events []models.OutboxEvent) []uint {
+	ids := make([]uint, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+	return ids
+}
+
+func (r *OutboxRelay) publish(event models.OutboxEvent) error {
+	for _, sink := range r.sinks {
+		if err := sink.Publish(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Start relays pending events every interval until stop is closed.
+func (r *OutboxRelay) Start(interval time.Duration, stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				for {
+					published, err := r.RelayOnce(OUTBOX_BATCH_SIZE)
+					if err != nil {
+						log.Println("failed to relay outbox events:", err)
+					}
+					if err != nil || published < OUTBOX_BATCH_SIZE {
+						break
+					}
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// recordEvent adds a domain event to the outbox using tx, so that it is only
+// published if the change it describes commits.
+func recordEvent(tx repository.Store, event models.DomainEvent) error {
+	outboxEvent, err := models.NewOutboxEvent(event)
+	if err != nil {
+		return err
+	}
+	return tx.Journal().CreateOutboxEvent(&outboxEvent)
+}
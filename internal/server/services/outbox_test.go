@@ -0,0 +1,117 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/events"
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// storeTestEvents writes an AccountOpened event per account number and
+// returns the outbox rows in the order they were written.
+func storeTestEvents(t *testing.T, db *gorm.DB, store repository.Store, accountNumbers ...string) []models.OutboxEvent {
+	for _, accountNumber := range accountNumbers {
+		assert.NoError(t, recordEvent(store, models.AccountOpened{AccountNumber: accountNumber}))
+	}
+
+	var stored []models.OutboxEvent
+	assert.NoError(t, db.Order("id").Find(&stored).Error)
+	return stored
+}
+
+func sentEventIDs(sink *events.MemorySink) []string {
+	ids := []string{}
+	for _, event := range sink.Events() {
+		ids = append(ids, event.EventID)
+	}
+	return ids
+}
+
+func TestRelayOncePublishesInOrder(t *testing.T) {
+	db, store := newTestDB(t)
+	stored := storeTestEvents(t, db, store, "1", "2", "3")
+	sink := events.NewMemorySink()
+	relay := NewOutboxRelay(db, sink)
+
+	published, err := relay.RelayOnce(2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, published)
+
+	published, err = relay.RelayOnce(2)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, published)
+	assert.Equal(t, []string{stored[0].EventID, stored[1].EventID, stored[2].EventID}, sentEventIDs(sink))
+
+	var relayed []models.OutboxEvent
+	assert.NoError(t, db.Order("id").Find(&relayed).Error)
+	for _, event := range relayed {
+		assert.NotNil(t, event.PublishedAt)
+		assert.Nil(t, event.ClaimedUntil)
+	}
+
+	published, err = relay.RelayOnce(2)
+	assert.NoError(t, err)
+	assert.Zero(t, published)
+	assert.Len(t, sink.Events(), 3)
+}
+
+func TestRelayOnceRetriesFailedEvents(t *testing.T) {
+	db, store := newTestDB(t)
+	stored := storeTestEvents(t, db, store, "1", "2")
+	sink := events.NewMemorySink()
+	relay := NewOutboxRelay(db, sink)
+
+	// The first event fails, so the second is not sent ahead of it.
+	sink.FailWith(errors.New("broker unavailable"))
+	published, err := relay.RelayOnce(OUTBOX_BATCH_SIZE)
+	assert.NoError(t, err)
+	assert.Zero(t, published)
+	assert.Empty(t, sink.Events())
+
+	var failed models.OutboxEvent
+	assert.NoError(t, db.First(&failed, stored[0].ID).Error)
+	assert.Equal(t, 1, failed.Attempts)
+	assert.Equal(t, "broker unavailable", failed.LastError)
+	assert.Nil(t, failed.PublishedAt)
+	assert.Nil(t, failed.ClaimedUntil)
+
+	var waiting models.OutboxEvent
+	assert.NoError(t, db.First(&waiting, stored[1].ID).Error)
+	assert.Zero(t, waiting.Attempts)
+	assert.Nil(t, waiting.PublishedAt)
+
+	sink.FailWith(nil)
+	published, err = relay.RelayOnce(OUTBOX_BATCH_SIZE)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, published)
+	assert.Equal(t, []string{stored[0].EventID, stored[1].EventID}, sentEventIDs(sink))
+}
+
+func TestRelayOnceRespectsClaims(t *testing.T) {
+	db, store := newTestDB(t)
+	stored := storeTestEvents(t, db, store, "1")
+	sink := events.NewMemorySink()
+	relay := NewOutboxRelay(db, sink)
+
+	// Another relay is still publishing the head of the queue.
+	claimed := time.Now().Add(time.Minute)
+	assert.NoError(t, db.Model(&stored[0]).Update("claimed_until", &claimed).Error)
+
+	published, err := relay.RelayOnce(OUTBOX_BATCH_SIZE)
+	assert.NoError(t, err)
+	assert.Zero(t, published)
+
+	// Its claim lapsed, so the event is taken over and sent again.
+	lapsed := time.Now().Add(-time.Minute)
+	assert.NoError(t, db.Model(&stored[0]).Update("claimed_until", &lapsed).Error)
+
+	published, err = relay.RelayOnce(OUTBOX_BATCH_SIZE)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, published)
+	assert.Equal(t, []string{stored[0].EventID}, sentEventIDs(sink))
+}
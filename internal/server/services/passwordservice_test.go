@@ -0,0 +1,102 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/mailer"
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type failingMailer struct{}
+
+func (failingMailer) Send(mailer.Message) error {
+	return errors.New("smtp unavailable")
+}
+
+func TestRequestReset(t *testing.T) {
+	db, store := newTestDB(t)
+	mail := &recordingMailer{}
+	service := NewPasswordService(db, mail)
+	user := createTestUser(t, store, "forgot@example.com")
+
+	assert.NoError(t, service.RequestReset(models.ForgotPassword{Email: "nobody@example.com"}))
+	assert.Empty(t, mail.messages)
+
+	assert.NoError(t, service.RequestReset(models.ForgotPassword{Email: user.Email}))
+	first := mailedToken(t, mail, user.Email)
+
+	// A new request replaces the outstanding token.
+	assert.NoError(t, service.RequestReset(models.ForgotPassword{Email: user.Email}))
+	second := mailedToken(t, mail, user.Email)
+	assert.NotEqual(t, first, second)
+
+	err := service.ResetPassword(models.ResetPassword{Token: first, Password: "a new password"}, models.ClientInfo{})
+	assert.EqualError(t, err, "invalid or expired reset token")
+	assert.NoError(t, service.ResetPassword(models.ResetPassword{Token: second, Password: "a new password"}, models.ClientInfo{}))
+}
+
+func TestRequestResetHidesMailFailures(t *testing.T) {
+	db, store := newTestDB(t)
+	service := NewPasswordService(db, failingMailer{})
+	user := createTestUser(t, store, "unlucky@example.com")
+
+	// Known and unknown addresses must look the same to the caller.
+	assert.NoError(t, service.RequestReset(models.ForgotPassword{Email: user.Email}))
+	assert.NoError(t, service.RequestReset(models.ForgotPassword{Email: "nobody@example.com"}))
+}
+
+func TestResetPassword(t *testing.T) {
+	db, store := newTestDB(t)
+	mail := &recordingMailer{}
+	service := NewPasswordService(db, mail)
+	user := createTestUser(t, store, "reset@example.com")
+	apiKey := models.APIKey{UserID: user.ID, KeyHash: "key"}
+	assert.NoError(t, db.Create(&apiKey).Error)
+
+	assert.NoError(t, service.RequestReset(models.ForgotPassword{Email: user.Email}))
+	token := mailedToken(t, mail, user.Email)
+
+	assert.NoError(t, service.ResetPassword(models.ResetPassword{Token: token, Password: "a new password"}, models.ClientInfo{}))
+
+	updated, err := store.Users().Get(user.ID)
+	assert.NoError(t, err)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(updated.Password), []byte("a new password")))
+	assert.True(t, updated.SessionsValidFrom.After(user.SessionsValidFrom))
+
+	assert.NoError(t, db.First(&apiKey, apiKey.ID).Error)
+	assert.NotNil(t, apiKey.RevokedAt)
+
+	var entry models.AuditLog
+	assert.NoError(t, db.Where("action = ? AND entity_id = ?", models.AUDIT_USER_PASSWORD_RESET, user.ID).First(&entry).Error)
+	assert.Contains(t, string(entry.After), `"apiKeysRevoked":1`)
+
+	// Tokens are single use.
+	err = service.ResetPassword(models.ResetPassword{Token: token, Password: "another password"}, models.ClientInfo{})
+	assert.EqualError(t, err, "invalid or expired reset token")
+
+	err = service.ResetPassword(models.ResetPassword{Token: "not-a-token", Password: "another password"}, models.ClientInfo{})
+	assert.EqualError(t, err, "invalid or expired reset token")
+}
+
+func TestResetPasswordRejectsExpiredTokens(t *testing.T) {
+	db, store := newTestDB(t)
+	mail := &recordingMailer{}
+	service := NewPasswordService(db, mail)
+	user := createTestUser(t, store, "expired@example.com")
+
+	assert.NoError(t, service.RequestReset(models.ForgotPassword{Email: user.Email}))
+	token := mailedToken(t, mail, user.Email)
+
+	assert.NoError(t, db.Model(&models.PasswordResetToken{}).Where("user_id = ?", user.ID).
+		Update("expires_on", time.Now().Add(-time.Minute)).Error)
+
+	err := service.ResetPassword(models.ResetPassword{Token: token, Password: "a new password"}, models.ClientInfo{})
+	assert.EqualError(t, err, "invalid or expired reset token")
+
+	unchanged, _ := store.Users().Get(user.ID)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(unchanged.Password), []byte(testPassword)))
+}
@@ -0,0 +1,445 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/mailer"
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	exportRetention       = 7 * 24 * time.Hour
+	exportRequestInterval = time.Hour
+)
+
+type PrivacyService struct {
+	db     *gorm.DB
+	mailer mailer.Mailer
+}
+
+func NewPrivacyService(db *gorm.DB, mailer mailer.Mailer) *PrivacyService {
+	return &PrivacyService{db: db, mailer: mailer}
+}
+
+// RequestExport queues an export of the user's data. The archive is built in
+// the background and the user is emailed when it can be downloaded.
+func (s *PrivacyService) RequestExport(actor models.Actor) (models.DataRequest, error) {
+	userID := actor.UserID
+	var recent int64
+	s.db.Model(&models.DataRequest{}).
+		Where("user_id = ? AND type = ? AND status <> ? AND created_at > ?",
+			userID, models.DATA_REQUEST_EXPORT, models.DATA_REQUEST_FAILED, time.Now().Add(-exportRequestInterval)).
+		Count(&recent)
+	if recent > 0 {
+		return models.DataRequest{}, ErrTooManyRequests
+	}
+
+	request := models.DataRequest{
+		UserID:      userID,
+		RequestedBy: userID,
+		Type:        models.DATA_REQUEST_EXPORT,
+		Status:      models.DATA_REQUEST_PENDING,
+		IP:          actor.IP,
+		UserAgent:   actor.UserAgent,
+	}
+
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&request).Error; err != nil {
+			return err
+		}
+
+		return recordAudit(repository.NewGormStore(tx), actor, models.AUDIT_DATA_EXPORT_REQUEST, models.ENTITY_DATA_REQUEST, request.ID, nil, request)
+	}); err != nil {
+		return request, fmt.Errorf("failed to request export")
+	}
+
+	go s.processExport(request.ID)
+
+	return request, nil
+}
+
+func (s *PrivacyService) ListDataRequests(userID uint) ([]models.DataRequest, error) {
+	var requests []models.DataRequest
+	if err := s.db.Omit("archive").Where("user_id = ?", userID).Order("created_at desc").Find(&requests).Error; err != nil {
+		return nil, fmt.Errorf("failed to list data requests")
+	}
+
+	return requests, nil
+}
+
+// DownloadExport returns a finished export archive that has not expired.
+func (s *PrivacyService) DownloadExport(userID uint, requestID uint) ([]byte, error) {
+	var request models.DataRequest
+	if err := s.db.Where("id = ? AND user_id = ?", requestID, userID).First(&request).Error; err != nil {
+		return nil, fmt.Errorf("export not found")
+	}
+
+	if !request.IsDownloadable() {
+		return nil, fmt.Errorf("export is not available")
+	}
+
+	return request.Archive, nil
+}
+
+func (s *PrivacyService) processExport(requestID uint) {
+	var request models.DataRequest
+	if err := s.db.First(&request, requestID).Error; err != nil {
+		log.Println("failed to load export request:", err)
+		return
+	}
+
+	archive, err := s.exportArchive(request.UserID)
+	if err != nil {
+		log.Println("failed to build export:", err)
+		s.db.Model(&request).Updates(map[string]interface{}{"status": models.DATA_REQUEST_FAILED, "error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(exportRetention)
+	if err := s.db.Model(&request).Updates(map[string]interface{}{
+		"status":       models.DATA_REQUEST_COMPLETED,
+		"archive":      archive,
+		"completed_at": &now,
+		"expires_at":   &expiresAt,
+	}).Error; err != nil {
+		log.Println("failed to save export:", err)
+		return
+	}
+
+	var user models.User
+	if err := s.db.First(&user, request.UserID).Error; err != nil {
+		return
+	}
+
+	if err := s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Your data export is ready",
+		Body: fmt.Sprintf("Hi %s,\n\nThe export of your Go Banking data is ready. Download it while logged in from\n\n"+
+			"%s/user/data-requests/%d/download\n\nThe link expires on %s.\n",
+			user.FirstName, mailer.BaseURL(), request.ID, expiresAt.Format(time.RFC1123)),
+	}); err != nil {
+		log.Println("failed to send export notice:", err)
+	}
+}
+
+func (s *PrivacyService) exportArchive(userID uint) ([]byte, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	export := models.DataExport{GeneratedAt: time.Now().UTC(), Profile: user.Response()}
+
+	if err := s.db.Where("user_id = ?", userID).Find(&export.Memberships).Error; err != nil {
+		return nil, fmt.Errorf("failed to load memberships")
+	}
+
+	if err := s.db.Where("account_number IN (?)", memberAccountNumbers(s.db, userID)).Find(&export.Accounts).Error; err != nil {
+		return nil, fmt.Errorf("failed to load accounts")
+	}
+
+	if err := s.db.Where("account_number IN (?)", memberAccountNumbers(s.db, userID)).Order("created_at").Find(&export.Transactions).Error; err != nil {
+		return nil, fmt.Errorf("failed to load transactions")
+	}
+
+	if err := s.db.Where("sender_id = ? OR receiver_id = ?", userID, userID).Order("created_at").Find(&export.Transfers).Error; err != nil {
+		return nil, fmt.Errorf("failed to load transfers")
+	}
+
+	return buildExportArchive(export)
+}
+
+// buildExportArchive writes the export as a zip with the full JSON document
+// and a CSV file per table.
+func buildExportArchive(export models.DataExport) ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	document, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := archive.Create("export.json")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Write(document); err != nil {
+		return nil, err
+	}
+
+	accounts := [][]string{{"accountNumber", "balance", "createdAt", "frozenAt"}}
+	for _, account := range export.Accounts {
+		accounts = append(accounts, []string{account.AccountNumber, formatAmount(account.Balance),
+			formatTime(&account.CreatedAt), formatTime(account.FrozenAt)})
+	}
+
+	transactions := [][]string{{"transactionId", "accountNumber", "type", "amount", "createdAt"}}
+	for _, transaction := range export.Transactions {
+		transactions = append(transactions, []string{transaction.TransactionID, transaction.AccountNumber,
+			transaction.Type, formatAmount(transaction.Amount), formatTime(&transaction.CreatedAt)})
+	}
+
+	transfers := [][]string{{"transactionId", "senderId", "receiverId", "amount", "status", "createdAt"}}
+	for _, transfer := range export.Transfers {
+		transfers = append(transfers, []string{transfer.TransactionID, strconv.FormatUint(uint64(transfer.SenderID), 10),
+			strconv.FormatUint(uint64(transfer.ReceiverID), 10), formatAmount(transfer.Amount), transfer.Status,
+			formatTime(&transfer.CreatedAt)})
+	}
+
+	for _, table := range []struct {
+		name string
+		rows [][]string
+	}{
+		{"accounts.csv", accounts},
+		{"transactions.csv", transactions},
+		{"transfers.csv", transfers},
+	} {
+		file, err := archive.Create(table.name)
+		if err != nil {
+			return nil, err
+		}
+		if err := csv.NewWriter(file).WriteAll(table.rows); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// RequestErasure erases the caller's own account after confirming their
+// password.
+func (s *PrivacyService) RequestErasure(actor models.Actor, password string) (models.DataRequest, error) {
+	var user models.User
+	if err := s.db.First(&user, actor.UserID).Error; err != nil {
+		return models.DataRequest{}, fmt.Errorf("user not found")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return models.DataRequest{}, ErrIncorrectPassword
+	}
+
+	return s.EraseUser(user.ID, actor)
+}
+
+// EraseUser pseudonymises the user's personal data and removes their
+// credentials. Accounts, transactions and transfers are kept for the legally
+// required retention period; accounts the user owns are frozen and must have
+// a zero balance. Failed attempts are recorded as well.
+func (s *PrivacyService) EraseUser(userID uint, actor models.Actor) (models.DataRequest, error) {
+	request := models.DataRequest{
+		UserID:      userID,
+		RequestedBy: actor.UserID,
+		Type:        models.DATA_REQUEST_ERASURE,
+		Status:      models.DATA_REQUEST_COMPLETED,
+		IP:          actor.IP,
+		UserAgent:   actor.UserAgent,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		store := repository.NewGormStore(tx)
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return fmt.Errorf("user not found")
+		}
+
+		if user.IsErased() {
+			return fmt.Errorf("user has already been erased")
+		}
+
+		// Lock the owned accounts so no deposit lands between the balance
+		// check and the freeze.
+		var owned []models.BankAccount
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("account_number IN (?)", ownedAccountNumbers(tx, userID)).
+			Order("account_number").Find(&owned).Error; err != nil {
+			return fmt.Errorf("failed to erase user")
+		}
+
+		var freezing []string
+		for _, account := range owned {
+			if account.Balance != 0 {
+				return fmt.Errorf("accounts must have a zero balance before erasure")
+			}
+			if account.FrozenAt == nil {
+				freezing = append(freezing, account.AccountNumber)
+			}
+		}
+
+		email := normalizeEmail(user.Email)
+		before := user.Response()
+		now := time.Now()
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"email":               fmt.Sprintf("erased-%d@erased.invalid", user.ID),
+			"first_name":          "Erased",
+			"last_name":           "User",
+			"password":            "",
+			"totp_secret":         "",
+			"totp_last_step":      0,
+			"totp_enabled":        false,
+			"email_verified_at":   nil,
+			"sessions_valid_from": now,
+			"erased_at":           &now,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to erase user")
+		}
+
+		if len(freezing) > 0 {
+			if err := tx.Model(&models.BankAccount{}).Where("account_number IN ?", freezing).
+				Update("frozen_at", &now).Error; err != nil {
+				return fmt.Errorf("failed to erase user")
+			}
+		}
+
+		for _, accountNumber := range freezing {
+			if err := recordEvent(store, models.AccountFrozen{AccountNumber: accountNumber}); err != nil {
+				return fmt.Errorf("failed to erase user")
+			}
+		}
+
+		if err := tx.Unscoped().Where("user_id = ? AND role <> ?", userID, models.MEMBER_OWNER).
+			Delete(&models.AccountMember{}).Error; err != nil {
+			return fmt.Errorf("failed to erase user")
+		}
+
+		for _, model := range []interface{}{
+			&models.RecoveryCode{},
+			&models.LoginChallenge{},
+			&models.PasswordResetToken{},
+			&models.LoginUnlockToken{},
+			&models.EmailVerification{},
+		} {
+			if err := tx.Unscoped().Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return fmt.Errorf("failed to erase user")
+			}
+		}
+
+		if err := tx.Model(&models.LoginAttempt{}).
+			Where("user_id = ? OR email = ?", userID, email).
+			Updates(map[string]interface{}{"email": "", "ip": "", "user_agent": ""}).Error; err != nil {
+			return fmt.Errorf("failed to erase user")
+		}
+
+		if err := tx.Model(&models.APIKey{}).Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", &now).Error; err != nil {
+			return fmt.Errorf("failed to erase user")
+		}
+
+		if err := tx.Model(&models.OAuthConsent{}).Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", &now).Error; err != nil {
+			return fmt.Errorf("failed to erase user")
+		}
+
+		if err := tx.Model(&models.DataRequest{}).Where("user_id = ?", userID).
+			Updates(map[string]interface{}{"archive": nil, "ip": "", "user_agent": ""}).Error; err != nil {
+			return fmt.Errorf("failed to erase user")
+		}
+
+		if err := tx.Unscoped().Where("endpoint_id IN (?)", tx.Unscoped().Model(&models.WebhookEndpoint{}).Select("id").Where("user_id = ?", userID)).
+			Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return fmt.Errorf("failed to erase user")
+		}
+
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.WebhookEndpoint{}).Error; err != nil {
+			return fmt.Errorf("failed to erase user")
+		}
+
+		// The audit log keeps the entries but lets their IP and user agent be
+		// cleared; see migration 0014.
+		if err := tx.Model(&models.AuditLog{}).
+			Where("(actor_id = ? OR (actor_id IS NULL AND entity_type = ? AND entity_id = ?)) AND (ip <> '' OR user_agent <> '')",
+				userID, models.ENTITY_USER, strconv.FormatUint(uint64(userID), 10)).
+			Updates(map[string]interface{}{"ip": "", "user_agent": ""}).Error; err != nil {
+			return fmt.Errorf("failed to erase user")
+		}
+
+		if actor.UserID == userID {
+			actor.IP = ""
+			actor.UserAgent = ""
+			request.IP = ""
+			request.UserAgent = ""
+		}
+
+		if err := tx.First(&user, userID).Error; err != nil {
+			return fmt.Errorf("failed to erase user")
+		}
+
+		if err := recordAudit(store, actor, models.AUDIT_USER_ERASE, models.ENTITY_USER, user.ID, before, user.Response()); err != nil {
+			return fmt.Errorf("failed to erase user")
+		}
+
+		request.CompletedAt = &now
+		return tx.Create(&request).Error
+	})
+	if err != nil {
+		request.Status = models.DATA_REQUEST_FAILED
+		request.Error = err.Error()
+		request.CompletedAt = nil
+		request.ID = 0
+		if createErr := s.db.Create(&request).Error; createErr != nil {
+			log.Println("failed to record erasure request:", createErr)
+		}
+		return request, err
+	}
+
+	return request, nil
+}
+
+// ListDataRequestsForAdmin returns a page of export and erasure requests,
+// optionally filtered by user.
+func (s *PrivacyService) ListDataRequestsForAdmin(userID uint, page int, pageSize int) ([]models.DataRequest, int64, error) {
+	query := s.db.Model(&models.DataRequest{})
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list data requests")
+	}
+
+	var requests []models.DataRequest
+	if err := query.Omit("archive").Order("created_at desc").Offset((page - 1) * pageSize).Limit(pageSize).Find(&requests).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list data requests")
+	}
+
+	return requests, total, nil
+}
+
+// memberAccountNumbers is a subquery selecting the accounts a user is an
+// active holder of.
+func memberAccountNumbers(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Model(&models.AccountMember{}).Select("account_number").Where("user_id = ? AND accepted_at IS NOT NULL", userID)
+}
+
+// ownedAccountNumbers is a subquery selecting the accounts a user owns.
+func ownedAccountNumbers(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Model(&models.AccountMember{}).Select("account_number").Where("user_id = ? AND role = ?", userID, models.MEMBER_OWNER)
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
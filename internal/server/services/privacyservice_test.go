@@ -0,0 +1,141 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"testing"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildExportArchive(t *testing.T) {
+	export := models.DataExport{
+		Profile:      models.UserResponse{ID: 1, Email: "test@example.com"},
+		Accounts:     []models.BankAccount{{AccountNumber: "1234", Balance: 10.5}},
+		Transactions: []models.Transaction{{TransactionID: "tx-1", AccountNumber: "1234", Type: DEPOSIT, Amount: 10.5}},
+	}
+
+	archive, err := buildExportArchive(export)
+	assert.NoError(t, err)
+
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	assert.NoError(t, err)
+
+	files := map[string]*zip.File{}
+	for _, file := range reader.File {
+		files[file.Name] = file
+	}
+	assert.Len(t, files, 4)
+	assert.Contains(t, files, "export.json")
+
+	file, err := files["transactions.csv"].Open()
+	assert.NoError(t, err)
+	rows, err := csv.NewReader(file).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, []string{"tx-1", "1234", DEPOSIT, "10.50"}, rows[1][:4])
+}
+
+func TestEraseUser(t *testing.T) {
+	db, store := newTestDB(t)
+	privacy := NewPrivacyService(db, &recordingMailer{})
+	bank := NewBankService(store)
+	user := createTestUser(t, store, "erase@example.com")
+	account, err := bank.CreateAccount(actorFor(user))
+	assert.NoError(t, err)
+
+	// A throttled attempt has no user_id, only the email that was typed.
+	assert.NoError(t, db.Create(&models.LoginAttempt{Email: "erase@example.com", IP: "203.0.113.7", UserAgent: "curl", Reason: "throttled"}).Error)
+	assert.NoError(t, db.Create(&models.LoginAttempt{Email: "erase@example.com", UserID: &user.ID, IP: "203.0.113.7", UserAgent: "curl", Success: true}).Error)
+	assert.NoError(t, db.Create(&models.LoginAttempt{Email: "other@example.com", IP: "198.51.100.1", UserAgent: "curl"}).Error)
+	assert.NoError(t, db.Create(&models.DataRequest{UserID: user.ID, RequestedBy: user.ID, Type: models.DATA_REQUEST_EXPORT,
+		Status: models.DATA_REQUEST_COMPLETED, IP: "203.0.113.7", UserAgent: "curl"}).Error)
+	assert.NoError(t, db.Create(&models.AuditLog{ActorID: &user.ID, IP: "203.0.113.7", UserAgent: "curl", Action: models.AUDIT_USER_UPDATE}).Error)
+	assert.NoError(t, db.Create(&models.AuditLog{IP: "203.0.113.7", UserAgent: "curl", Action: models.AUDIT_LOGIN_FAILURE,
+		EntityType: models.ENTITY_USER, EntityID: fmt.Sprint(user.ID)}).Error)
+	assert.NoError(t, db.Create(&models.AuditLog{IP: "198.51.100.1", UserAgent: "curl", Action: models.AUDIT_LOGIN_FAILURE}).Error)
+
+	actor := actorFor(user)
+	actor.IP = "203.0.113.7"
+	actor.UserAgent = "curl"
+	request, err := privacy.RequestErasure(actor, testPassword)
+	assert.NoError(t, err)
+	assert.Equal(t, models.DATA_REQUEST_COMPLETED, request.Status)
+
+	erased, err := store.Users().Get(user.ID)
+	assert.NoError(t, err)
+	assert.True(t, erased.IsErased())
+	assert.NotEqual(t, "erase@example.com", erased.Email)
+
+	var attempts []models.LoginAttempt
+	assert.NoError(t, db.Order("id").Find(&attempts).Error)
+	assert.Len(t, attempts, 3)
+	for _, attempt := range attempts[:2] {
+		assert.Empty(t, attempt.Email)
+		assert.Empty(t, attempt.IP)
+		assert.Empty(t, attempt.UserAgent)
+	}
+	assert.Equal(t, "198.51.100.1", attempts[2].IP)
+
+	var requests []models.DataRequest
+	assert.NoError(t, db.Where("user_id = ?", user.ID).Find(&requests).Error)
+	assert.Len(t, requests, 2)
+	for _, request := range requests {
+		assert.Empty(t, request.IP)
+		assert.Empty(t, request.UserAgent)
+	}
+
+	var entries []models.AuditLog
+	assert.NoError(t, db.Order("id").Find(&entries).Error)
+	for _, entry := range entries {
+		if entry.IP == "198.51.100.1" {
+			continue
+		}
+		assert.Empty(t, entry.IP, entry.Action)
+		assert.Empty(t, entry.UserAgent, entry.Action)
+	}
+	assert.Equal(t, models.AUDIT_USER_ERASE, entries[len(entries)-1].Action)
+
+	account, err = store.Accounts().Get(account.AccountNumber)
+	assert.NoError(t, err)
+	assert.True(t, account.IsFrozen())
+}
+
+func TestEraseUserRequiresZeroBalance(t *testing.T) {
+	db, store := newTestDB(t)
+	privacy := NewPrivacyService(db, &recordingMailer{})
+	bank := NewBankService(store)
+	user := createTestUser(t, store, "funded@example.com")
+	account, err := bank.CreateAccount(actorFor(user))
+	assert.NoError(t, err)
+	_, err = bank.DepositToAccount(models.Transaction{AccountNumber: account.AccountNumber, Amount: 10}, actorFor(user))
+	assert.NoError(t, err)
+
+	request, err := privacy.EraseUser(user.ID, models.Actor{})
+	assert.EqualError(t, err, "accounts must have a zero balance before erasure")
+	assert.Equal(t, models.DATA_REQUEST_FAILED, request.Status)
+
+	stored, err := store.Users().Get(user.ID)
+	assert.NoError(t, err)
+	assert.False(t, stored.IsErased())
+
+	account, err = store.Accounts().Get(account.AccountNumber)
+	assert.NoError(t, err)
+	assert.False(t, account.IsFrozen())
+	assert.Equal(t, 10.0, account.Balance)
+}
+
+func TestEraseUserRejectsErasedUsers(t *testing.T) {
+	db, store := newTestDB(t)
+	privacy := NewPrivacyService(db, &recordingMailer{})
+	user := createTestUser(t, store, "twice@example.com")
+
+	_, err := privacy.EraseUser(user.ID, models.Actor{})
+	assert.NoError(t, err)
+
+	_, err = privacy.EraseUser(user.ID, models.Actor{})
+	assert.EqualError(t, err, "user has already been erased")
+}
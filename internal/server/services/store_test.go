@@ -0,0 +1,103 @@
+package services
+
+import (
+	"net/url"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/mailer"
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/repository"
+	"github.com/FaizanAC/Go-Banking/tests/integration/testdb"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// recordingMailer keeps sent messages for assertions.
+type recordingMailer struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+}
+
+func (m *recordingMailer) Send(msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+func (m *recordingMailer) sentTo(email string) []mailer.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sent := []mailer.Message{}
+	for _, msg := range m.messages {
+		if msg.To == email {
+			sent = append(sent, msg)
+		}
+	}
+	return sent
+}
+
+var mailedTokenPattern = regexp.MustCompile(`token=(\S+)`)
+
+// mailedToken extracts the token from the link in the last email sent to
+// email.
+func mailedToken(t *testing.T, mail *recordingMailer, email string) string {
+	sent := mail.sentTo(email)
+	if !assert.NotEmpty(t, sent) {
+		return ""
+	}
+
+	match := mailedTokenPattern.FindStringSubmatch(sent[len(sent)-1].Body)
+	if !assert.Len(t, match, 2) {
+		return ""
+	}
+
+	token, err := url.QueryUnescape(match[1])
+	assert.NoError(t, err)
+	return token
+}
+
+const testPassword = "correct horse battery"
+
+// newTestDB opens a migrated database for the services built on *gorm.DB,
+// returning it with a GormStore over it.
+func newTestDB(t *testing.T) (*gorm.DB, repository.Store) {
+	db := testdb.Open(t)
+	return db, repository.NewGormStore(db)
+}
+
+// createTestUser stores a customer with a verified email and testPassword.
+func createTestUser(t *testing.T, store repository.Store, email string) models.User {
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	user := models.User{Email: email, FirstName: "Test", LastName: "User", Password: string(hash), EmailVerifiedAt: &now}
+	assert.NoError(t, store.Users().Create(&user))
+	return user
+}
+
+func actorFor(user models.User) models.Actor {
+	return models.Actor{UserID: user.ID, AuthMethod: models.AUTH_METHOD_JWT}
+}
+
+func auditActions(store *repository.MemoryStore) []string {
+	actions := []string{}
+	for _, entry := range store.AuditLogs() {
+		actions = append(actions, entry.Action)
+	}
+	return actions
+}
+
+func eventTypes(store *repository.MemoryStore) []string {
+	types := []string{}
+	for _, event := range store.OutboxEvents() {
+		types = append(types, event.Type)
+	}
+	return types
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// STREAM_CHANNEL is the Postgres NOTIFY channel used to fan events out
+	// to every server instance.
+	STREAM_CHANNEL      = "bank_events"
+	STREAM_BUFFER_SIZE  = 64
+	STREAM_REPLAY_LIMIT = 500
+)
+
+// Subscription receives the stream events of one user. Events is closed if
+// the subscriber falls too far behind; clients then reconnect and resume.
+type Subscription struct {
+	userID uint
+	Events chan models.StreamEvent
+}
+
+// StreamService pushes account activity to connected clients. It is an
+// events.Sink: with fan-out enabled, published events are broadcast with
+// NOTIFY and dispatched by every instance when the notification arrives;
+// otherwise they are dispatched locally.
+type StreamService struct {
+	db          *gorm.DB
+	mu          sync.Mutex
+	subscribers map[uint]map[*Subscription]struct{}
+	fanout      bool
+}
+
+func NewStreamService(db *gorm.DB) *StreamService {
+	return &StreamService{db: db, subscribers: make(map[uint]map[*Subscription]struct{})}
+}
+
+// EnableFanout switches Publish to NOTIFY. Only call it once something is
+// listening on STREAM_CHANNEL and passing notifications to HandleNotification.
+func (s *StreamService) EnableFanout() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fanout = true
+}
+
+func (s *StreamService) Subscribe(userID uint) *Subscription {
+	subscription := &Subscription{userID: userID, Events: make(chan models.StreamEvent, STREAM_BUFFER_SIZE)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.subscribers[userID] == nil {
+		s.subscribers[userID] = make(map[*Subscription]struct{})
+	}
+	s.subscribers[userID][subscription] = struct{}{}
+	return subscription
+}
+
+func (s *StreamService) Unsubscribe(subscription *Subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(subscription)
+}
+
+// remove must be called with s.mu held.
+func (s *StreamService) remove(subscription *Subscription) {
+	if _, ok := s.subscribers[subscription.userID][subscription]; !ok {
+		return
+	}
+
+	delete(s.subscribers[subscription.userID], subscription)
+	if len(s.subscribers[subscription.userID]) == 0 {
+		delete(s.subscribers, subscription.userID)
+	}
+	close(subscription.Events)
+}
+
+// Publish implements events.Sink.
+func (s *StreamService) Publish(event models.OutboxEvent) error {
+	s.mu.Lock()
+	fanout := s.fanout
+	s.mu.Unlock()
+
+	if fanout {
+		return s.db.Exec("SELECT pg_notify(?, ?)", STREAM_CHANNEL, strconv.FormatUint(uint64(event.ID), 10)).Error
+	}
+
+	s.Dispatch(event)
+	return nil
+}
+
+// HandleNotification dispatches the outbox event named in a NOTIFY payload.
+func (s *StreamService) HandleNotification(payload string) {
+	id, err := strconv.ParseUint(payload, 10, 64)
+	if err != nil {
+		return
+	}
+
+	var event models.OutboxEvent
+	if err := s.db.First(&event, id).Error; err != nil {
+		log.Println("failed to load streamed event:", err)
+		return
+	}
+
+	s.Dispatch(event)
+}
+
+// Dispatch sends an event to the local subscribers it concerns.
+func (s *StreamService) Dispatch(event models.OutboxEvent) {
+	s.mu.Lock()
+	connected := len(s.subscribers) > 0
+	s.mu.Unlock()
+	if !connected {
+		return
+	}
+
+	var holders []uint
+	if err := s.db.Model(&models.AccountMember{}).
+		Where("account_number = ? AND accepted_at IS NOT NULL", event.AggregateID).
+		Pluck("user_id", &holders).Error; err != nil {
+		log.Println("failed to dispatch streamed event:", err)
+		return
+	}
+
+	isHolder := make(map[uint]bool, len(holders))
+	for _, userID := range holders {
+		isHolder[userID] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for userID, subscriptions := range s.subscribers {
+		for _, streamEvent := range streamEventsForUser(event, userID, isHolder[userID]) {
+			for subscription := range subscriptions {
+				select {
+				case subscription.Events <- streamEvent:
+				default:
+					s.remove(subscription)
+				}
+			}
+		}
+	}
+}
+
+// Replay returns the events after afterID that concern the user, so that a
+// reconnecting client misses nothing. It reads at most STREAM_REPLAY_LIMIT
+// outbox events; truncated reports that more remain after the last one.
+func (s *StreamService) Replay(userID uint, afterID uint) (events []models.StreamEvent, truncated bool, err error) {
+	var accountNumbers []string
+	if err := memberAccountNumbers(s.db, userID).Pluck("account_number", &accountNumbers).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to replay events")
+	}
+
+	isHolder := make(map[string]bool, len(accountNumbers))
+	for _, accountNumber := range accountNumbers {
+		isHolder[accountNumber] = true
+	}
+
+	var outboxEvents []models.OutboxEvent
+	if err := s.db.Where("id > ? AND (aggregate_id IN ? OR receiver_id = ?)", afterID, accountNumbers, userID).
+		Order("id").Limit(STREAM_REPLAY_LIMIT + 1).Find(&outboxEvents).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to replay events")
+	}
+
+	if len(outboxEvents) > STREAM_REPLAY_LIMIT {
+		outboxEvents = outboxEvents[:STREAM_REPLAY_LIMIT]
+		truncated = true
+	}
+
+	streamEvents := []models.StreamEvent{}
+	for _, event := range outboxEvents {
+		streamEvents = append(streamEvents, streamEventsForUser(event, userID, isHolder[event.AggregateID])...)
+	}
+	return streamEvents, truncated, nil
+}
+
+// streamEventsForUser decides what, if anything, the user sees of an event.
+// Holders of the account see the event itself; the receiver of a transfer
+// sees that it arrived but not the sender's balance.
+func streamEventsForUser(event models.OutboxEvent, userID uint, isHolder bool) []models.StreamEvent {
+	var streamEvents []models.StreamEvent
+	if isHolder {
+		streamEvents = append(streamEvents, models.StreamEvent{ID: event.ID, Type: event.Type, Data: event.Payload})
+	}
+
+	if event.Type != models.EVENT_TRANSFER_SENT {
+		return streamEvents
+	}
+
+	domainEvent, err := event.Decode()
+	if err != nil {
+		return streamEvents
+	}
+
+	sent := domainEvent.(models.TransferSent)
+	if sent.ReceiverID != userID {
+		return streamEvents
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"transactionId": sent.TransactionID,
+		"senderId":      sent.SenderID,
+		"amount":        sent.Amount,
+		"expiresOn":     sent.ExpiresOn,
+	})
+	if err != nil {
+		return streamEvents
+	}
+
+	return append(streamEvents, models.StreamEvent{ID: event.ID, Type: models.STREAM_TRANSFER_RECEIVED, Data: data})
+}
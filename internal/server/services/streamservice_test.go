@@ -0,0 +1,82 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamEventsForUser(t *testing.T) {
+	event, err := models.NewOutboxEvent(models.TransferSent{AccountNumber: "123", TransactionID: "abc", SenderID: 1, ReceiverID: 2, Amount: 10, Balance: 90})
+	assert.Nil(t, err)
+	event.ID = 7
+
+	sender := streamEventsForUser(event, 1, true)
+	assert.Len(t, sender, 1)
+	assert.Equal(t, models.EVENT_TRANSFER_SENT, sender[0].Type)
+	assert.Equal(t, uint(7), sender[0].ID)
+
+	receiver := streamEventsForUser(event, 2, false)
+	assert.Len(t, receiver, 1)
+	assert.Equal(t, models.STREAM_TRANSFER_RECEIVED, receiver[0].Type)
+	assert.NotContains(t, string(receiver[0].Data), "balance")
+
+	assert.Empty(t, streamEventsForUser(event, 3, false))
+}
+
+func TestStreamSubscriptions(t *testing.T) {
+	s := NewStreamService(nil)
+
+	first := s.Subscribe(1)
+	second := s.Subscribe(1)
+	assert.Len(t, s.subscribers[1], 2)
+
+	s.Unsubscribe(first)
+	s.Unsubscribe(first)
+	_, open := <-first.Events
+	assert.False(t, open)
+	assert.Len(t, s.subscribers[1], 1)
+
+	s.Unsubscribe(second)
+	assert.NotContains(t, s.subscribers, uint(1))
+}
+
+func TestReplay(t *testing.T) {
+	db, store := newTestDB(t)
+	holder := createTestUser(t, store, "holder@example.com")
+	receiver := createTestUser(t, store, "receiver@example.com")
+	busy := createTestUser(t, store, "busy@example.com")
+	account, err := NewBankService(store).CreateAccount(actorFor(holder))
+	assert.NoError(t, err)
+
+	// Other users' transfers must not use up the replay limit.
+	var events []models.OutboxEvent
+	for i := 0; i <= STREAM_REPLAY_LIMIT; i++ {
+		event, err := models.NewOutboxEvent(models.TransferSent{AccountNumber: account.AccountNumber, SenderID: holder.ID, ReceiverID: busy.ID, Amount: 1})
+		assert.NoError(t, err)
+		events = append(events, event)
+	}
+	event, err := models.NewOutboxEvent(models.TransferSent{AccountNumber: account.AccountNumber, SenderID: holder.ID, ReceiverID: receiver.ID, Amount: 5})
+	assert.NoError(t, err)
+	events = append(events, event)
+	assert.NoError(t, db.CreateInBatches(&events, 100).Error)
+
+	s := NewStreamService(db)
+
+	received, truncated, err := s.Replay(receiver.ID, 0)
+	assert.NoError(t, err)
+	assert.False(t, truncated)
+	assert.Len(t, received, 1)
+	assert.Equal(t, models.STREAM_TRANSFER_RECEIVED, received[0].Type)
+
+	first, truncated, err := s.Replay(busy.ID, 0)
+	assert.NoError(t, err)
+	assert.True(t, truncated)
+	assert.Len(t, first, STREAM_REPLAY_LIMIT)
+
+	rest, truncated, err := s.Replay(busy.ID, first[len(first)-1].ID)
+	assert.NoError(t, err)
+	assert.False(t, truncated)
+	assert.Len(t, rest, 1)
+}
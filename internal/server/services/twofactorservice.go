@@ -0,0 +1,285 @@
+package services
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image/png"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/repository"
+	"github.com/FaizanAC/Go-Banking/internal/util"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	recoveryCodeCount     = 10
+	loginChallengeTTL     = time.Minute * 5
+	maxChallengeAttempts  = 5
+	defaultTOTPIssuerName = "Go Banking"
+	totpPeriod            = 30
+)
+
+var errInvalidCode = errors.New("invalid code")
+
+type TwoFactorService struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorService(db *gorm.DB) *TwoFactorService {
+	return &TwoFactorService{db: db}
+}
+
+// Enroll generates a new TOTP secret for the user. The secret is stored but
+// not enforced until it is confirmed with a valid code.
+func (s *TwoFactorService) Enroll(userID uint) (models.TwoFactorEnrollment, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return models.TwoFactorEnrollment{}, fmt.Errorf("user not found")
+	}
+
+	if user.TOTPEnabled {
+		return models.TwoFactorEnrollment{}, fmt.Errorf("two-factor authentication is already enabled")
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      totpIssuer(),
+		AccountName: user.Email,
+	})
+	if err != nil {
+		return models.TwoFactorEnrollment{}, fmt.Errorf("failed to generate secret")
+	}
+
+	image, err := key.Image(256, 256)
+	if err != nil {
+		return models.TwoFactorEnrollment{}, fmt.Errorf("failed to generate QR code")
+	}
+
+	var qrCode bytes.Buffer
+	if err := png.Encode(&qrCode, image); err != nil {
+		return models.TwoFactorEnrollment{}, fmt.Errorf("failed to generate QR code")
+	}
+
+	if err := s.db.Model(&user).Update("totp_secret", key.Secret()).Error; err != nil {
+		return models.TwoFactorEnrollment{}, fmt.Errorf("failed to save secret")
+	}
+
+	return models.TwoFactorEnrollment{
+		Secret: key.Secret(),
+		URI:    key.URL(),
+		QRCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(qrCode.Bytes()),
+	}, nil
+}
+
+// Confirm enables two-factor authentication once the user proves their
+// authenticator works, and returns the one-time recovery codes.
+func (s *TwoFactorService) Confirm(actor models.Actor, code string) ([]string, error) {
+	var user models.User
+	if err := s.db.First(&user, actor.UserID).Error; err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	if user.TOTPEnabled {
+		return nil, fmt.Errorf("two-factor authentication is already enabled")
+	}
+
+	if user.TOTPSecret == "" {
+		return nil, fmt.Errorf("two-factor enrolment has not been started")
+	}
+
+	before := user.Response()
+	var recoveryCodes []string
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		store := repository.NewGormStore(tx)
+		if !verifyTOTP(store.Logins(), &user, code) {
+			return errInvalidCode
+		}
+
+		if err := tx.Model(&user).Update("totp_enabled", true).Error; err != nil {
+			return err
+		}
+		user.TOTPEnabled = true
+
+		var err error
+		if recoveryCodes, err = replaceRecoveryCodes(tx, user.ID); err != nil {
+			return err
+		}
+
+		return recordAudit(store, actor, models.AUDIT_USER_2FA_ENABLE, models.ENTITY_USER, user.ID, before, user.Response())
+	}); err != nil {
+		if errors.Is(err, errInvalidCode) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to enable two-factor authentication")
+	}
+
+	return recoveryCodes, nil
+}
+
+// Disable turns two-factor authentication off. Both the password and a
+// current code (or recovery code) are required.
+func (s *TwoFactorService) Disable(actor models.Actor, request models.DisableTwoFactor) error {
+	var user models.User
+	if err := s.db.First(&user, actor.UserID).Error; err != nil {
+		return fmt.Errorf("user not found")
+	}
+
+	if !user.TOTPEnabled {
+		return fmt.Errorf("two-factor authentication is not enabled")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(request.Password)); err != nil {
+		return fmt.Errorf("invalid Password")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		store := repository.NewGormStore(tx)
+		if err := verifySecondFactor(store.Logins(), &user, request.Code); err != nil {
+			return err
+		}
+
+		before := user.Response()
+		// The last accepted step belongs to the secret, so it goes with it.
+		if err := tx.Model(&user).Updates(map[string]interface{}{"totp_enabled": false, "totp_secret": "", "totp_last_step": 0}).Error; err != nil {
+			return fmt.Errorf("failed to disable two-factor authentication")
+		}
+		user.TOTPEnabled = false
+
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return fmt.Errorf("failed to disable two-factor authentication")
+		}
+
+		if err := recordAudit(store, actor, models.AUDIT_USER_2FA_DISABLE, models.ENTITY_USER, user.ID, before, user.Response()); err != nil {
+			return fmt.Errorf("failed to disable two-factor authentication")
+		}
+
+		return nil
+	})
+}
+
+// RegenerateRecoveryCodes invalidates every existing recovery code and
+// issues a fresh set.
+func (s *TwoFactorService) RegenerateRecoveryCodes(actor models.Actor, code string) ([]string, error) {
+	var user models.User
+	if err := s.db.First(&user, actor.UserID).Error; err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	if !user.TOTPEnabled {
+		return nil, fmt.Errorf("two-factor authentication is not enabled")
+	}
+
+	var recoveryCodes []string
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		store := repository.NewGormStore(tx)
+		if !verifyTOTP(store.Logins(), &user, code) {
+			return errInvalidCode
+		}
+
+		var err error
+		if recoveryCodes, err = replaceRecoveryCodes(tx, user.ID); err != nil {
+			return err
+		}
+
+		return recordAudit(store, actor, models.AUDIT_USER_RECOVERY_CODES, models.ENTITY_USER, user.ID, nil, nil)
+	}); err != nil {
+		if errors.Is(err, errInvalidCode) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to generate recovery codes")
+	}
+
+	return recoveryCodes, nil
+}
+
+// verifySecondFactor accepts either a current TOTP code or an unused
+// recovery code, which is consumed.
+func verifySecondFactor(logins repository.LoginRepository, user *models.User, code string) error {
+	code = strings.TrimSpace(code)
+	if verifyTOTP(logins, user, code) {
+		return nil
+	}
+
+	used, err := logins.UseRecoveryCode(user.ID, util.HashToken(strings.ToLower(code)))
+	if err != nil || !used {
+		return errInvalidCode
+	}
+
+	return nil
+}
+
+// verifyTOTP accepts a current TOTP code for a newer time step than the
+// last code the user spent, so an intercepted code cannot be replayed.
+func verifyTOTP(logins repository.LoginRepository, user *models.User, code string) bool {
+	step, ok := matchTOTP(strings.TrimSpace(code), user.TOTPSecret, time.Now())
+	if !ok {
+		return false
+	}
+
+	used, err := logins.UseTOTPStep(user.ID, step)
+	return err == nil && used
+}
+
+// matchTOTP returns the time step code belongs to, allowing one step of
+// clock drift either way.
+func matchTOTP(code string, secret string, now time.Time) (int64, bool) {
+	if secret == "" {
+		return 0, false
+	}
+
+	current := now.Unix() / totpPeriod
+	for step := current - 1; step <= current+1; step++ {
+		expected, err := totp.GenerateCodeCustom(secret, time.Unix(step*totpPeriod, 0), totp.ValidateOpts{
+			Period:    totpPeriod,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	rows := make([]models.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		token, err := util.GenerateToken(5)
+		if err != nil {
+			return nil, err
+		}
+
+		code := token[:4] + "-" + token[4:]
+		codes = append(codes, code)
+		rows = append(rows, models.RecoveryCode{UserID: userID, CodeHash: util.HashToken(code)})
+	}
+
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func totpIssuer() string {
+	if issuer := os.Getenv("TOTP_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return defaultTOTPIssuerName
+}
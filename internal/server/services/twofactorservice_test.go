@@ -0,0 +1,142 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+)
+
+// enrolTwoFactor enables two-factor authentication for user and returns the
+// secret and recovery codes.
+func enrolTwoFactor(t *testing.T, service *TwoFactorService, user models.User) (string, []string) {
+	enrollment, err := service.Enroll(user.ID)
+	assert.NoError(t, err)
+
+	codes, err := service.Confirm(actorFor(user), totpCode(t, enrollment.Secret, time.Now()))
+	assert.NoError(t, err)
+	return enrollment.Secret, codes
+}
+
+func totpCode(t *testing.T, secret string, at time.Time) string {
+	code, err := totp.GenerateCode(secret, at)
+	assert.NoError(t, err)
+	return code
+}
+
+func TestEnrollAndConfirmTwoFactor(t *testing.T) {
+	db, store := newTestDB(t)
+	service := NewTwoFactorService(db)
+	user := createTestUser(t, store, "enrol@example.com")
+
+	_, err := service.Confirm(actorFor(user), "123456")
+	assert.EqualError(t, err, "two-factor enrolment has not been started")
+
+	enrollment, err := service.Enroll(user.ID)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, enrollment.Secret)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/")
+	assert.Contains(t, enrollment.QRCode, "data:image/png;base64,")
+
+	_, err = service.Confirm(actorFor(user), "000000")
+	assert.EqualError(t, err, "invalid code")
+
+	codes, err := service.Confirm(actorFor(user), totpCode(t, enrollment.Secret, time.Now()))
+	assert.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+
+	enabled, _ := store.Users().Get(user.ID)
+	assert.True(t, enabled.TOTPEnabled)
+
+	_, err = service.Enroll(user.ID)
+	assert.EqualError(t, err, "two-factor authentication is already enabled")
+}
+
+func TestTOTPCodesCannotBeReplayed(t *testing.T) {
+	db, store := newTestDB(t)
+	service := NewTwoFactorService(db)
+	user := createTestUser(t, store, "replay@example.com")
+
+	enrollment, err := service.Enroll(user.ID)
+	assert.NoError(t, err)
+	code := totpCode(t, enrollment.Secret, time.Now())
+	_, err = service.Confirm(actorFor(user), code)
+	assert.NoError(t, err)
+
+	_, err = service.RegenerateRecoveryCodes(actorFor(user), code)
+	assert.EqualError(t, err, "invalid code")
+
+	err = service.Disable(actorFor(user), models.DisableTwoFactor{Password: testPassword, Code: code})
+	assert.EqualError(t, err, "invalid code")
+
+	// An earlier step is rejected as well, the next one is still accepted.
+	user, _ = store.Users().Get(user.ID)
+	assert.False(t, verifyTOTP(store.Logins(), &user, totpCode(t, enrollment.Secret, time.Now().Add(-totpPeriod*time.Second))))
+	assert.True(t, verifyTOTP(store.Logins(), &user, totpCode(t, enrollment.Secret, time.Now().Add(totpPeriod*time.Second))))
+}
+
+func TestRegenerateRecoveryCodes(t *testing.T) {
+	db, store := newTestDB(t)
+	service := NewTwoFactorService(db)
+	user := createTestUser(t, store, "regenerate@example.com")
+
+	_, err := service.RegenerateRecoveryCodes(actorFor(user), "123456")
+	assert.EqualError(t, err, "two-factor authentication is not enabled")
+
+	secret, oldCodes := enrolTwoFactor(t, service, user)
+
+	// The enrolment code's step is spent, so use the next one.
+	newCodes, err := service.RegenerateRecoveryCodes(actorFor(user), totpCode(t, secret, time.Now().Add(totpPeriod*time.Second)))
+	assert.NoError(t, err)
+	assert.Len(t, newCodes, recoveryCodeCount)
+	assert.NotEqual(t, oldCodes, newCodes)
+
+	user, _ = store.Users().Get(user.ID)
+	assert.EqualError(t, verifySecondFactor(store.Logins(), &user, oldCodes[0]), "invalid code")
+	assert.NoError(t, verifySecondFactor(store.Logins(), &user, newCodes[0]))
+}
+
+func TestRecoveryCodesAreSingleUse(t *testing.T) {
+	db, store := newTestDB(t)
+	service := NewTwoFactorService(db)
+	user := createTestUser(t, store, "recovery@example.com")
+	_, codes := enrolTwoFactor(t, service, user)
+
+	user, _ = store.Users().Get(user.ID)
+	assert.NoError(t, verifySecondFactor(store.Logins(), &user, " "+codes[0]+" "))
+	assert.EqualError(t, verifySecondFactor(store.Logins(), &user, codes[0]), "invalid code")
+	assert.NoError(t, verifySecondFactor(store.Logins(), &user, codes[1]))
+}
+
+func TestDisableTwoFactor(t *testing.T) {
+	db, store := newTestDB(t)
+	service := NewTwoFactorService(db)
+	user := createTestUser(t, store, "disable@example.com")
+
+	err := service.Disable(actorFor(user), models.DisableTwoFactor{Password: testPassword, Code: "123456"})
+	assert.EqualError(t, err, "two-factor authentication is not enabled")
+
+	_, codes := enrolTwoFactor(t, service, user)
+
+	err = service.Disable(actorFor(user), models.DisableTwoFactor{Password: "wrong", Code: codes[0]})
+	assert.EqualError(t, err, "invalid Password")
+
+	err = service.Disable(actorFor(user), models.DisableTwoFactor{Password: testPassword, Code: codes[0]})
+	assert.NoError(t, err)
+
+	disabled, _ := store.Users().Get(user.ID)
+	assert.False(t, disabled.TOTPEnabled)
+	assert.Empty(t, disabled.TOTPSecret)
+	assert.Zero(t, disabled.TOTPLastStep)
+
+	// Deleted codes must not linger as soft-deleted rows.
+	var remaining int64
+	db.Unscoped().Model(&models.RecoveryCode{}).Where("user_id = ?", user.ID).Count(&remaining)
+	assert.Zero(t, remaining)
+
+	var actions []string
+	db.Model(&models.AuditLog{}).Where("entity_type = ? AND entity_id = ?", models.ENTITY_USER, user.ID).Order("id").Pluck("action", &actions)
+	assert.Equal(t, []string{models.AUDIT_USER_2FA_ENABLE, models.AUDIT_USER_2FA_DISABLE}, actions)
+}
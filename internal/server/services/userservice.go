@@ -1,38 +1,337 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/mailer"
 	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/repository"
+	"github.com/FaizanAC/Go-Banking/internal/util"
 	"golang.org/x/crypto/bcrypt"
-	"gorm.io/gorm"
+)
+
+const (
+	verificationResendInterval = time.Minute
+	verificationHourlyLimit    = 3
+)
+
+var (
+	ErrTooManyRequests   = errors.New("too many requests, please try again later")
+	ErrIncorrectPassword = errors.New("current password is incorrect")
+	ErrBlankName         = errors.New("first and last name cannot be blank")
+	ErrEmailTaken        = errors.New("email is already in use")
 )
 
 type UserService struct {
-	db *gorm.DB
+	store  repository.Store
+	mailer mailer.Mailer
 }
 
-func NewUserService(db *gorm.DB) *UserService {
-	return &UserService{db: db}
+func NewUserService(store repository.Store, mailer mailer.Mailer) *UserService {
+	return &UserService{store: store, mailer: mailer}
 }
 
-func (s *UserService) CreateUser(user *models.User) error {
+func (s *UserService) CreateUser(user *models.User, client models.ClientInfo) error {
+	user.Email = normalizeEmail(user.Email)
+	user.FirstName = strings.TrimSpace(user.FirstName)
+	user.LastName = strings.TrimSpace(user.LastName)
+	if user.FirstName == "" || user.LastName == "" {
+		return ErrBlankName
+	}
+
 	encryptedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), 10)
 	if err != nil {
 		return err
 	}
 
 	user.Password = string(encryptedPassword)
+	user.Role = models.ROLE_CUSTOMER
+	user.EmailVerifiedAt = nil
 
-	if res := s.db.Create(user); res.Error != nil {
-		return res.Error
+	if err := s.store.Transaction(func(tx repository.Store) error {
+		if err := tx.Users().Create(user); err != nil {
+			return err
+		}
+
+		actor := models.Actor{UserID: user.ID, ClientInfo: client}
+		return recordAudit(tx, actor, models.AUDIT_USER_CREATE, models.ENTITY_USER, user.ID, nil, user.Response())
+	}); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return ErrEmailTaken
+		}
+		return err
+	}
+
+	if err := s.sendVerificationEmail(user); err != nil {
+		log.Println("failed to send verification email:", err)
 	}
 
 	return nil
 }
 
 func (s *UserService) GetUserByID(id string) (*models.User, error) {
-	var user models.User
-	if err := s.db.First(&user, id).Error; err != nil {
+	userID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, repository.ErrNotFound
+	}
+
+	user, err := s.store.Users().Get(uint(userID))
+	if err != nil {
+		return nil, err
+	}
+	user.Password = ""
+	return &user, nil
+}
+
+// GetUsersByIDs loads the listed users in a single query, without their
+// password hashes.
+func (s *UserService) GetUsersByIDs(ids []uint) ([]models.User, error) {
+	users, err := s.store.Users().ListByIDs(ids)
+	if err != nil {
 		return nil, err
 	}
+
+	for i := range users {
+		users[i].Password = ""
+	}
+	return users, nil
+}
+
+// UpdateProfile applies the fields set in the update. Changing the email
+// address marks it unverified and sends a new verification link, with a
+// notice to the old address.
+func (s *UserService) UpdateProfile(actor models.Actor, update models.UpdateProfile) (*models.User, error) {
+	user, err := s.store.Users().Get(actor.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	before := user.Response()
+	changes := []string{}
+
+	if update.FirstName != nil {
+		firstName := strings.TrimSpace(*update.FirstName)
+		if firstName == "" {
+			return nil, ErrBlankName
+		}
+		user.FirstName = firstName
+		changes = append(changes, "FirstName")
+	}
+
+	if update.LastName != nil {
+		lastName := strings.TrimSpace(*update.LastName)
+		if lastName == "" {
+			return nil, ErrBlankName
+		}
+		user.LastName = lastName
+		changes = append(changes, "LastName")
+	}
+
+	oldEmail := user.Email
+	emailChanged := false
+	if update.Email != nil {
+		email := normalizeEmail(*update.Email)
+		if email != normalizeEmail(user.Email) {
+			taken, err := s.store.Users().EmailTaken(email, user.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to update profile")
+			}
+			if taken {
+				return nil, ErrEmailTaken
+			}
+
+			user.Email, user.EmailVerifiedAt = email, nil
+			changes = append(changes, "Email", "EmailVerifiedAt")
+			emailChanged = true
+		}
+	}
+
+	if len(changes) == 0 {
+		user.Password = ""
+		return &user, nil
+	}
+
+	if err := s.store.Transaction(func(tx repository.Store) error {
+		if err := tx.Users().Update(&user, changes...); err != nil {
+			return err
+		}
+
+		return recordAudit(tx, actor, models.AUDIT_USER_UPDATE, models.ENTITY_USER, user.ID, before, user.Response())
+	}); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, ErrEmailTaken
+		}
+		return nil, fmt.Errorf("failed to update profile")
+	}
+
+	if emailChanged {
+		if err := s.sendVerificationEmail(&user); err != nil {
+			log.Println("failed to send verification email:", err)
+		}
+
+		if err := s.mailer.Send(mailer.Message{
+			To:      oldEmail,
+			Subject: "Your email address was changed",
+			Body: fmt.Sprintf("Hi %s,\n\nThe email address on your Go Banking account was changed to %s.\n\n"+
+				"If you did not make this change, contact support immediately.\n", user.FirstName, user.Email),
+		}); err != nil {
+			log.Println("failed to send email change notice:", err)
+		}
+	}
+
+	user.Password = ""
 	return &user, nil
 }
+
+// ChangePassword replaces the password after checking the current one. Every
+// existing session and API key is revoked, so a fresh token is returned for
+// the caller.
+func (s *UserService) ChangePassword(actor models.Actor, request models.ChangePassword) (string, error) {
+	user, err := s.store.Users().Get(actor.UserID)
+	if err != nil {
+		return "", fmt.Errorf("user not found")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(request.CurrentPassword)); err != nil {
+		return "", ErrIncorrectPassword
+	}
+
+	encryptedPassword, err := bcrypt.GenerateFromPassword([]byte(request.NewPassword), 10)
+	if err != nil {
+		return "", fmt.Errorf("failed to change password")
+	}
+
+	user.Password, user.SessionsValidFrom = string(encryptedPassword), time.Now()
+	if err := s.store.Transaction(func(tx repository.Store) error {
+		if err := tx.Users().Update(&user, "Password", "SessionsValidFrom"); err != nil {
+			return err
+		}
+
+		if err := tx.Logins().DeletePendingChallenges(user.ID); err != nil {
+			return err
+		}
+
+		revokedKeys, err := tx.Logins().RevokeAPIKeys(user.ID, user.SessionsValidFrom)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(tx, actor, models.AUDIT_USER_PASSWORD_CHANGE, models.ENTITY_USER, user.ID,
+			nil, map[string]interface{}{"sessionsRevoked": true, "apiKeysRevoked": revokedKeys})
+	}); err != nil {
+		return "", fmt.Errorf("failed to change password")
+	}
+
+	token, err := util.GenerateJWT(user.ID, user.Role)
+	if err != nil {
+		return "", fmt.Errorf("failed to create session")
+	}
+
+	if err := s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Your password was changed",
+		Body: fmt.Sprintf("Hi %s,\n\nThe password on your Go Banking account was just changed.\n\n"+
+			"If you did not make this change, reset your password and contact support immediately.\n", user.FirstName),
+	}); err != nil {
+		log.Println("failed to send password change notice:", err)
+	}
+
+	return token, nil
+}
+
+// VerifyEmail marks the address in a verification link as verified, provided
+// it is still the user's current address.
+func (s *UserService) VerifyEmail(token string, client models.ClientInfo) error {
+	claims, err := util.ParseEmailVerificationToken(token)
+	if err != nil {
+		return fmt.Errorf("invalid or expired verification link")
+	}
+
+	userID, err := claims.UserID()
+	if err != nil {
+		return fmt.Errorf("invalid or expired verification link")
+	}
+
+	user, err := s.store.Users().Get(userID)
+	if err != nil {
+		return fmt.Errorf("invalid or expired verification link")
+	}
+
+	if user.Email != claims.Email {
+		return fmt.Errorf("invalid or expired verification link")
+	}
+
+	if user.IsEmailVerified() {
+		return nil
+	}
+
+	before := user.Response()
+	if err := s.store.Transaction(func(tx repository.Store) error {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		if err := tx.Users().Update(&user, "EmailVerifiedAt"); err != nil {
+			return err
+		}
+
+		actor := models.Actor{UserID: user.ID, ClientInfo: client}
+		return recordAudit(tx, actor, models.AUDIT_USER_EMAIL_VERIFY, models.ENTITY_USER, user.ID, before, user.Response())
+	}); err != nil {
+		return fmt.Errorf("failed to verify email")
+	}
+
+	return nil
+}
+
+// ResendVerification sends a fresh verification link, at most once a minute
+// and verificationHourlyLimit times an hour.
+func (s *UserService) ResendVerification(userID uint) error {
+	user, err := s.store.Users().Get(userID)
+	if err != nil {
+		return fmt.Errorf("user not found")
+	}
+
+	if user.IsEmailVerified() {
+		return fmt.Errorf("email is already verified")
+	}
+
+	recent, err := s.store.Users().ListEmailVerifications(user.ID, time.Now().Add(-time.Hour))
+	if err != nil {
+		return fmt.Errorf("failed to send verification email")
+	}
+
+	if len(recent) >= verificationHourlyLimit ||
+		(len(recent) > 0 && time.Since(recent[0].CreatedAt) < verificationResendInterval) {
+		return ErrTooManyRequests
+	}
+
+	if err := s.sendVerificationEmail(&user); err != nil {
+		return fmt.Errorf("failed to send verification email")
+	}
+
+	return nil
+}
+
+func (s *UserService) sendVerificationEmail(user *models.User) error {
+	token, err := util.GenerateEmailVerificationToken(user.ID, user.Email)
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.Users().CreateEmailVerification(&models.EmailVerification{UserID: user.ID, Email: user.Email}); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/user/verify-email?token=%s", mailer.BaseURL(), url.QueryEscape(token))
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below.\n\n%s\n\n"+
+			"Until it is confirmed you will not be able to move money.\n", user.FirstName, link),
+	})
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/repository"
+	"github.com/FaizanAC/Go-Banking/internal/util"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTestUserService() (*UserService, *repository.MemoryStore, *recordingMailer) {
+	store := repository.NewMemoryStore()
+	mail := &recordingMailer{}
+	return NewUserService(store, mail), store, mail
+}
+
+func TestCreateUser(t *testing.T) {
+	service, store, mail := newTestUserService()
+
+	user := models.User{Email: "new@example.com", FirstName: " New ", LastName: "User", Password: testPassword, Role: models.ROLE_ADMIN}
+	assert.NoError(t, service.CreateUser(&user, models.ClientInfo{}))
+
+	stored, err := store.Users().Get(user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "New", stored.FirstName)
+	assert.Equal(t, models.ROLE_CUSTOMER, stored.Role)
+	assert.False(t, stored.IsEmailVerified())
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(stored.Password), []byte(testPassword)))
+
+	assert.Len(t, mail.sentTo("new@example.com"), 1)
+	assert.Equal(t, []string{models.AUDIT_USER_CREATE}, auditActions(store))
+
+	blank := models.User{Email: "blank@example.com", FirstName: " ", LastName: "User", Password: testPassword}
+	assert.ErrorIs(t, service.CreateUser(&blank, models.ClientInfo{}), ErrBlankName)
+
+	duplicate := models.User{Email: " New@Example.com", FirstName: "New", LastName: "User", Password: testPassword}
+	assert.ErrorIs(t, service.CreateUser(&duplicate, models.ClientInfo{}), ErrEmailTaken)
+}
+
+func TestCreateUserNormalizesEmail(t *testing.T) {
+	service, store, _ := newTestUserService()
+
+	user := models.User{Email: " Mixed@Example.COM ", FirstName: "Mixed", LastName: "Case", Password: testPassword}
+	assert.NoError(t, service.CreateUser(&user, models.ClientInfo{}))
+	assert.Equal(t, "mixed@example.com", user.Email)
+
+	found, err := store.Users().GetByEmail("MIXED@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+}
+
+func TestGetUsers(t *testing.T) {
+	service, store, _ := newTestUserService()
+	first := createTestUser(t, store, "first@example.com")
+	second := createTestUser(t, store, "second@example.com")
+
+	user, err := service.GetUserByID(fmt.Sprint(first.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, first.Email, user.Email)
+	assert.Empty(t, user.Password)
+
+	_, err = service.GetUserByID("999")
+	assert.Error(t, err)
+	_, err = service.GetUserByID("not-a-number")
+	assert.Error(t, err)
+
+	users, err := service.GetUsersByIDs([]uint{first.ID, second.ID, 999})
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+	for _, user := range users {
+		assert.Empty(t, user.Password)
+	}
+}
+
+func TestUpdateProfile(t *testing.T) {
+	service, store, mail := newTestUserService()
+	user := createTestUser(t, store, "old@example.com")
+	createTestUser(t, store, "taken@example.com")
+
+	firstName, email := "Renamed", "New@Example.com"
+	updated, err := service.UpdateProfile(actorFor(user), models.UpdateProfile{FirstName: &firstName, Email: &email})
+	assert.NoError(t, err)
+	assert.Equal(t, "Renamed", updated.FirstName)
+	assert.Equal(t, "new@example.com", updated.Email)
+	assert.False(t, updated.IsEmailVerified())
+	assert.Empty(t, updated.Password)
+
+	assert.Len(t, mail.sentTo("new@example.com"), 1)
+	assert.Len(t, mail.sentTo("old@example.com"), 1)
+
+	taken := "TAKEN@example.com"
+	_, err = service.UpdateProfile(actorFor(user), models.UpdateProfile{Email: &taken})
+	assert.EqualError(t, err, "email is already in use")
+
+	blank := " "
+	_, err = service.UpdateProfile(actorFor(user), models.UpdateProfile{LastName: &blank})
+	assert.ErrorIs(t, err, ErrBlankName)
+}
+
+func TestChangePassword(t *testing.T) {
+	service, store, mail := newTestUserService()
+	user := createTestUser(t, store, "user@example.com")
+	assert.NoError(t, store.Logins().CreateChallenge(&models.LoginChallenge{ChallengeHash: "pending", UserID: user.ID}))
+	store.AddAPIKey(&models.APIKey{UserID: user.ID, KeyHash: "key"})
+
+	_, err := service.ChangePassword(actorFor(user), models.ChangePassword{CurrentPassword: "wrong", NewPassword: "a new password"})
+	assert.ErrorIs(t, err, ErrIncorrectPassword)
+
+	token, err := service.ChangePassword(actorFor(user), models.ChangePassword{CurrentPassword: testPassword, NewPassword: "a new password"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+
+	stored, _ := store.Users().Get(user.ID)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(stored.Password), []byte("a new password")))
+	assert.False(t, stored.SessionsValidFrom.IsZero())
+
+	_, err = store.Logins().GetChallenge("pending")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	assert.Len(t, mail.sentTo(user.Email), 1)
+	assert.NotNil(t, store.APIKeys()[0].RevokedAt)
+}
+
+func TestVerifyEmail(t *testing.T) {
+	service, store, _ := newTestUserService()
+	user := models.User{Email: "unverified@example.com", FirstName: "Un", LastName: "Verified", Password: testPassword}
+	assert.NoError(t, service.CreateUser(&user, models.ClientInfo{}))
+
+	stale, err := util.GenerateEmailVerificationToken(user.ID, "previous@example.com")
+	assert.NoError(t, err)
+	assert.EqualError(t, service.VerifyEmail(stale, models.ClientInfo{}), "invalid or expired verification link")
+
+	token, err := util.GenerateEmailVerificationToken(user.ID, user.Email)
+	assert.NoError(t, err)
+	assert.NoError(t, service.VerifyEmail(token, models.ClientInfo{}))
+
+	stored, _ := store.Users().Get(user.ID)
+	assert.True(t, stored.IsEmailVerified())
+	assert.Contains(t, auditActions(store), models.AUDIT_USER_EMAIL_VERIFY)
+}
+
+func TestResendVerification(t *testing.T) {
+	service, store, mail := newTestUserService()
+	user := models.User{Email: "unverified@example.com", FirstName: "Un", LastName: "Verified", Password: testPassword}
+	assert.NoError(t, service.CreateUser(&user, models.ClientInfo{}))
+
+	// The email sent on sign-up was under a minute ago.
+	assert.ErrorIs(t, service.ResendVerification(user.ID), ErrTooManyRequests)
+	assert.Len(t, mail.sentTo(user.Email), 1)
+
+	verified := createTestUser(t, store, "verified@example.com")
+	assert.EqualError(t, service.ResendVerification(verified.ID), "email is already verified")
+}
@@ -0,0 +1,618 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/repository"
+	"github.com/FaizanAC/Go-Banking/internal/util"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	WEBHOOK_TIMEOUT       = 10 * time.Second
+	WEBHOOK_MAX_ATTEMPTS  = 10
+	WEBHOOK_BASE_BACKOFF  = 30 * time.Second
+	WEBHOOK_MAX_BACKOFF   = 12 * time.Hour
+	WEBHOOK_DISABLE_AFTER = 20
+	WEBHOOK_BATCH_SIZE    = 50
+)
+
+const (
+	WEBHOOK_ID_HEADER        = "X-Webhook-ID"
+	WEBHOOK_EVENT_HEADER     = "X-Webhook-Event"
+	WEBHOOK_TIMESTAMP_HEADER = "X-Webhook-Timestamp"
+	WEBHOOK_SIGNATURE_HEADER = "X-Webhook-Signature"
+)
+
+var errBlockedWebhookAddress = errors.New("webhook address is not publicly routable")
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), which is no
+// more public than the private ranges.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+type WebhookService struct {
+	db     *gorm.DB
+	client *http.Client
+	// allowLocalhost permits plain HTTP deliveries to the local machine, for
+	// development. It is set by WEBHOOK_ALLOW_LOCALHOST.
+	allowLocalhost bool
+}
+
+func NewWebhookService(db *gorm.DB) *WebhookService {
+	allowLocalhost, _ := strconv.ParseBool(os.Getenv("WEBHOOK_ALLOW_LOCALHOST"))
+
+	// Endpoints are checked when registered, but DNS can change afterwards,
+	// so every connection is checked again against the address it dials.
+	dialer := &net.Dialer{
+		Timeout: WEBHOOK_TIMEOUT,
+		Control: func(network string, address string, conn syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if !webhookAddressAllowed(net.ParseIP(host), allowLocalhost) {
+				return errBlockedWebhookAddress
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// A proxy would dial on our behalf and bypass the check above.
+	transport.Proxy = nil
+
+	return &WebhookService{
+		db: db,
+		client: &http.Client{
+			Timeout:   WEBHOOK_TIMEOUT,
+			Transport: transport,
+			// A redirect could point anywhere; treat it as a failed delivery.
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		allowLocalhost: allowLocalhost,
+	}
+}
+
+// CreateEndpoint registers a webhook for the user. The signing secret is only
+// part of the returned response and cannot be retrieved again.
+func (s *WebhookService) CreateEndpoint(actor models.Actor, request models.NewWebhookEndpoint) (models.WebhookEndpointResponse, error) {
+	if err := validateWebhookURL(request.URL, s.allowLocalhost); err != nil {
+		return models.WebhookEndpointResponse{}, err
+	}
+
+	for _, eventType := range request.EventTypes {
+		if !models.IsValidWebhookEvent(eventType) {
+			return models.WebhookEndpointResponse{}, fmt.Errorf("unknown event type %q", eventType)
+		}
+	}
+
+	secret, err := util.GenerateToken(32)
+	if err != nil {
+		return models.WebhookEndpointResponse{}, fmt.Errorf("failed to generate webhook secret")
+	}
+
+	endpoint := models.WebhookEndpoint{
+		UserID:              actor.UserID,
+		URL:                 request.URL,
+		Secret:              "whsec_" + secret,
+		EventTypes:          strings.Join(request.EventTypes, ","),
+		LowBalanceThreshold: request.LowBalanceThreshold,
+	}
+
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&endpoint).Error; err != nil {
+			return err
+		}
+
+		return recordAudit(repository.NewGormStore(tx), actor, models.AUDIT_WEBHOOK_CREATE, models.ENTITY_WEBHOOK, endpoint.ID, nil, endpoint.Response())
+	}); err != nil {
+		return models.WebhookEndpointResponse{}, fmt.Errorf("failed to create webhook")
+	}
+
+	response := endpoint.Response()
+	response.Secret = endpoint.Secret
+	return response, nil
+}
+
+// validateWebhookURL only allows HTTPS to public hosts. With allowLocalhost,
+// plain HTTP to the local machine is allowed too, for development.
+func validateWebhookURL(rawURL string, allowLocalhost bool) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return fmt.Errorf("invalid webhook url")
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	local := host == "localhost" || strings.HasSuffix(host, ".localhost")
+	if ip := net.ParseIP(host); ip != nil {
+		local = ip.IsLoopback()
+		if !local && !webhookAddressAllowed(ip, false) {
+			return fmt.Errorf("webhook url must point to a public address")
+		}
+	}
+
+	if local {
+		if !allowLocalhost {
+			return fmt.Errorf("webhook url must point to a public address")
+		}
+		if parsed.Scheme == "http" || parsed.Scheme == "https" {
+			return nil
+		}
+	}
+
+	if parsed.Scheme != "https" {
+		return fmt.Errorf("webhook url must use https")
+	}
+	return nil
+}
+
+// webhookAddressAllowed reports whether deliveries may connect to ip, which
+// rules out loopback, private, link-local and other internal ranges.
+func webhookAddressAllowed(ip net.IP, allowLoopback bool) bool {
+	if ip == nil {
+		return false
+	}
+	if ip.IsLoopback() {
+		return allowLoopback
+	}
+
+	return !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+func (s *WebhookService) ListEndpoints(userID uint) ([]models.WebhookEndpointResponse, error) {
+	var endpoints []models.WebhookEndpoint
+	if err := s.db.Where("user_id = ?", userID).Order("id").Find(&endpoints).Error; err != nil {
+		return nil, fmt.Errorf("failed to list webhooks")
+	}
+
+	responses := make([]models.WebhookEndpointResponse, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		responses = append(responses, endpoint.Response())
+	}
+	return responses, nil
+}
+
+func (s *WebhookService) endpointForUser(userID uint, endpointID uint) (models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	if err := s.db.Where("id = ? AND user_id = ?", endpointID, userID).First(&endpoint).Error; err != nil {
+		return endpoint, fmt.Errorf("webhook not found")
+	}
+	return endpoint, nil
+}
+
+// DeleteEndpoint removes the webhook and abandons its pending deliveries.
+func (s *WebhookService) DeleteEndpoint(actor models.Actor, endpointID uint) error {
+	endpoint, err := s.endpointForUser(actor.UserID, endpointID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.WebhookDelivery{}).
+			Where("endpoint_id = ? AND status = ?", endpoint.ID, models.DELIVERY_PENDING).
+			Updates(map[string]interface{}{"status": models.DELIVERY_FAILED, "next_attempt_at": nil, "last_error": "webhook deleted"}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&endpoint).Error; err != nil {
+			return err
+		}
+
+		return recordAudit(repository.NewGormStore(tx), actor, models.AUDIT_WEBHOOK_DELETE, models.ENTITY_WEBHOOK, endpoint.ID, endpoint.Response(), nil)
+	}); err != nil {
+		return fmt.Errorf("failed to delete webhook")
+	}
+
+	return nil
+}
+
+// EnableEndpoint re-enables a webhook that was disabled after repeated
+// failures. Deliveries still pending resume on the next run.
+func (s *WebhookService) EnableEndpoint(actor models.Actor, endpointID uint) (models.WebhookEndpointResponse, error) {
+	endpoint, err := s.endpointForUser(actor.UserID, endpointID)
+	if err != nil {
+		return models.WebhookEndpointResponse{}, err
+	}
+
+	before := endpoint.Response()
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&endpoint).Updates(map[string]interface{}{"disabled_at": nil, "consecutive_failures": 0}).Error; err != nil {
+			return err
+		}
+
+		endpoint.DisabledAt, endpoint.ConsecutiveFailures = nil, 0
+		return recordAudit(repository.NewGormStore(tx), actor, models.AUDIT_WEBHOOK_ENABLE, models.ENTITY_WEBHOOK, endpoint.ID, before, endpoint.Response())
+	}); err != nil {
+		return before, fmt.Errorf("failed to enable webhook")
+	}
+
+	return endpoint.Response(), nil
+}
+
+// ListDeliveries returns a page of the webhook's delivery log, newest first.
+func (s *WebhookService) ListDeliveries(userID uint, endpointID uint, page int, pageSize int) ([]models.WebhookDelivery, int64, error) {
+	if _, err := s.endpointForUser(userID, endpointID); err != nil {
+		return nil, 0, err
+	}
+
+	query := s.db.Model(&models.WebhookDelivery{}).Where("endpoint_id = ?", endpointID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list deliveries")
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := query.Order("id desc").Offset((page - 1) * pageSize).Limit(pageSize).Find(&deliveries).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list deliveries")
+	}
+
+	return deliveries, total, nil
+}
+
+// Redeliver queues a delivery to be sent again straight away, with a fresh
+// set of retries.
+func (s *WebhookService) Redeliver(actor models.Actor, endpointID uint, deliveryID uint) (models.WebhookDelivery, error) {
+	endpoint, err := s.endpointForUser(actor.UserID, endpointID)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	var delivery models.WebhookDelivery
+	if err := s.db.Where("id = ? AND endpoint_id = ?", deliveryID, endpoint.ID).First(&delivery).Error; err != nil {
+		return delivery, fmt.Errorf("delivery not found")
+	}
+
+	before := delivery
+	now := time.Now()
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&delivery).Updates(map[string]interface{}{
+			"status":          models.DELIVERY_PENDING,
+			"attempts":        0,
+			"next_attempt_at": &now,
+		}).Error; err != nil {
+			return err
+		}
+
+		delivery.Status, delivery.Attempts, delivery.NextAttemptAt = models.DELIVERY_PENDING, 0, &now
+		return recordAudit(repository.NewGormStore(tx), actor, models.AUDIT_WEBHOOK_REDELIVER, models.ENTITY_WEBHOOK, endpoint.ID, before, delivery)
+	}); err != nil {
+		return before, fmt.Errorf("failed to redeliver")
+	}
+
+	return delivery, nil
+}
+
+// Publish implements events.Sink. It turns a domain event into deliveries
+// for every subscribed endpoint; the HTTP calls happen in DeliverDue, so a
+// slow endpoint never holds up the outbox.
+func (s *WebhookService) Publish(event models.OutboxEvent) error {
+	domainEvent, err := event.Decode()
+	if err != nil {
+		return nil
+	}
+
+	switch e := domainEvent.(type) {
+	case models.TransferSent:
+		if err := s.queue(event, models.WEBHOOK_TRANSFER_RECEIVED, []uint{e.ReceiverID}, map[string]interface{}{
+			"transactionId": e.TransactionID,
+			"senderId":      e.SenderID,
+			"amount":        e.Amount,
+			"expiresOn":     e.ExpiresOn,
+		}); err != nil {
+			return err
+		}
+		return s.queueLowBalance(event, e.AccountNumber, e.Balance, e.Amount)
+
+	case models.TransferAccepted:
+		return s.queue(event, models.WEBHOOK_TRANSFER_ACCEPTED, []uint{e.SenderID}, map[string]interface{}{
+			"transactionId": e.TransactionID,
+			"receiverId":    e.ReceiverID,
+			"amount":        e.Amount,
+		})
+
+	case models.FundsDeposited:
+		holders, err := s.holders(e.AccountNumber)
+		if err != nil {
+			return err
+		}
+		return s.queue(event, models.WEBHOOK_DEPOSIT, holders, map[string]interface{}{
+			"accountNumber": e.AccountNumber,
+			"transactionId": e.TransactionID,
+			"amount":        e.Amount,
+			"balance":       e.Balance,
+		})
+
+	case models.FundsWithdrawn:
+		return s.queueLowBalance(event, e.AccountNumber, e.Balance, e.Amount)
+
+	case models.BalanceAdjusted:
+		if e.Amount < 0 {
+			return s.queueLowBalance(event, e.AccountNumber, e.Balance, -e.Amount)
+		}
+	}
+
+	return nil
+}
+
+// queueLowBalance notifies holders whose threshold the balance has just
+// dropped below.
+func (s *WebhookService) queueLowBalance(event models.OutboxEvent, accountNumber string, balance float64, debited float64) error {
+	holders, err := s.holders(accountNumber)
+	if err != nil {
+		return err
+	}
+
+	endpoints, err := s.subscribedEndpoints(models.WEBHOOK_LOW_BALANCE, holders)
+	if err != nil {
+		return err
+	}
+
+	for _, endpoint := range endpoints {
+		threshold := endpoint.LowBalanceThreshold
+		if balance >= threshold || balance+debited < threshold {
+			continue
+		}
+
+		if err := s.createDelivery(endpoint, event, models.WEBHOOK_LOW_BALANCE, map[string]interface{}{
+			"accountNumber": accountNumber,
+			"balance":       balance,
+			"threshold":     threshold,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *WebhookService) queue(event models.OutboxEvent, eventType string, userIDs []uint, data map[string]interface{}) error {
+	endpoints, err := s.subscribedEndpoints(eventType, userIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, endpoint := range endpoints {
+		if err := s.createDelivery(endpoint, event, eventType, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *WebhookService) holders(accountNumber string) ([]uint, error) {
+	var userIDs []uint
+	err := s.db.Model(&models.AccountMember{}).
+		Where("account_number = ? AND accepted_at IS NOT NULL", accountNumber).
+		Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+func (s *WebhookService) subscribedEndpoints(eventType string, userIDs []uint) ([]models.WebhookEndpoint, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	var endpoints []models.WebhookEndpoint
+	if err := s.db.Where("user_id IN ?", userIDs).Find(&endpoints).Error; err != nil {
+		return nil, err
+	}
+
+	subscribed := endpoints[:0]
+	for _, endpoint := range endpoints {
+		if endpoint.IsSubscribed(eventType) {
+			subscribed = append(subscribed, endpoint)
+		}
+	}
+	return subscribed, nil
+}
+
+// createDelivery queues one event for one endpoint. The outbox may publish an
+// event more than once, so duplicates are ignored.
+func (s *WebhookService) createDelivery(endpoint models.WebhookEndpoint, event models.OutboxEvent, eventType string, data map[string]interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(models.WebhookPayload{
+		ID:         event.EventID,
+		Type:       eventType,
+		OccurredAt: event.OccurredAt,
+		Data:       encoded,
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.WebhookDelivery{
+		EndpointID:    endpoint.ID,
+		EventID:       event.EventID,
+		EventType:     eventType,
+		Payload:       payload,
+		Status:        models.DELIVERY_PENDING,
+		NextAttemptAt: &now,
+	}).Error
+}
+
+// DeliverDue attempts up to limit deliveries whose next attempt is due and
+// returns how many were attempted.
+func (s *WebhookService) DeliverDue(limit int) (int, error) {
+	now := time.Now()
+
+	var due []models.WebhookDelivery
+	if err := s.db.Where("status = ? AND next_attempt_at <= ?", models.DELIVERY_PENDING, now).
+		Where("endpoint_id IN (?)", s.db.Model(&models.WebhookEndpoint{}).Select("id").Where("disabled_at IS NULL")).
+		Order("id").Limit(limit).Find(&due).Error; err != nil {
+		return 0, err
+	}
+
+	attempted := 0
+	for _, delivery := range due {
+		// Push the next attempt past the request timeout so that another
+		// instance does not pick up the same delivery meanwhile.
+		lease := now.Add(2 * WEBHOOK_TIMEOUT)
+		claim := s.db.Model(&models.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.ID, models.DELIVERY_PENDING, delivery.NextAttemptAt).
+			Update("next_attempt_at", &lease)
+		if claim.Error != nil {
+			return attempted, claim.Error
+		}
+		if claim.RowsAffected == 0 {
+			continue
+		}
+
+		var endpoint models.WebhookEndpoint
+		if err := s.db.First(&endpoint, delivery.EndpointID).Error; err != nil {
+			continue
+		}
+
+		statusCode, err := s.send(endpoint, delivery)
+		if err := s.recordAttempt(endpoint, delivery, statusCode, err); err != nil {
+			return attempted, err
+		}
+		attempted++
+	}
+
+	return attempted, nil
+}
+
+// send POSTs the delivery to the endpoint, signed with its secret.
+func (s *WebhookService) send(endpoint models.WebhookEndpoint, delivery models.WebhookDelivery) (int, error) {
+	request, err := http.NewRequest(http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "Go-Banking-Webhooks/1.0")
+	request.Header.Set(WEBHOOK_ID_HEADER, delivery.EventID)
+	request.Header.Set(WEBHOOK_EVENT_HEADER, delivery.EventType)
+	request.Header.Set(WEBHOOK_TIMESTAMP_HEADER, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(WEBHOOK_SIGNATURE_HEADER, "v1="+util.SignWebhook(endpoint.Secret, timestamp, delivery.Payload))
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return response.StatusCode, fmt.Errorf("endpoint responded with %d", response.StatusCode)
+	}
+	return response.StatusCode, nil
+}
+
+// recordAttempt stores the outcome of a delivery attempt, schedules a retry
+// with exponential backoff and disables endpoints that keep failing.
+func (s *WebhookService) recordAttempt(endpoint models.WebhookEndpoint, delivery models.WebhookDelivery, statusCode int, sendErr error) error {
+	now := time.Now()
+	attempts := delivery.Attempts + 1
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if sendErr == nil {
+			if err := tx.Model(&delivery).Updates(map[string]interface{}{
+				"status":           models.DELIVERY_SUCCEEDED,
+				"attempts":         attempts,
+				"last_status_code": statusCode,
+				"last_error":       "",
+				"next_attempt_at":  nil,
+				"delivered_at":     &now,
+			}).Error; err != nil {
+				return err
+			}
+
+			return tx.Model(&endpoint).Update("consecutive_failures", 0).Error
+		}
+
+		updates := map[string]interface{}{
+			"attempts":         attempts,
+			"last_status_code": statusCode,
+			"last_error":       sendErr.Error(),
+		}
+		if attempts >= WEBHOOK_MAX_ATTEMPTS {
+			updates["status"], updates["next_attempt_at"] = models.DELIVERY_FAILED, nil
+		} else {
+			next := now.Add(webhookBackoff(attempts))
+			updates["next_attempt_at"] = &next
+		}
+
+		if err := tx.Model(&delivery).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&endpoint).Update("consecutive_failures", gorm.Expr("consecutive_failures + 1")).Error; err != nil {
+			return err
+		}
+
+		disable := tx.Model(&models.WebhookEndpoint{}).
+			Where("id = ? AND disabled_at IS NULL AND consecutive_failures >= ?", endpoint.ID, WEBHOOK_DISABLE_AFTER).
+			Update("disabled_at", &now)
+		if disable.Error != nil {
+			return disable.Error
+		}
+		if disable.RowsAffected == 0 {
+			return nil
+		}
+
+		log.Printf("disabled webhook %d after %d consecutive failures", endpoint.ID, WEBHOOK_DISABLE_AFTER)
+		before := endpoint.Response()
+		endpoint.DisabledAt = &now
+		return recordAudit(repository.NewGormStore(tx), models.Actor{}, models.AUDIT_WEBHOOK_DISABLE, models.ENTITY_WEBHOOK, endpoint.ID, before, endpoint.Response())
+	})
+}
+
+// webhookBackoff is the delay before retrying after the given number of
+// failed attempts: 30s, 1m, 2m, ... capped at 12h.
+func webhookBackoff(attempts int) time.Duration {
+	backoff := WEBHOOK_BASE_BACKOFF
+	for i := 1; i < attempts && backoff < WEBHOOK_MAX_BACKOFF; i++ {
+		backoff *= 2
+	}
+	if backoff > WEBHOOK_MAX_BACKOFF {
+		return WEBHOOK_MAX_BACKOFF
+	}
+	return backoff
+}
+
+// Start sends due deliveries every interval until stop is closed.
+func (s *WebhookService) Start(interval time.Duration, stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.DeliverDue(WEBHOOK_BATCH_SIZE); err != nil {
+					log.Println("failed to deliver webhooks:", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
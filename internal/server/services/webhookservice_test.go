@@ -0,0 +1,126 @@
+package services
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateWebhookURL(t *testing.T) {
+	assert.Nil(t, validateWebhookURL("https://example.com/hooks", false))
+	assert.Nil(t, validateWebhookURL("https://93.184.216.34/hooks", false))
+	assert.NotNil(t, validateWebhookURL("http://example.com/hooks", false))
+	assert.NotNil(t, validateWebhookURL("ftp://example.com", false))
+	assert.NotNil(t, validateWebhookURL("not a url", false))
+
+	for _, internal := range []string{
+		"https://localhost/hooks",
+		"https://api.localhost/hooks",
+		"https://127.0.0.1/hooks",
+		"https://[::1]/hooks",
+		"https://10.0.0.5/hooks",
+		"https://192.168.1.1/hooks",
+		"https://169.254.169.254/latest/meta-data",
+		"https://[fe80::1]/hooks",
+		"https://100.64.0.1/hooks",
+		"https://0.0.0.0/hooks",
+		"https://[::ffff:10.0.0.1]/hooks",
+	} {
+		assert.EqualError(t, validateWebhookURL(internal, false), "webhook url must point to a public address", internal)
+	}
+
+	// The development flag only opens up the local machine.
+	assert.Nil(t, validateWebhookURL("http://localhost:9000/hooks", true))
+	assert.Nil(t, validateWebhookURL("http://127.0.0.1:9000/hooks", true))
+	assert.NotNil(t, validateWebhookURL("https://10.0.0.5/hooks", true))
+	assert.NotNil(t, validateWebhookURL("http://example.com/hooks", true))
+}
+
+func TestWebhookDialRejectsInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	// The URL passed validation once, but the address it reaches is only
+	// checked when dialling.
+	_, err := NewWebhookService(nil).send(models.WebhookEndpoint{URL: server.URL}, models.WebhookDelivery{})
+	assert.ErrorIs(t, err, errBlockedWebhookAddress)
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, webhookBackoff(1))
+	assert.Equal(t, time.Minute, webhookBackoff(2))
+	assert.Equal(t, 4*time.Minute, webhookBackoff(4))
+	assert.Equal(t, WEBHOOK_MAX_BACKOFF, webhookBackoff(50))
+}
+
+func TestWebhookSendIsSigned(t *testing.T) {
+	endpoint := models.WebhookEndpoint{Secret: "whsec_test"}
+	delivery := models.WebhookDelivery{EventID: "evt-1", EventType: models.WEBHOOK_DEPOSIT, Payload: []byte(`{"type":"deposit"}`)}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, err := strconv.ParseInt(r.Header.Get(WEBHOOK_TIMESTAMP_HEADER), 10, 64)
+		assert.Nil(t, err)
+
+		assert.Equal(t, "evt-1", r.Header.Get(WEBHOOK_ID_HEADER))
+		assert.Equal(t, "v1="+util.SignWebhook("whsec_test", timestamp, body), r.Header.Get(WEBHOOK_SIGNATURE_HEADER))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	t.Setenv("WEBHOOK_ALLOW_LOCALHOST", "true")
+	endpoint.URL = server.URL
+	statusCode, err := NewWebhookService(nil).send(endpoint, delivery)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, statusCode)
+}
+
+func TestWebhookSendTreatsRedirectsAsFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/", http.StatusFound)
+	}))
+	defer server.Close()
+
+	t.Setenv("WEBHOOK_ALLOW_LOCALHOST", "true")
+	statusCode, err := NewWebhookService(nil).send(models.WebhookEndpoint{URL: server.URL}, models.WebhookDelivery{})
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusFound, statusCode)
+}
+
+func TestRelayQueuesWebhookDeliveries(t *testing.T) {
+	db, store := newTestDB(t)
+	user := createTestUser(t, store, "hooked@example.com")
+	bank := NewBankService(store)
+	webhooks := NewWebhookService(db)
+
+	account, err := bank.CreateAccount(actorFor(user))
+	assert.NoError(t, err)
+	_, err = webhooks.CreateEndpoint(actorFor(user), models.NewWebhookEndpoint{URL: "https://hooks.example.com/bank", EventTypes: []string{models.WEBHOOK_DEPOSIT}})
+	assert.NoError(t, err)
+	_, err = bank.DepositToAccount(models.Transaction{AccountNumber: account.AccountNumber, Amount: 10}, actorFor(user))
+	assert.NoError(t, err)
+
+	// The sink writes deliveries while the relay is running; on SQLite that
+	// only works if the relay holds no transaction open meanwhile.
+	published, err := NewOutboxRelay(db, webhooks).RelayOnce(OUTBOX_BATCH_SIZE)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, published)
+
+	var deliveries []models.WebhookDelivery
+	assert.NoError(t, db.Find(&deliveries).Error)
+	assert.Len(t, deliveries, 1)
+	assert.Equal(t, models.WEBHOOK_DEPOSIT, deliveries[0].EventType)
+
+	var stuck int64
+	assert.NoError(t, db.Model(&models.OutboxEvent{}).Where("published_at IS NULL OR last_error <> ''").Count(&stuck).Error)
+	assert.Zero(t, stuck)
+}
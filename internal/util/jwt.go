@@ -3,30 +3,157 @@ package util
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func GenerateJWT(userID uint) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": userID,
-		"exp": time.Now().Add(time.Hour * 24).Unix(),
-	})
+const (
+	defaultJWTIssuer   = "go-banking"
+	defaultJWTAudience = "go-banking-api"
+	jwtLifetime        = time.Hour * 24
+	jwtLeeway          = time.Second * 30
 
-	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_KEY")))
+	emailVerificationAudience = "email-verification"
+	emailVerificationLifetime = time.Hour * 48
+	OAuthAccessTokenLifetime  = time.Minute * 15
+)
+
+func init() {
+	// Session revocation compares iat against the revocation time, so keep
+	// the sub-second part instead of truncating to whole seconds.
+	jwt.TimePrecision = time.Microsecond
+}
+
+type Claims struct {
+	Role      string `json:"role,omitempty"`
+	Email     string `json:"email,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	ConsentID uint   `json:"cid,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// UserID returns the numeric user ID stored in the subject claim.
+func (c *Claims) UserID() (uint, error) {
+	id, err := strconv.ParseUint(c.Subject, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid subject claim")
+	}
+
+	return uint(id), nil
+}
+
+func JWTIssuer() string {
+	if issuer := os.Getenv("JWT_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return defaultJWTIssuer
+}
+
+func JWTAudience() string {
+	if audience := os.Getenv("JWT_AUDIENCE"); audience != "" {
+		return audience
+	}
+	return defaultJWTAudience
+}
+
+func GenerateJWT(userID uint, role string) (string, error) {
+	claims := newClaims(userID, JWTAudience(), jwtLifetime)
+	claims.Role = role
+	return signClaims(claims)
+}
+
+// ParseJWT verifies the signature of tokenString against the key named by its
+// kid header and checks the registered claims (iss, aud, nbf, exp).
+func ParseJWT(tokenString string) (*Claims, error) {
+	return parseClaims(tokenString, JWTAudience())
+}
+
+// GenerateOAuthAccessToken signs a short-lived access token delegated to a
+// third-party client. It carries no role, so it never grants staff access.
+func GenerateOAuthAccessToken(userID uint, clientID string, consentID uint, scope string) (string, error) {
+	claims := newClaims(userID, JWTAudience(), OAuthAccessTokenLifetime)
+	claims.ClientID = clientID
+	claims.ConsentID = consentID
+	claims.Scope = scope
+	return signClaims(claims)
+}
+
+// GenerateEmailVerificationToken signs a link token bound to the address it
+// was sent to, so it stops working once the user changes their email.
+func GenerateEmailVerificationToken(userID uint, email string) (string, error) {
+	claims := newClaims(userID, emailVerificationAudience, emailVerificationLifetime)
+	claims.Email = email
+	return signClaims(claims)
+}
+
+func ParseEmailVerificationToken(tokenString string) (*Claims, error) {
+	return parseClaims(tokenString, emailVerificationAudience)
+}
 
-	return tokenString, err
+func newClaims(userID uint, audience string, lifetime time.Duration) Claims {
+	now := time.Now()
+	return Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(uint64(userID), 10),
+			Issuer:    JWTIssuer(),
+			Audience:  jwt.ClaimStrings{audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(lifetime)),
+		},
+	}
 }
 
-func ParseJWT(tokenString string) (*jwt.Token, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+func signClaims(claims Claims) (string, error) {
+	keySet, err := DefaultKeySet()
+	if err != nil {
+		return "", err
+	}
+
+	key, err := keySet.ActiveKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+
+	return token.SignedString(key.PrivateKey)
+}
+
+func parseClaims(tokenString string, audience string) (*Claims, error) {
+	keySet, err := DefaultKeySet()
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &Claims{}
+	_, err = jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := keySet.Key(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, fmt.Errorf("unexpected Signing Method: %v", token.Header["alg"])
 		}
 
-		return []byte(os.Getenv("JWT_KEY")), nil
-	})
+		return key.PublicKey(), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(JWTIssuer()),
+		jwt.WithAudience(audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(jwtLeeway),
+	)
+	if err != nil {
+		return nil, err
+	}
 
-	return token, err
+	return claims, nil
 }
@@ -2,20 +2,45 @@ package util
 
 import (
 	"testing"
+	"time"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestGenerateAndParseJWT(t *testing.T) {
 	var userID uint = 1
-	tokenString, err := GenerateJWT(userID)
+	tokenString, err := GenerateJWT(userID, "admin")
 	assert.Nil(t, err)
 
-	jwtToken, err := ParseJWT(tokenString)
+	claims, err := ParseJWT(tokenString)
 	assert.Nil(t, err)
 
-	if claims, ok := jwtToken.Claims.(jwt.MapClaims); ok {
-		assert.Equal(t, claims["sub"], float64(userID))
-	}
+	parsedID, err := claims.UserID()
+	assert.Nil(t, err)
+	assert.Equal(t, userID, parsedID)
+	assert.Equal(t, JWTIssuer(), claims.Issuer)
+	assert.Equal(t, "admin", claims.Role)
+}
+
+func TestEmailVerificationTokenIsNotAnAccessToken(t *testing.T) {
+	tokenString, err := GenerateEmailVerificationToken(1, "test@example.com")
+	assert.Nil(t, err)
+
+	claims, err := ParseEmailVerificationToken(tokenString)
+	assert.Nil(t, err)
+	assert.Equal(t, "test@example.com", claims.Email)
+
+	_, err = ParseJWT(tokenString)
+	assert.NotNil(t, err)
+}
+
+func TestIssuedAtKeepsSubSecondPrecision(t *testing.T) {
+	before := time.Now().Truncate(time.Microsecond)
+	tokenString, err := GenerateJWT(1, "customer")
+	assert.Nil(t, err)
+
+	claims, err := ParseJWT(tokenString)
+	assert.Nil(t, err)
+	assert.False(t, claims.IssuedAt.Before(before))
+	assert.True(t, claims.IssuedAt.Before(time.Now().Add(time.Microsecond)))
 }
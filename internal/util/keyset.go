@@ -0,0 +1,273 @@
+package util
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type SigningKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.Signer
+	ActiveFrom time.Time
+}
+
+func (k *SigningKey) PublicKey() crypto.PublicKey {
+	return k.PrivateKey.Public()
+}
+
+type JWK struct {
+	KeyID     string `json:"kid"`
+	KeyType   string `json:"kty"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// KeySet holds every key that tokens may be verified with. The key with the
+// latest ActiveFrom that is not in the future signs new tokens, so a freshly
+// added key takes over on the next reload while older keys keep verifying
+// the tokens they issued until they are removed from the directory.
+type KeySet struct {
+	mu   sync.RWMutex
+	dir  string
+	keys map[string]*SigningKey
+}
+
+func NewKeySet(keys ...*SigningKey) *KeySet {
+	ks := &KeySet{keys: make(map[string]*SigningKey)}
+	for _, key := range keys {
+		ks.keys[key.ID] = key
+	}
+	return ks
+}
+
+// LoadKeySet reads every *.pem file in dir as a PKCS#8 RSA or Ed25519 private
+// key. The file name without extension becomes the kid and the file's
+// modification time the moment the key becomes active.
+func LoadKeySet(dir string) (*KeySet, error) {
+	ks := &KeySet{dir: dir}
+	if err := ks.Reload(); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+func (ks *KeySet) Reload() error {
+	if ks.dir == "" {
+		return nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(ks.dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]*SigningKey)
+	for _, path := range paths {
+		key, err := loadSigningKey(path)
+		if err != nil {
+			return err
+		}
+		keys[key.ID] = key
+	}
+
+	if len(keys) == 0 {
+		return fmt.Errorf("no signing keys found in %s", ks.dir)
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.mu.Unlock()
+
+	return nil
+}
+
+// StartRotation reloads the key directory every interval until stop is closed.
+func (ks *KeySet) StartRotation(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := ks.Reload(); err != nil {
+					log.Println("failed to reload signing keys:", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+func (ks *KeySet) ActiveKey() (*SigningKey, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	now := time.Now()
+	var active *SigningKey
+	for _, key := range ks.keys {
+		if key.ActiveFrom.After(now) {
+			continue
+		}
+		if active == nil || key.ActiveFrom.After(active.ActiveFrom) ||
+			(key.ActiveFrom.Equal(active.ActiveFrom) && key.ID > active.ID) {
+			active = key
+		}
+	}
+
+	if active == nil {
+		return nil, fmt.Errorf("no active signing key")
+	}
+
+	return active, nil
+}
+
+func (ks *KeySet) Key(kid string) (*SigningKey, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+func (ks *KeySet) JWKS() JWKS {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range ks.keys {
+		jwk := JWK{
+			KeyID:     key.ID,
+			Algorithm: key.Method.Alg(),
+			Use:       "sig",
+		}
+
+		switch pub := key.PublicKey().(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].KeyID < jwks.Keys[j].KeyID })
+
+	return jwks
+}
+
+func loadSigningKey(path string) (*SigningKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not PEM encoded", path)
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	kid := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return newSigningKey(kid, parsed, info.ModTime())
+}
+
+func newSigningKey(kid string, privateKey any, activeFrom time.Time) (*SigningKey, error) {
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		return &SigningKey{ID: kid, Method: jwt.SigningMethodRS256, PrivateKey: key, ActiveFrom: activeFrom}, nil
+	case ed25519.PrivateKey:
+		return &SigningKey{ID: kid, Method: jwt.SigningMethodEdDSA, PrivateKey: key, ActiveFrom: activeFrom}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T for %s", privateKey, kid)
+	}
+}
+
+// GenerateEd25519Key creates an in-memory signing key, used when no key
+// directory is configured.
+func GenerateEd25519Key(kid string) (*SigningKey, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return newSigningKey(kid, privateKey, time.Now())
+}
+
+var (
+	defaultKeySet     *KeySet
+	defaultKeySetOnce sync.Once
+	defaultKeySetErr  error
+)
+
+// DefaultKeySet returns the process-wide key set, loading it from
+// JWT_KEYS_DIR on first use. Without JWT_KEYS_DIR an ephemeral Ed25519 key is
+// generated, which means tokens do not survive a restart.
+func DefaultKeySet() (*KeySet, error) {
+	defaultKeySetOnce.Do(func() {
+		if defaultKeySet != nil {
+			return
+		}
+
+		if dir := os.Getenv("JWT_KEYS_DIR"); dir != "" {
+			defaultKeySet, defaultKeySetErr = LoadKeySet(dir)
+			return
+		}
+
+		log.Println("JWT_KEYS_DIR is not set, signing tokens with an ephemeral key")
+		key, err := GenerateEd25519Key("ephemeral")
+		if err != nil {
+			defaultKeySetErr = err
+			return
+		}
+		defaultKeySet = NewKeySet(key)
+	})
+
+	return defaultKeySet, defaultKeySetErr
+}
+
+// SetDefaultKeySet replaces the process-wide key set.
+func SetDefaultKeySet(ks *KeySet) {
+	defaultKeySetOnce.Do(func() {})
+	defaultKeySet, defaultKeySetErr = ks, nil
+}
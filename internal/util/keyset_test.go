@@ -0,0 +1,87 @@
+package util
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestKey(t *testing.T, dir string, kid string, privateKey any, modTime time.Time) {
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	assert.Nil(t, err)
+
+	path := filepath.Join(dir, kid+".pem")
+	err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+	assert.Nil(t, err)
+	assert.Nil(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestKeySetRotation(t *testing.T) {
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	writeTestKey(t, dir, "2024-01", rsaKey, time.Now().Add(-time.Hour))
+
+	keySet, err := LoadKeySet(dir)
+	assert.Nil(t, err)
+	previous, err := DefaultKeySet()
+	assert.Nil(t, err)
+	SetDefaultKeySet(keySet)
+	t.Cleanup(func() { SetDefaultKeySet(previous) })
+
+	oldToken, err := GenerateJWT(1, "customer")
+	assert.Nil(t, err)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	writeTestKey(t, dir, "2024-02", edKey, time.Now())
+	assert.Nil(t, keySet.Reload())
+
+	active, err := keySet.ActiveKey()
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-02", active.ID)
+	assert.Equal(t, "EdDSA", active.Method.Alg())
+
+	_, err = ParseJWT(oldToken)
+	assert.Nil(t, err)
+
+	jwks := keySet.JWKS()
+	assert.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "RSA", jwks.Keys[0].KeyType)
+	assert.Equal(t, "OKP", jwks.Keys[1].KeyType)
+
+	assert.Nil(t, os.Remove(filepath.Join(dir, "2024-01.pem")))
+	assert.Nil(t, keySet.Reload())
+
+	_, err = ParseJWT(oldToken)
+	assert.NotNil(t, err)
+}
+
+func TestKeySetIgnoresFutureKeys(t *testing.T) {
+	dir := t.TempDir()
+
+	_, current, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	writeTestKey(t, dir, "current", current, time.Now().Add(-time.Hour))
+
+	_, next, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	writeTestKey(t, dir, "next", next, time.Now().Add(time.Hour))
+
+	keySet, err := LoadKeySet(dir)
+	assert.Nil(t, err)
+
+	active, err := keySet.ActiveKey()
+	assert.Nil(t, err)
+	assert.Equal(t, "current", active.ID)
+	assert.Len(t, keySet.JWKS().Keys, 2)
+}
@@ -1,8 +1,13 @@
 package util
 
 import (
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
 	"math/rand"
 	"strconv"
+	"strings"
 )
 
 func GenerateAccountNumber() string {
@@ -17,3 +22,43 @@ func GenerateAccountNumber() string {
 
 	return accountNumber
 }
+
+// GenerateToken returns a URL-safe random string carrying n bytes of entropy.
+func GenerateToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := crand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf)), nil
+}
+
+// HashToken returns the hex SHA-256 digest of token. Tokens are random and
+// high-entropy, so a fast hash is enough to keep them out of the database.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+const apiKeyPrefix = "gbk_"
+
+// GenerateAPIKey returns a new API key and the short public prefix used to
+// recognise it without revealing the secret part.
+func GenerateAPIKey() (string, string, error) {
+	id, err := GenerateToken(5)
+	if err != nil {
+		return "", "", err
+	}
+
+	secret, err := GenerateToken(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	prefix := apiKeyPrefix + id
+	return prefix + "_" + secret, prefix, nil
+}
+
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, apiKeyPrefix)
+}
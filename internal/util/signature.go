@@ -0,0 +1,59 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// SignDocument signs payload with the active key of the default key set and
+// returns the key ID and the base64url signature.
+func SignDocument(payload []byte) (string, string, error) {
+	keySet, err := DefaultKeySet()
+	if err != nil {
+		return "", "", err
+	}
+
+	key, err := keySet.ActiveKey()
+	if err != nil {
+		return "", "", err
+	}
+
+	signature, err := key.Method.Sign(string(payload), key.PrivateKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	return key.ID, base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// VerifyDocument checks a signature made by SignDocument.
+func VerifyDocument(payload []byte, kid string, signature string) error {
+	keySet, err := DefaultKeySet()
+	if err != nil {
+		return err
+	}
+
+	key, ok := keySet.Key(kid)
+	if !ok {
+		return fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("malformed signature")
+	}
+
+	return key.Method.Verify(string(payload), raw, key.PublicKey())
+}
+
+// SignWebhook returns the hex HMAC-SHA256 of "timestamp.body" under secret.
+// Including the timestamp lets receivers reject replayed deliveries.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,41 @@
+package util
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignDocument(t *testing.T) {
+	dir := t.TempDir()
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	writeTestKey(t, dir, "2024-01", edKey, time.Now())
+
+	keySet, err := LoadKeySet(dir)
+	assert.Nil(t, err)
+	previous, err := DefaultKeySet()
+	assert.Nil(t, err)
+	SetDefaultKeySet(keySet)
+	t.Cleanup(func() { SetDefaultKeySet(previous) })
+
+	kid, signature, err := SignDocument([]byte("checkpoint"))
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-01", kid)
+
+	assert.Nil(t, VerifyDocument([]byte("checkpoint"), kid, signature))
+	assert.NotNil(t, VerifyDocument([]byte("tampered"), kid, signature))
+	assert.NotNil(t, VerifyDocument([]byte("checkpoint"), "unknown", signature))
+}
+
+func TestSignWebhook(t *testing.T) {
+	signature := SignWebhook("secret", 1700000000, []byte(`{"id":"1"}`))
+	assert.Len(t, signature, 64)
+	assert.Equal(t, signature, SignWebhook("secret", 1700000000, []byte(`{"id":"1"}`)))
+	assert.NotEqual(t, signature, SignWebhook("secret", 1700000001, []byte(`{"id":"1"}`)))
+	assert.NotEqual(t, signature, SignWebhook("other", 1700000000, []byte(`{"id":"1"}`)))
+}
@@ -0,0 +1,189 @@
+package bank
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FaizanAC/Go-Banking/internal/models"
+	"github.com/FaizanAC/Go-Banking/internal/server"
+	"github.com/FaizanAC/Go-Banking/internal/server/services"
+	"github.com/FaizanAC/Go-Banking/tests/integration/testdb"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func request(t *testing.T, r *gin.Engine, method string, path string, token string, body string) (int, map[string]interface{}) {
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	return w.Code, response
+}
+
+// signUp registers a user with a verified email and returns their token.
+func signUp(t *testing.T, r *gin.Engine, db *gorm.DB, email string) (models.User, string) {
+	code, _ := request(t, r, "POST", "/user", "", fmt.Sprintf(`{"email": %q, "firstName": "Test", "lastName": "User", "password": "password"}`, email))
+	assert.Equal(t, http.StatusCreated, code)
+
+	var user models.User
+	assert.Nil(t, db.Where("email = ?", email).First(&user).Error)
+	assert.Nil(t, db.Model(&user).Update("email_verified_at", time.Now()).Error)
+
+	req, _ := http.NewRequest("POST", "/login", strings.NewReader(fmt.Sprintf(`{"email": %q, "password": "password"}`, email)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == "token" {
+			return user, cookie.Value
+		}
+	}
+	t.Fatal("login did not set a token")
+	return user, ""
+}
+
+func TestDepositWithdrawAndTransfer(t *testing.T) {
+	db := testdb.Open(t)
+	r := server.NewServer(db, os.Getenv("PORT")).SetupRouter()
+
+	_, senderToken := signUp(t, r, db, "sender@example.com")
+	receiver, receiverToken := signUp(t, r, db, "receiver@example.com")
+
+	code, response := request(t, r, "POST", "/bank/new-account", senderToken, "")
+	assert.Equal(t, http.StatusCreated, code)
+	senderAccount := response["Account Number"].(string)
+
+	_, response = request(t, r, "POST", "/bank/new-account", receiverToken, "")
+	receiverAccount := response["Account Number"].(string)
+
+	code, response = request(t, r, "POST", "/bank/deposit", senderToken, fmt.Sprintf(`{"accountNumber": %q, "amount": 100}`, senderAccount))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 100.0, response["New Balance"])
+
+	code, response = request(t, r, "POST", "/bank/withdraw", senderToken, fmt.Sprintf(`{"accountNumber": %q, "amount": 30}`, senderAccount))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 70.0, response["New Balance"])
+
+	code, _ = request(t, r, "POST", "/bank/transfer/send", senderToken,
+		fmt.Sprintf(`{"accountNumber": %q, "receiverID": %d, "amount": 20}`, senderAccount, receiver.ID))
+	assert.Equal(t, http.StatusOK, code)
+
+	var transfer models.Transfer
+	assert.Nil(t, db.Where("receiver_id = ?", receiver.ID).First(&transfer).Error)
+
+	code, response = request(t, r, "POST", "/bank/transfer/accept", receiverToken,
+		fmt.Sprintf(`{"transactionID": %q, "accountNumber": %q}`, transfer.TransactionID, receiverAccount))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 20.0, response["New Balance"])
+
+	// Recent transactions per account use a window function.
+	code, response = request(t, r, "POST", "/graphql", senderToken, `{"query": "{ accounts { balance transactions(first: 2) { type amount sequence } } }"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Nil(t, response["errors"])
+	accounts := response["data"].(map[string]interface{})["accounts"].([]interface{})
+	assert.Len(t, accounts, 1)
+	account := accounts[0].(map[string]interface{})
+	assert.Equal(t, 50.0, account["balance"])
+	transactions := account["transactions"].([]interface{})
+	assert.Len(t, transactions, 2)
+	assert.Equal(t, 3.0, transactions[0].(map[string]interface{})["sequence"])
+
+	ledger := services.NewLedgerService(db)
+	verification, err := ledger.Verify("")
+	assert.Nil(t, err)
+	assert.True(t, verification.Valid)
+	assert.Equal(t, 4, verification.Transactions)
+
+	checkpoint, err := ledger.Checkpoint()
+	assert.Nil(t, err)
+	assert.Len(t, checkpoint.Heads, 2)
+	assert.Nil(t, ledger.VerifyCheckpoint(checkpoint))
+}
+
+func TestAuditLogIsAppendOnly(t *testing.T) {
+	db := testdb.Open(t)
+	r := server.NewServer(db, os.Getenv("PORT")).SetupRouter()
+	signUp(t, r, db, "user@example.com")
+
+	var entries int64
+	assert.Nil(t, db.Model(&models.AuditLog{}).Count(&entries).Error)
+	assert.NotZero(t, entries)
+
+	assert.ErrorContains(t, db.Exec("UPDATE audit_logs SET action = 'rewritten'").Error, "append-only")
+	assert.ErrorContains(t, db.Exec("DELETE FROM audit_logs").Error, "append-only")
+}
+
+func TestConstraintViolations(t *testing.T) {
+	db := testdb.Open(t)
+	r := server.NewServer(db, os.Getenv("PORT")).SetupRouter()
+	_, token := signUp(t, r, db, "user@example.com")
+
+	code, response := request(t, r, "POST", "/user", "", `{"email": "user@example.com", "firstName": "Test", "lastName": "User", "password": "password"}`)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, "email is already in use", response["error"])
+
+	_, otherToken := signUp(t, r, db, "other@example.com")
+	code, response = request(t, r, "PATCH", "/user/me", otherToken, `{"email": "USER@example.com"}`)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, "email is already in use", response["error"])
+
+	code, _ = request(t, r, "PATCH", "/user/me", otherToken, `{"email": "not-an-email"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	_, response = request(t, r, "POST", "/bank/new-account", token, "")
+	accountNumber := response["Account Number"].(string)
+
+	_, response = request(t, r, "POST", "/bank/deposit", token, fmt.Sprintf(`{"accountNumber": %q, "amount": 10}`, accountNumber))
+	assert.Equal(t, 10.0, response["New Balance"])
+
+	_, response = request(t, r, "POST", "/bank/deposit", token, fmt.Sprintf(`{"accountNumber": %q, "amount": -5}`, accountNumber))
+	assert.Equal(t, "amount must be positive", response["error"])
+
+	_, response = request(t, r, "POST", "/bank/transfer/send", token, fmt.Sprintf(`{"accountNumber": %q, "receiverID": 999, "amount": 5}`, accountNumber))
+	assert.Equal(t, "receiver not found", response["error"])
+
+	var account models.BankAccount
+	assert.Nil(t, db.Where("account_number = ?", accountNumber).First(&account).Error)
+	assert.Equal(t, 10.0, account.Balance)
+}
+
+func TestAPIKeyAllowlistIgnoresSpoofedForwardedFor(t *testing.T) {
+	db := testdb.Open(t)
+	r := server.NewServer(db, os.Getenv("PORT")).SetupRouter()
+
+	_, token := signUp(t, r, db, "keyholder@example.com")
+	code, response := request(t, r, "POST", "/user/api-keys", token, `{"name": "office", "scopes": ["accounts:read"], "allowedIps": ["203.0.113.7"]}`)
+	assert.Equal(t, http.StatusCreated, code)
+	key := response["key"].(string)
+
+	// The request arrives from 192.0.2.1, which is not a trusted proxy.
+	forwarded := func(r *gin.Engine) int {
+		req, _ := http.NewRequest("GET", "/bank/accounts", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("Authorization", "Bearer "+key)
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusUnauthorized, forwarded(r))
+
+	t.Setenv("TRUSTED_PROXIES", "192.0.2.1")
+	assert.Equal(t, http.StatusOK, forwarded(server.NewServer(db, os.Getenv("PORT")).SetupRouter()))
+}
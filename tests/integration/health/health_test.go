@@ -6,14 +6,14 @@ import (
 	"os"
 	"testing"
 
-	"github.com/FaizanAC/Go-Banking/internal/database"
 	"github.com/FaizanAC/Go-Banking/internal/server"
+	"github.com/FaizanAC/Go-Banking/tests/integration/testdb"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestPingRoute(t *testing.T) {
 	s := server.NewServer(
-		database.NewDatabase(), os.Getenv("PORT"),
+		testdb.Open(t), os.Getenv("PORT"),
 	)
 	r := s.SetupRouter()
 
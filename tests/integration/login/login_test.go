@@ -3,23 +3,30 @@ package login
 import (
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 
-	"github.com/FaizanAC/Go-Banking/internal/database"
 	"github.com/FaizanAC/Go-Banking/internal/models"
 	"github.com/FaizanAC/Go-Banking/internal/server"
+	"github.com/FaizanAC/Go-Banking/internal/server/services"
+	"github.com/FaizanAC/Go-Banking/tests/integration/testdb"
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 func TestLoginWithValidAccount(t *testing.T) {
 	userPassword, err := bcrypt.GenerateFromPassword([]byte("password"), 10)
 	assert.Nil(t, err)
 
-	db := database.NewDatabase()
-	database.MigrateDB(db)
+	db := testdb.Open(t)
 	res := db.Create(&models.User{
 		Email:    "test@example.com",
 		Password: string(userPassword),
@@ -27,7 +34,7 @@ func TestLoginWithValidAccount(t *testing.T) {
 	assert.Nil(t, res.Error)
 
 	s := server.NewServer(
-		database.NewDatabase(), os.Getenv("PORT"),
+		db, os.Getenv("PORT"),
 	)
 	r := s.SetupRouter()
 
@@ -45,7 +52,7 @@ func TestLoginWithValidAccount(t *testing.T) {
 
 func TestLoginWithInvalidAccount(t *testing.T) {
 	s := server.NewServer(
-		database.NewDatabase(), os.Getenv("PORT"),
+		testdb.Open(t), os.Getenv("PORT"),
 	)
 	r := s.SetupRouter()
 
@@ -56,6 +63,116 @@ func TestLoginWithInvalidAccount(t *testing.T) {
 
 	r.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusNotFound, w.Code)
-	assert.Contains(t, w.Body.String(), "User not Found")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid email or password")
+}
+
+var mailedLinkPattern = regexp.MustCompile(`http://bank\.test(\S+)`)
+
+// followMailedLink opens the link in the last email the file mailer wrote and
+// returns the page it shows.
+func followMailedLink(t *testing.T, r *gin.Engine, dir string) string {
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	assert.NoError(t, err)
+	if !assert.NotEmpty(t, files) {
+		return ""
+	}
+	sort.Strings(files)
+
+	mail, err := os.ReadFile(files[len(files)-1])
+	assert.NoError(t, err)
+	match := mailedLinkPattern.FindSubmatch(mail)
+	if !assert.Len(t, match, 2) {
+		return ""
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", string(match[1]), nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	return w.Body.String()
+}
+
+var hiddenTokenPattern = regexp.MustCompile(`name="token" value="([^"]+)"`)
+
+// submitPage posts the page's form the way a browser would.
+func submitPage(t *testing.T, r *gin.Engine, path string, page string, fields url.Values) *httptest.ResponseRecorder {
+	match := hiddenTokenPattern.FindStringSubmatch(page)
+	if !assert.Len(t, match, 2) {
+		return httptest.NewRecorder()
+	}
+	fields.Set("token", match[1])
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", path, strings.NewReader(fields.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func login(r *gin.Engine, email string, password string) int {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/login", strings.NewReader(`{"email": "`+email+`", "password": "`+password+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func newMailingRouter(t *testing.T) (*gin.Engine, *gorm.DB, string) {
+	dir := t.TempDir()
+	t.Setenv("MAILER", "file")
+	t.Setenv("MAILER_DIR", dir)
+	t.Setenv("APP_BASE_URL", "http://bank.test")
+
+	userPassword, err := bcrypt.GenerateFromPassword([]byte("password"), 10)
+	assert.Nil(t, err)
+
+	db := testdb.Open(t)
+	assert.Nil(t, db.Create(&models.User{Email: "test@example.com", FirstName: "Test", Password: string(userPassword)}).Error)
+
+	return server.NewServer(db, os.Getenv("PORT")).SetupRouter(), db, dir
+}
+
+func TestPasswordResetLinkOpensAPage(t *testing.T) {
+	r, _, dir := newMailingRouter(t)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/password/forgot", strings.NewReader(`{"email": "test@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	page := followMailedLink(t, r, dir)
+	w = submitPage(t, r, "/password/reset", page, url.Values{"password": {"new password"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Password has been reset")
+
+	assert.Equal(t, http.StatusOK, login(r, "test@example.com", "new password"))
+}
+
+func TestUnlockLinkOpensAPage(t *testing.T) {
+	r, db, dir := newMailingRouter(t)
+
+	var user models.User
+	assert.Nil(t, db.Where("email = ?", "test@example.com").First(&user).Error)
+
+	// Backdate the earlier failures so the progressive delay has passed; the
+	// next one locks the account and sends the link.
+	for i := 0; i < 9; i++ {
+		assert.Nil(t, db.Create(&models.LoginAttempt{
+			GormModel: models.GormModel{CreatedAt: time.Now().Add(-time.Minute)},
+			Email:     user.Email, UserID: &user.ID, IP: "198.51.100.1", Reason: services.LOGIN_INVALID_CREDENTIALS,
+		}).Error)
+	}
+	assert.Equal(t, http.StatusUnauthorized, login(r, "test@example.com", "wrong password"))
+	assert.Equal(t, http.StatusTooManyRequests, login(r, "test@example.com", "password"))
+
+	page := followMailedLink(t, r, dir)
+	w := submitPage(t, r, "/login/unlock", page, url.Values{})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Account unlocked")
+
+	assert.Equal(t, http.StatusOK, login(r, "test@example.com", "password"))
 }
@@ -0,0 +1,30 @@
+// Package testdb opens the database for integration tests: the backend
+// configured by DB_DRIVER when it is set, otherwise a throwaway SQLite file
+// so the suite runs without any services.
+package testdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/FaizanAC/Go-Banking/internal/database"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func Open(t *testing.T) *gorm.DB {
+	if os.Getenv("DB_DRIVER") == "" {
+		t.Setenv("DB_DRIVER", database.DRIVER_SQLITE)
+		t.Setenv("SQLITE_PATH", filepath.Join(t.TempDir(), "test.db"))
+	}
+
+	db := database.NewDatabase()
+	assert.Nil(t, database.MigrateDB(db))
+
+	sqlDB, err := db.DB()
+	assert.Nil(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	return db
+}